@@ -7,7 +7,7 @@ import (
 	"harvest/internal/cache"
 	"harvest/internal/config"
 	"harvest/internal/database"
-	"harvest/internal/orchestrator"
+	"harvest/internal/events"
 	"harvest/internal/orchestrator/worker"
 	"harvest/internal/rabbitmq"
 	"harvest/internal/server"
@@ -69,24 +69,22 @@ func main() {
 	}
 	log.Info().Msg("Rabbit MQ connection established")
 
+	// Broadcast job progress to every instance so clients can stream it from any of them
+	jobEvents := events.NewRabbitJobEventBus(rabbit, cfg.RabbitMQ)
+	if err := jobEvents.Start(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Failed to start job event bus")
+		return
+	}
+	db = database.WithJobEvents(db, jobEvents)
+
 	// Initialize PUBG API client
 	pubgClient := pubg.New(cfg.PUBG, cache)
 	defer pubgClient.Close()
 	log.Info().Msg("PUBG API client initialized")
 
-	// Initialize base process registry for job workers
-
-	// Creating processors
-
-	playerWorker := worker.NewPlayerExpanderWorker(pubgClient, db)
-	matchWorker := worker.NewMatchExpanderWorker(pubgClient, db)
-	tournamentWorker := worker.NewTournamentExpanderWorker(pubgClient, db)
-	tournamentMatchWorker := worker.NewTournamentMatchExpanderWorker(pubgClient, db)
-	processMatchesWorker := worker.NewProcessMatchWorker(pubgClient, db)
-	rotationWorker := worker.NewRotationWorker(pubgClient, db)
-
-	// Registering processors
-	registry := orchestrator.NewWorkerRegistry(playerWorker, matchWorker, tournamentWorker, tournamentMatchWorker, processMatchesWorker, rotationWorker)
+	// Initialize process registry for job workers. In enqueue-only mode it is only used
+	// to validate job types and payloads
+	registry := worker.NewRegistry(pubgClient, db, cfg.Jobs)
 
 	// Create AWS File Service
 	fileService, err := aws.NewFileService(cfg.AWS.S3.AccessKeyID, cfg.AWS.S3.SecretAccessKey, cfg.AWS.S3.Bucket, cfg.AWS.Region)
@@ -95,8 +93,16 @@ func main() {
 		return
 	}
 
+	// Keep downloaded telemetry files so matches can be processed again without downloading them
+	telemetryArchive, err := pubg.NewTelemetryArchiveFromConfig(cfg.PUBG.TelemetryArchive, fileService)
+	if err != nil {
+		log.Error().Err(err).Msg("could not create telemetry archive")
+		return
+	}
+	pubgClient.SetTelemetryArchive(telemetryArchive)
+
 	// Create and start HTTP server
-	srv := server.New(*cfg, db, cache, rabbit, *pubgClient, registry, fileService)
+	srv := server.New(*cfg, db, cache, rabbit, jobEvents, *pubgClient, registry, fileService)
 
 	// Start the server in a goroutine to avoid blocking
 	go func() {
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"harvest/internal/aws"
+	"harvest/internal/cache"
+	"harvest/internal/config"
+	"harvest/internal/controller"
+	"harvest/internal/database"
+	"harvest/internal/events"
+	"harvest/internal/orchestrator/worker"
+	"harvest/internal/rabbitmq"
+	"harvest/pkg/pubg"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Worker consumes jobs from RabbitMQ without serving the HTTP API. Run several of them
+// next to an API started with "api_mode": "enqueue" to scale job processing.
+func main() {
+	// Load configuration
+	env := os.Getenv("ENV")
+	env_config_prefix := "dev"
+
+	if env != "" {
+		env_config_prefix = env
+	}
+
+	config_file := fmt.Sprintf("config/%v.config.json", env_config_prefix)
+	log.Info().Str("Environment", env_config_prefix).Str("Config File", config_file).Msg("loading config file")
+
+	cfg, err := config.LoadConfig(config_file)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load configuration")
+		return
+	}
+
+	// Configure logging
+	setupLogger(cfg.Logging)
+	log.Info().Msg("Starting PUBG Harvest worker")
+	log.Info().Str("environment", cfg.Env).Int("workerCount", cfg.Jobs.WorkerCount).Msg("Configuration loaded")
+
+	// Initialize MongoDB connection
+	db, err := database.New(cfg)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize database connection")
+		return
+	}
+	log.Info().Msg("Database connection established")
+
+	// Initialize Redis connection
+	cache, err := cache.NewRedisCache(cfg.Redis)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize redis cache connection")
+		return
+	}
+	log.Info().Msg("Redis connection established")
+
+	// Don't let the broker hand this worker more jobs than it can run at once
+	if cfg.Jobs.WorkerCount > 0 {
+		cfg.RabbitMQ.PrefetchCount = cfg.Jobs.WorkerCount
+	}
+
+	// Initialize RabbitMQ connection
+	rabbit, err := rabbitmq.NewClientFromConfig(cfg.RabbitMQ)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to intialize rabbit mq client")
+		return
+	}
+	defer rabbit.Close()
+	log.Info().Msg("Rabbit MQ connection established")
+
+	// Publish job progress so API instances can stream it to clients
+	jobEvents := events.NewRabbitJobEventBus(rabbit, cfg.RabbitMQ)
+	if err := jobEvents.Start(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Failed to start job event bus")
+		return
+	}
+	db = database.WithJobEvents(db, jobEvents)
+
+	// Initialize PUBG API client
+	pubgClient := pubg.New(cfg.PUBG, cache)
+	defer pubgClient.Close()
+	log.Info().Msg("PUBG API client initialized")
+
+	// The S3 archive backend needs the AWS File Service, which the worker doesn't use otherwise
+	var fileService aws.FileService
+	if cfg.PUBG.TelemetryArchive.Backend == pubg.TelemetryArchiveS3 {
+		fileService, err = aws.NewFileService(cfg.AWS.S3.AccessKeyID, cfg.AWS.S3.SecretAccessKey, cfg.AWS.S3.Bucket, cfg.AWS.Region)
+		if err != nil {
+			log.Error().Err(err).Msg("could not create AWS File Service")
+			return
+		}
+	}
+
+	// Keep downloaded telemetry files so matches can be processed again without downloading them
+	telemetryArchive, err := pubg.NewTelemetryArchiveFromConfig(cfg.PUBG.TelemetryArchive, fileService)
+	if err != nil {
+		log.Error().Err(err).Msg("could not create telemetry archive")
+		return
+	}
+	pubgClient.SetTelemetryArchive(telemetryArchive)
+
+	registry := worker.NewRegistry(pubgClient, db, cfg.Jobs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jc := controller.NewJobController(db, db, rabbit, jobEvents, cfg.RabbitMQ, cfg.Jobs, registry)
+	if err := jc.ProcessJobs(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to start job processing")
+		return
+	}
+
+	if err := jc.ResumeJobs(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to resume orphaned jobs")
+	}
+
+	// Block until we receive a signal
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	sig := <-quit
+	log.Info().Str("signal", sig.String()).Msg("Shutting down worker")
+
+	jc.StopProcessing()
+
+	log.Info().Msg("Worker exiting")
+}
+
+func setupLogger(config config.LoggingConfig) {
+	// Set global log level
+	level, err := zerolog.ParseLevel(config.Level)
+	if err != nil {
+		level = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(level)
+
+	// Configure logger output
+	switch config.Format {
+	case "json":
+		// JSON is the default for zerolog
+	case "console", "combined":
+		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
+	}
+
+	// Add timestamp
+	log.Logger = log.With().Timestamp().Logger()
+}
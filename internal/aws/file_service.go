@@ -2,19 +2,23 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/rs/zerolog/log"
 )
 
 type FileService interface {
 	GetAllFiles()
 	UploadFile(string, io.Reader) (string, error)
+	DownloadFile(string) (io.ReadCloser, error)
 	TestConnection() error
 }
 
@@ -69,6 +73,25 @@ func (s *fileService) UploadFile(fileName string, file io.Reader) (string, error
 	return imageURL, nil
 }
 
+// DownloadFile returns the contents of a file in the bucket. A missing file is returned as an
+// error matching fs.ErrNotExist.
+func (s *fileService) DownloadFile(fileName string) (io.ReadCloser, error) {
+	output, err := s.s3.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fileName),
+	})
+
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, fmt.Errorf("%w: %s", fs.ErrNotExist, fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Body, nil
+}
+
 func (s *fileService) GetAllFiles() {
 	// Get the first page of results for ListObjectsV2 for a bucket
 	output, err := s.s3.ListObjectsV2(context.TODO(), &s3.ListObjectsV2Input{
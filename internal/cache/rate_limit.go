@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// How long an idle rate limit bucket is kept before Redis drops it
+const rateLimitBucketTTL = 10 * time.Minute
+
+// takeTokenScript refills the bucket for the time passed since the last call and takes a token.
+// Returns 0 when a token was taken, otherwise the milliseconds until one is available.
+var takeTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated_at', 'paused_until')
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+local paused = tonumber(state[3]) or 0
+
+if paused > now then
+	return paused - now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated_at', now)
+redis.call('PEXPIRE', KEYS[1], ttl)
+return wait
+`)
+
+// limitTokensScript lowers the tokens left in the bucket and pauses it until the given time
+var limitTokensScript = redis.NewScript(`
+local remaining = tonumber(ARGV[1])
+local paused_until = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'paused_until')
+local tokens = tonumber(state[1])
+local paused = tonumber(state[2]) or 0
+
+if remaining >= 0 and (tokens == nil or remaining < tokens) then
+	redis.call('HSET', KEYS[1], 'tokens', remaining, 'updated_at', now)
+end
+
+if paused_until > paused then
+	redis.call('HSET', KEYS[1], 'paused_until', paused_until)
+end
+
+redis.call('PEXPIRE', KEYS[1], ttl)
+return 0
+`)
+
+// TakeRateLimitToken takes a token from a token bucket shared by every process using the same
+// Redis. Returns how long to wait before trying again when the bucket is empty.
+func (c *RedisCache) TakeRateLimitToken(ctx context.Context, key string, perMinute int, burst int) (time.Duration, error) {
+	formattedKey := c.formatKey(key)
+	ratePerMs := float64(perMinute) / float64(time.Minute.Milliseconds())
+
+	wait, err := takeTokenScript.Run(ctx, c.client, []string{formattedKey},
+		ratePerMs, burst, time.Now().UnixMilli(), rateLimitBucketTTL.Milliseconds()).Int64()
+	if err != nil {
+		log.Error().Err(err).Str("key", formattedKey).Msg("Error taking rate limit token from Redis")
+		return 0, err
+	}
+
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+// LimitRateLimitTokens caps the tokens left in a shared bucket and pauses it until pausedUntil.
+// A negative remaining leaves the tokens alone and a zero pausedUntil doesn't pause.
+func (c *RedisCache) LimitRateLimitTokens(ctx context.Context, key string, remaining int, pausedUntil time.Time) error {
+	formattedKey := c.formatKey(key)
+
+	var pausedUntilMs int64
+	if !pausedUntil.IsZero() {
+		pausedUntilMs = pausedUntil.UnixMilli()
+	}
+
+	err := limitTokensScript.Run(ctx, c.client, []string{formattedKey},
+		remaining, pausedUntilMs, time.Now().UnixMilli(), rateLimitBucketTTL.Milliseconds()).Err()
+	if err != nil {
+		log.Error().Err(err).Str("key", formattedKey).Msg("Error limiting rate limit tokens in Redis")
+		return err
+	}
+
+	return nil
+}
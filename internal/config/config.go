@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 )
 
 // Config represents the entire application configuration
@@ -54,14 +55,39 @@ type CORSConfig struct {
 // PUBGConfig contains PUBG API-related configurations
 type PUBGConfig struct {
 	APIKey            string            `json:"api_key"`
+	APIKeys           []string          `json:"api_keys"` // Additional keys, requests are spread over all of them
 	BaseURL           string            `json:"base_url"`
 	Shards            map[string]string `json:"shards"`
 	RequestDelay      int               `json:"request_delay"`
 	MaxRetries        int               `json:"max_retries"`
 	RequestsPerMinute int               `json:"requests_per_minute"`
+	RateLimitBurst    int               `json:"rate_limit_burst"`  // Requests that may be made at once after an idle period, defaults to 1
+	SharedRateLimit   bool              `json:"shared_rate_limit"` // Share the requests per minute with every replica through Redis
 	OldEnoughMin      int               `json:"old_enough_min"`
 	Cache             bool              `json:"cache"`
 	DefaultCacheTTL   int               `json:"default_cache_ttl"`
+
+	TelemetryArchive TelemetryArchiveConfig `json:"telemetry_archive"`
+}
+
+// TelemetryArchiveConfig controls where downloaded telemetry files are archived
+type TelemetryArchiveConfig struct {
+	Backend   string `json:"backend"`   // "local", "s3" or empty to download telemetry every time
+	Directory string `json:"directory"` // Root directory of the local backend, defaults to data
+	Prefix    string `json:"prefix"`    // Path of the files within the backend, defaults to telemetry
+}
+
+// Keys returns every configured API key once, api_key first
+func (c PUBGConfig) Keys() []string {
+	keys := make([]string, 0, len(c.APIKeys)+1)
+	seen := make(map[string]bool)
+	for _, key := range append([]string{c.APIKey}, c.APIKeys...) {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
 
 // MongoDBConfig contains MongoDB connection details
@@ -85,19 +111,139 @@ type RabbitMQConfig struct {
 	RoutingKey    string `json:"routing_key"`
 	PrefetchCount int    `json:"prefetch_count"`
 	MaxRetries    int    `json:"max_retries"`
+
+	// Fanout exchange used to broadcast job progress events to every API instance
+	EventsExchange string `json:"events_exchange"`
+
+	// Rejected job messages are routed here instead of being dropped
+	DeadLetterExchange string `json:"dead_letter_exchange"`
+	DeadLetterQueue    string `json:"dead_letter_queue"`
 }
 
+// API modes for job processing
+const (
+	APIModeProcess     = "process" // the API enqueues and runs jobs in-process (default)
+	APIModeEnqueueOnly = "enqueue" // the API only enqueues, jobs are run by cmd/worker
+)
+
 // JobsConfig contains job processing settings
 type JobsConfig struct {
-	WorkerCount      int             `json:"worker_count"`
+	WorkerCount      int             `json:"worker_count"` // Maximum number of jobs a process runs at once, 0 for no limit
 	DefaultBatchSize int             `json:"default_batch_size"`
 	JobTypes         []JobTypeConfig `json:"job_types"`
+	APIMode          string          `json:"api_mode"`
+	Queues           []string        `json:"queues"` // Job queues this process consumes, empty for all of them
+	Retention        RetentionConfig `json:"retention"`
+
+	// A processing job whose instance hasn't sent a heartbeat for this long is resumed on another
+	// instance, defaults to 15
+	HeartbeatTimeoutMinutes int `json:"heartbeat_timeout_minutes"`
+
+	// Players that land further than this many metres from every drop spot of the map aren't
+	// counted at any drop spot, defaults to 500
+	DropSpotRadiusMetres int `json:"drop_spot_radius_metres"`
+
+	// A job message returned to the queue this many times because its job type kept running
+	// elsewhere is dead-lettered, defaults to 240 (an hour)
+	MaxLeaseRequeues int `json:"max_lease_requeues"`
+}
+
+// LeaseRequeueLimit returns how often a job message may wait for the lease on its job type
+func (c JobsConfig) LeaseRequeueLimit() int {
+	if c.MaxLeaseRequeues > 0 {
+		return c.MaxLeaseRequeues
+	}
+	return 240
+}
+
+// DropSpotRadius returns how far from a drop spot a landing still counts as landing there, in
+// telemetry world units (centimetres)
+func (c JobsConfig) DropSpotRadius() float64 {
+	metres := c.DropSpotRadiusMetres
+	if metres <= 0 {
+		metres = 500
+	}
+	return float64(metres) * 100
+}
+
+// HeartbeatTimeout returns how long a job may go without a heartbeat
+func (c JobsConfig) HeartbeatTimeout() time.Duration {
+	if c.HeartbeatTimeoutMinutes > 0 {
+		return time.Duration(c.HeartbeatTimeoutMinutes) * time.Minute
+	}
+	return 15 * time.Minute
+}
+
+// MaxRuntime returns how long a job of the type may run, 0 for no limit
+func (c JobsConfig) MaxRuntime(jobType string) time.Duration {
+	return time.Duration(c.JobTypeConfig(jobType).MaxRuntimeMinutes) * time.Minute
+}
+
+// RetentionConfig controls when finished jobs are moved to the jobs_archive collection
+type RetentionConfig struct {
+	ArchiveAfterHours      int `json:"archive_after_hours"`      // Age of a finished job before it is archived, 0 to keep jobs forever
+	ArchiveIntervalMinutes int `json:"archive_interval_minutes"` // How often old jobs are archived, defaults to 60
+}
+
+// Job queue used by job types that don't configure one
+const DefaultJobQueue = "default"
+
+// EnqueueOnly reports whether the API leaves job processing to separate worker processes
+func (c JobsConfig) EnqueueOnly() bool {
+	return c.APIMode == APIModeEnqueueOnly
+}
+
+// AllowsConcurrent reports whether jobs of the type may run while another job of the same type is running
+func (c JobsConfig) AllowsConcurrent(jobType string) bool {
+	return c.JobTypeConfig(jobType).Concurrent
+}
+
+// JobTypeConfig returns the configuration of a job type, or an empty config if it has none
+func (c JobsConfig) JobTypeConfig(jobType string) JobTypeConfig {
+	for _, jt := range c.JobTypes {
+		if jt.Type == jobType {
+			return jt
+		}
+	}
+	return JobTypeConfig{Type: jobType}
+}
+
+// QueueFor returns the job queue a job type is routed to
+func (c JobsConfig) QueueFor(jobType string) string {
+	if queue := c.JobTypeConfig(jobType).Queue; queue != "" {
+		return queue
+	}
+	return DefaultJobQueue
+}
+
+// ConsumedQueues returns the job queues this process consumes. Without an explicit list that is
+// the default queue and every queue a job type is routed to.
+func (c JobsConfig) ConsumedQueues() []string {
+	if len(c.Queues) > 0 {
+		return c.Queues
+	}
+
+	queues := []string{DefaultJobQueue}
+	seen := map[string]bool{DefaultJobQueue: true}
+	for _, jt := range c.JobTypes {
+		if jt.Queue != "" && !seen[jt.Queue] {
+			seen[jt.Queue] = true
+			queues = append(queues, jt.Queue)
+		}
+	}
+
+	return queues
 }
 
 // JobTypeConfig contains configuration for a specific job type
 type JobTypeConfig struct {
-	Type      string `json:"type"`
-	BatchSize int    `json:"batch_size"`
+	Type       string `json:"type"`
+	BatchSize  int    `json:"batch_size"` // Items per batch, jobs.default_batch_size if 0
+	Concurrent bool   `json:"concurrent"` // Allow several jobs of this type to run at once across all instances
+	Queue      string `json:"queue"`      // Job queue the type is routed to, "default" if empty
+	Priority   string `json:"priority"`   // Default priority of its jobs: low, normal or high
+
+	MaxRuntimeMinutes int `json:"max_runtime_minutes"` // Jobs running longer than this are failed, 0 for no limit
 }
 
 // LoggingConfig contains logging-related configurations
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"harvest/internal/database"
+	"harvest/internal/model"
+	"harvest/internal/rabbitmq"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reasons a job message is dead-lettered
+const (
+	DeadLetterInvalidMessage   = "invalid_message"
+	DeadLetterJobNotFound      = "job_not_found"
+	DeadLetterLookupFailed     = "job_lookup_failed"
+	DeadLetterUnknownType      = "unknown_job_type"
+	DeadLetterStatusFailed     = "status_update_failed"
+	DeadLetterAlreadyActive    = "job_already_active"
+	DeadLetterSpawnFailed      = "worker_spawn_failed"
+	DeadLetterLeaseUnavailable = "job_lease_unavailable"
+)
+
+// ErrDeadLetterJobFinished is returned when replaying a message of a job that has already finished
+var ErrDeadLetterJobFinished = errors.New("job of the dead-lettered message has already finished")
+
+// DeadLetterController inspects and manages job messages on the dead-letter queue
+type DeadLetterController interface {
+	// ListDeadLetters returns up to limit dead-lettered messages without removing them
+	ListDeadLetters(limit int) ([]model.DeadLetter, error)
+
+	// GetDeadLetter returns a single dead-lettered message, or nil if it doesn't exist
+	GetDeadLetter(messageID string) (*model.DeadLetter, error)
+
+	// ReplayDeadLetter sends a message back to the jobs queue, returning nil if it doesn't exist.
+	// Messages of jobs that have already finished are left on the queue with ErrDeadLetterJobFinished.
+	ReplayDeadLetter(ctx context.Context, messageID string) (*model.DeadLetter, error)
+
+	// DeleteDeadLetter removes a single message, returning nil if it doesn't exist
+	DeleteDeadLetter(messageID string) (*model.DeadLetter, error)
+
+	// PurgeDeadLetters removes every dead-lettered message and returns how many were removed
+	PurgeDeadLetters() (int, error)
+}
+
+type deadLetterController struct {
+	db           database.JobDatabase
+	rabbitClient rabbitmq.Client
+}
+
+// NewDeadLetterController creates a new dead-letter controller
+func NewDeadLetterController(db database.JobDatabase, rabbitClient rabbitmq.Client) DeadLetterController {
+	return &deadLetterController{
+		db:           db,
+		rabbitClient: rabbitClient,
+	}
+}
+
+func (c *deadLetterController) ListDeadLetters(limit int) ([]model.DeadLetter, error) {
+	messages, err := c.rabbitClient.PeekDeadLetters(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]model.DeadLetter, 0, len(messages))
+	for _, msg := range messages {
+		deadLetters = append(deadLetters, toDeadLetter(msg))
+	}
+
+	return deadLetters, nil
+}
+
+func (c *deadLetterController) GetDeadLetter(messageID string) (*model.DeadLetter, error) {
+	messages, err := c.rabbitClient.PeekDeadLetters(rabbitmq.MaxDeadLetterScan)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, msg := range messages {
+		if msg.MessageId == messageID {
+			deadLetter := toDeadLetter(msg)
+			return &deadLetter, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (c *deadLetterController) ReplayDeadLetter(ctx context.Context, messageID string) (*model.DeadLetter, error) {
+	current, err := c.GetDeadLetter(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current == nil {
+		return nil, nil
+	}
+
+	// The consumer drops messages of finished jobs, replaying one would only lose it
+	if jobID, err := primitive.ObjectIDFromHex(current.JobID); err == nil {
+		job, err := c.db.GetJobByID(ctx, jobID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job of dead-lettered message: %w", err)
+		}
+
+		if job != nil && job.Status.IsFinished() {
+			return current, ErrDeadLetterJobFinished
+		}
+	}
+
+	msg, err := c.rabbitClient.ReplayDeadLetter(messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay dead-lettered message: %w", err)
+	}
+
+	if msg == nil {
+		return nil, nil
+	}
+
+	deadLetter := toDeadLetter(*msg)
+	log.Info().
+		Str("messageId", messageID).
+		Str("jobId", deadLetter.JobID).
+		Str("reason", deadLetter.Reason).
+		Msg("Replayed dead-lettered job message")
+
+	return &deadLetter, nil
+}
+
+func (c *deadLetterController) DeleteDeadLetter(messageID string) (*model.DeadLetter, error) {
+	msg, err := c.rabbitClient.DeleteDeadLetter(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg == nil {
+		return nil, nil
+	}
+
+	deadLetter := toDeadLetter(*msg)
+	log.Info().Str("messageId", messageID).Msg("Deleted dead-lettered job message")
+
+	return &deadLetter, nil
+}
+
+func (c *deadLetterController) PurgeDeadLetters() (int, error) {
+	count, err := c.rabbitClient.PurgeDeadLetters()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Info().Int("count", count).Msg("Purged dead-letter queue")
+	return count, nil
+}
+
+// toDeadLetter converts a raw dead-lettered delivery into its API representation
+func toDeadLetter(msg amqp.Delivery) model.DeadLetter {
+	header := func(key string) string {
+		value, _ := msg.Headers[key].(string)
+		return value
+	}
+
+	deadLetter := model.DeadLetter{
+		MessageID:          msg.MessageId,
+		JobID:              header("job_id"),
+		JobType:            header("job_type"),
+		Reason:             header(rabbitmq.HeaderDeadLetterReason),
+		Error:              header(rabbitmq.HeaderDeadLetterError),
+		OriginalExchange:   header(rabbitmq.HeaderOriginalExchange),
+		OriginalRoutingKey: header(rabbitmq.HeaderOriginalRoutingKey),
+		Headers:            map[string]interface{}(msg.Headers),
+		Body:               string(msg.Body),
+	}
+
+	if deadLetteredAt, err := time.Parse(time.RFC3339, header(rabbitmq.HeaderDeadLetteredAt)); err == nil {
+		deadLetter.DeadLetteredAt = &deadLetteredAt
+	}
+
+	return deadLetter
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"harvest/internal/model"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReplayDeadLetter(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       model.JobStatus // Empty when the job doesn't exist
+		wantErr      error
+		wantReplayed bool
+	}{
+		{name: "queued job", status: model.StatusQueued, wantReplayed: true},
+		{name: "processing job", status: model.StatusProcessing, wantReplayed: true},
+		{name: "missing job", wantReplayed: true},
+		{name: "completed job", status: model.StatusCompleted, wantErr: ErrDeadLetterJobFinished},
+		{name: "failed job", status: model.StatusFailed, wantErr: ErrDeadLetterJobFinished},
+		{name: "cancelled job", status: model.StatusCancelled, wantErr: ErrDeadLetterJobFinished},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobID := primitive.NewObjectID()
+			db := newFakeJobDB()
+			if tt.status != "" {
+				db = newFakeJobDB(&model.Job{ID: jobID, Type: "test", Status: tt.status})
+			}
+
+			rabbit := &fakeRabbit{stored: []amqp.Delivery{{
+				MessageId: "message-1",
+				Headers:   amqp.Table{"job_id": jobID.Hex(), "job_type": "test"},
+			}}}
+			c := NewDeadLetterController(db, rabbit)
+
+			deadLetter, err := c.ReplayDeadLetter(context.Background(), "message-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReplayDeadLetter returned %v, want %v", err, tt.wantErr)
+			}
+			if deadLetter == nil || deadLetter.JobID != jobID.Hex() {
+				t.Errorf("dead letter = %+v, want the message of job %s", deadLetter, jobID.Hex())
+			}
+
+			replayed := len(rabbit.replayed) == 1
+			if replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+		})
+	}
+
+	t.Run("unknown message", func(t *testing.T) {
+		c := NewDeadLetterController(newFakeJobDB(), &fakeRabbit{})
+
+		deadLetter, err := c.ReplayDeadLetter(context.Background(), "message-1")
+		if err != nil || deadLetter != nil {
+			t.Errorf("ReplayDeadLetter = %+v, %v, want nil, nil", deadLetter, err)
+		}
+	})
+}
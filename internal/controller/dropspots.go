@@ -6,6 +6,9 @@ import (
 	"harvest/internal/database"
 	"harvest/internal/model"
 	"harvest/pkg/pubg"
+	"math"
+	"sort"
+	"time"
 )
 
 type DropSpotLocationController interface {
@@ -15,8 +18,19 @@ type DropSpotLocationController interface {
 	UpdateDropSpotLocation(ctx context.Context, id string, dropSpotLocation *model.DropSpotLocation) error
 	DeleteDropSpotLocation(ctx context.Context, id string) error
 	BulkUpsertDropSpotLocations(ctx context.Context, dropSpotLocations []model.DropSpotLocation) error
+	GetDropSpotStats(ctx context.Context, mapName string, filter DropSpotStatsFilter) (*model.MapDropSpotStats, error)
 }
 
+// DropSpotStatsFilter selects the matches drop spot stats are computed from
+type DropSpotStatsFilter struct {
+	MatchTypes []string
+	StartDate  *time.Time
+	EndBefore  *time.Time // Matches created at or after it are left out
+}
+
+// Upper bounds in metres of the distance from the plane path buckets, the last bucket is open ended
+var planePathBucketBounds = []float64{1000, 2000, 3000, 4000}
+
 type dropSpotLocationController struct {
 	db database.DropSpotLocationDatabase
 }
@@ -67,3 +81,159 @@ func (c *dropSpotLocationController) BulkUpsertDropSpotLocations(ctx context.Con
 
 	return c.db.BulkUpsertDropSpotLocations(ctx, dropSpotLocations)
 }
+
+// GetDropSpotStats works out how often each drop spot of a map was landed at and contested, the
+// average placement of the teams that landed there and how far from the plane path they were
+func (c *dropSpotLocationController) GetDropSpotStats(ctx context.Context, mapName string, filter DropSpotStatsFilter) (*model.MapDropSpotStats, error) {
+	if pubg.MAP_NAME_MAP[mapName] == "" {
+		return nil, fmt.Errorf("invalid map name: %s", mapName)
+	}
+
+	dropSpots, err := c.db.GetDropSpotLocationByMap(ctx, mapName)
+	if err != nil {
+		return nil, err
+	}
+
+	landings, err := c.db.GetDropSpotLandings(ctx, mapName, filter.MatchTypes, filter.StartDate, filter.EndBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every processed match counts towards the landing rate, not just those with a resolved landing
+	matchCount, err := c.db.CountDropSpotMatches(ctx, mapName, filter.MatchTypes, filter.StartDate, filter.EndBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	type spotTotals struct {
+		stats            *model.DropSpotStats
+		teamsByMatch     map[string]int
+		placementSum     int
+		placements       int
+		planePathSum     float64
+		planePathCounted int
+	}
+
+	totals := make(map[string]*spotTotals, len(dropSpots))
+	for _, spot := range dropSpots {
+		totals[spot.ID.Hex()] = &spotTotals{
+			stats: &model.DropSpotStats{
+				DropSpotID: spot.ID,
+				Names:      spot.Names,
+				X:          spot.X,
+				Y:          spot.Y,
+				PlanePath:  model.PlanePathDistribution{Buckets: newPlanePathBuckets()},
+			},
+			teamsByMatch: make(map[string]int),
+		}
+	}
+
+	for _, row := range landings {
+		if row.Landing.DropSpotID == nil {
+			continue
+		}
+		spot, ok := totals[row.Landing.DropSpotID.Hex()]
+		if !ok {
+			// Drop spot deleted since the match was processed
+			continue
+		}
+
+		spot.stats.Landings++
+		spot.teamsByMatch[row.MatchID]++
+
+		if row.Landing.Placement > 0 {
+			spot.placementSum += row.Landing.Placement
+			spot.placements++
+		}
+
+		if distance, ok := planePathDistance(row.PlanePath, row.Landing.X, row.Landing.Y); ok {
+			spot.planePathSum += distance
+			spot.planePathCounted++
+			addToPlanePathBucket(spot.stats.PlanePath.Buckets, distance)
+		}
+	}
+
+	result := &model.MapDropSpotStats{
+		MapName:   mapName,
+		Matches:   int(matchCount),
+		DropSpots: make([]model.DropSpotStats, 0, len(totals)),
+	}
+
+	for _, spot := range totals {
+		stats := spot.stats
+		stats.Matches = len(spot.teamsByMatch)
+		for _, teams := range spot.teamsByMatch {
+			if teams > 1 {
+				stats.ContestedMatches++
+			}
+		}
+
+		if stats.Matches > 0 {
+			stats.ContestRate = float64(stats.ContestedMatches) / float64(stats.Matches)
+			stats.AverageTeams = float64(stats.Landings) / float64(stats.Matches)
+		}
+		if result.Matches > 0 {
+			stats.LandingRate = float64(stats.Matches) / float64(result.Matches)
+		}
+		if spot.placements > 0 {
+			stats.AveragePlacement = float64(spot.placementSum) / float64(spot.placements)
+		}
+		if spot.planePathCounted > 0 {
+			stats.PlanePath.AverageDistance = spot.planePathSum / float64(spot.planePathCounted)
+		}
+
+		result.DropSpots = append(result.DropSpots, *stats)
+	}
+
+	// Most landed at first
+	sort.Slice(result.DropSpots, func(i, j int) bool {
+		if result.DropSpots[i].Landings != result.DropSpots[j].Landings {
+			return result.DropSpots[i].Landings > result.DropSpots[j].Landings
+		}
+		return result.DropSpots[i].DropSpotID.Hex() < result.DropSpots[j].DropSpotID.Hex()
+	})
+
+	return result, nil
+}
+
+// planePathDistance returns the distance in metres from a landing to the line the plane flew
+// along. Matches without a plane path are reported as not ok.
+func planePathDistance(path model.PlanePath, x, y float64) (float64, bool) {
+	dx, dy := path.EndX-path.StartX, path.EndY-path.StartY
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return 0, false
+	}
+
+	// Telemetry coordinates are in centimetres
+	distance := math.Abs(dy*(x-path.StartX)-dx*(y-path.StartY)) / length
+	return distance / 100, true
+}
+
+func newPlanePathBuckets() []model.DistanceBucket {
+	buckets := make([]model.DistanceBucket, 0, len(planePathBucketBounds)+1)
+
+	lower := 0.0
+	for _, upper := range planePathBucketBounds {
+		buckets = append(buckets, model.DistanceBucket{
+			Label:    fmt.Sprintf("%g-%gkm", lower/1000, upper/1000),
+			MinMetre: lower,
+			MaxMetre: upper,
+		})
+		lower = upper
+	}
+
+	return append(buckets, model.DistanceBucket{
+		Label:    fmt.Sprintf("%gkm+", lower/1000),
+		MinMetre: lower,
+	})
+}
+
+func addToPlanePathBucket(buckets []model.DistanceBucket, distance float64) {
+	for i := range buckets {
+		if buckets[i].MaxMetre == 0 || distance < buckets[i].MaxMetre {
+			buckets[i].Landings++
+			return
+		}
+	}
+}
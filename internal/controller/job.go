@@ -5,85 +5,227 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"harvest/internal/config"
 	"harvest/internal/database"
+	"harvest/internal/events"
 	"harvest/internal/model"
 	"harvest/internal/orchestrator"
 	"harvest/internal/rabbitmq"
+	"harvest/pkg/pubg"
 	"sync"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // JobController handles job operations
 type JobController interface {
 	// CreateJob creates a new job and enqueues it for processing
-	CreateJob(ctx context.Context, jobType string, payload interface{}, userID string) (*model.Job, error)
+	// An empty priority uses the default priority of the job type
+	CreateJob(ctx context.Context, jobType string, payload interface{}, priority model.JobPriority, dryRun bool, userID string) (*model.Job, error)
 
 	// ProcessJobs starts consuming and processing jobs
 	ProcessJobs(ctx context.Context) error
 
+	// ResumeJobs re-enqueues jobs left in processing by a previous run so they continue from their last checkpoint
+	ResumeJobs(ctx context.Context) error
+
 	// Get Available Job Types
 	GetAvailableJobTypes() map[string]orchestrator.BatchWorker
 
-	// Cancel Jobs by Type
-	CancelJob(string) error
+	// Cancel a job by ID
+	CancelJob(context.Context, string) error
+
+	// RetryJob creates a new job of the same type that only processes the items that failed in the given job
+	RetryJob(ctx context.Context, jobID string, tokenID string) (*model.Job, error)
+
+	// CreatePipeline validates a DAG of job steps and starts the steps that have no dependencies
+	CreatePipeline(ctx context.Context, name string, steps []model.PipelineStep, tokenID string) (*model.Pipeline, error)
+
+	// GetPipeline returns a pipeline with the status of each step
+	GetPipeline(ctx context.Context, id string) (*model.Pipeline, error)
+
+	// ListPipelines returns all pipelines
+	ListPipelines(ctx context.Context) ([]model.Pipeline, error)
+
+	// CancelPipeline cancels the running steps of a pipeline and skips the rest
+	CancelPipeline(ctx context.Context, id string) error
 
 	// StopProcessing stops the job processing
 	StopProcessing()
 
-	// List all the jobs
-	ListJobs(context.Context) ([]model.Job, error)
+	// ListJobs returns a page of jobs matching the filter with the total number of matches
+	ListJobs(ctx context.Context, filter model.JobFilter) ([]model.Job, int64, error)
+
+	// StartArchiver moves old finished jobs to the archive in the background
+	StartArchiver(ctx context.Context)
 
 	GetJob(context.Context, string) (*model.Job, error)
+
+	// GetJobItems returns a page of per-item results for a job, optionally filtered by result type
+	GetJobItems(ctx context.Context, jobID string, result model.JobResultType, page, size int) ([]model.JobItem, int64, error)
 }
 
+const (
+	// Delay before the first automatic retry of a job, doubled for every further attempt
+	baseRetryDelay = 30 * time.Second
+	maxRetryDelay  = 15 * time.Minute
+
+	// How often a running job checks its document for a cancel request, in case the broadcast was missed
+	cancelPollInterval = 30 * time.Second
+
+	// How long a job whose type is running elsewhere stays off the queue before it is tried again
+	jobLeaseRetryDelay = 15 * time.Second
+
+	// Prefix of the job queue names when RabbitMQConfig.QueueName is not set
+	defaultJobQueuePrefix = "jobs"
+)
+
 // jobController implements JobController
 type jobController struct {
 	db              database.JobDatabase
+	pipelineDB      database.PipelineDatabase
 	rabbitClient    rabbitmq.Client
+	jobEvents       events.JobEventBus
 	rabbitConfig    config.RabbitMQConfig
 	jobsConfig      config.JobsConfig
 	processRegistry orchestrator.WorkerRegistry
-	consumerTag     string
+	workerID        string
+	slots           chan struct{} // Limits concurrent jobs to JobsConfig.WorkerCount, nil for no limit
+	declaredQueues  sync.Map      // Job queues already declared and bound by this process
+	timedOut        sync.Map      // Reasons of local jobs stopped by the watchdog, keyed by job ID
 	shutdown        chan struct{}
 	wg              sync.WaitGroup
 }
 
 // NewJobController creates a new job controller
-func NewJobController(db database.JobDatabase, rabbitClient rabbitmq.Client,
+func NewJobController(db database.JobDatabase, pipelineDB database.PipelineDatabase, rabbitClient rabbitmq.Client, jobEvents events.JobEventBus,
 	rabbitConfig config.RabbitMQConfig, jobsConfig config.JobsConfig, registry orchestrator.WorkerRegistry) JobController {
+	var slots chan struct{}
+	if jobsConfig.WorkerCount > 0 {
+		slots = make(chan struct{}, jobsConfig.WorkerCount)
+	}
+
 	return &jobController{
 		db:              db,
+		pipelineDB:      pipelineDB,
 		rabbitClient:    rabbitClient,
+		jobEvents:       jobEvents,
 		rabbitConfig:    rabbitConfig,
 		jobsConfig:      jobsConfig,
 		processRegistry: registry,
+		workerID:        orchestrator.InstanceID(),
+		slots:           slots,
 		shutdown:        make(chan struct{}),
 	}
 }
 
-func (c *jobController) CancelJob(jobType string) error {
-	worker, ok := c.processRegistry.Get(jobType)
-	jobID := worker.ActiveJobID()
-	if !ok {
-		return fmt.Errorf("job type does not exist: %v", jobType)
+// CancelJob cancels a running or queued job by ID
+func (c *jobController) CancelJob(ctx context.Context, jobID string) error {
+	jobIDPrim, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return err
 	}
 
-	if !worker.IsActive() {
-		return fmt.Errorf("job type is not active: %v", jobType)
+	job, err := c.db.GetJobByID(ctx, jobIDPrim)
+	if err != nil {
+		return err
 	}
 
-	err := worker.Cancel()
-	if err != nil {
+	if job == nil {
+		return mongo.ErrNoDocuments
+	}
+
+	// A processing job may be running on any instance, so the request is stored on the job and
+	// broadcast. The instance running it stops the job and sets the cancelled status.
+	if job.Status == model.StatusProcessing {
+		requested, err := c.db.RequestJobCancel(ctx, jobIDPrim)
+		if err != nil {
+			return err
+		}
+
+		if !requested {
+			return fmt.Errorf("job is no longer processing")
+		}
+
+		if _, active := c.processRegistry.ActiveWorker(jobIDPrim); active {
+			c.processRegistry.CancelJob(jobIDPrim)
+		}
+
+		log.Info().Str("jobId", jobID).Msg("Job cancel requested")
+		return nil
+	}
+
+	// Queued and retrying jobs are dropped by the consumer once they are marked cancelled
+	if job.Status != model.StatusQueued && job.Status != model.StatusRetrying {
+		return fmt.Errorf("job is not active: %v", job.Status)
+	}
+
+	if err := c.db.UpdateJobStatus(ctx, jobIDPrim, model.StatusCancelled); err != nil {
 		return err
 	}
 
-	return c.db.UpdateJobStatus(context.TODO(), *jobID, model.StatusCancelled)
+	c.updatePipelineStep(ctx, job, model.StatusCancelled, nil)
+	return nil
+}
+
+// RetryJob enqueues a job that processes again only the items that failed in a finished job
+func (c *jobController) RetryJob(ctx context.Context, jobID string, tokenID string) (*model.Job, error) {
+	jobIDPrim, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := c.db.GetJobByID(ctx, jobIDPrim)
+	if err != nil {
+		return nil, err
+	}
+
+	if original == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	switch original.Status {
+	case model.StatusQueued, model.StatusProcessing, model.StatusRetrying:
+		return nil, fmt.Errorf("job is still running: %v", original.Status)
+	}
+
+	if _, ok := c.processRegistry.Get(original.Type); !ok {
+		return nil, fmt.Errorf("job type not found in registry: %v", original.Type)
+	}
+
+	items, err := c.db.GetFailedJobItems(ctx, jobIDPrim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed items: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("job has no failed items to retry")
+	}
+
+	// The payload was validated when the original job was created
+	job := c.newJob(original.Type, original.Payload, tokenID)
+	job.RetryOf = &original.ID
+	job.RetryItems = items
+	job.DryRun = original.DryRun
+
+	if err := c.submitJob(ctx, job); err != nil {
+		return job, err
+	}
+
+	log.Info().
+		Str("jobId", job.ID.Hex()).
+		Str("retryOf", original.ID.Hex()).
+		Str("jobType", job.Type).
+		Int("items", len(items)).
+		Msg("Retry job created and enqueued")
+
+	return job, nil
 }
 
 func (c *jobController) GetJob(ctx context.Context, jobID string) (*model.Job, error) {
@@ -94,24 +236,67 @@ func (c *jobController) GetJob(ctx context.Context, jobID string) (*model.Job, e
 	return c.db.GetJobByID(ctx, jobIDPrim)
 }
 
+func (c *jobController) GetJobItems(ctx context.Context, jobID string, result model.JobResultType, page, size int) ([]model.JobItem, int64, error) {
+	jobIDPrim, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return c.db.ListJobItems(ctx, jobIDPrim, result, page, size)
+}
+
 // ListJob implements JobController.
-func (c *jobController) ListJobs(ctx context.Context) ([]model.Job, error) {
-	return c.db.ListJobs(ctx)
+func (c *jobController) ListJobs(ctx context.Context, filter model.JobFilter) ([]model.Job, int64, error) {
+	return c.db.ListJobs(ctx, filter)
 }
 
 // CreateJob creates a new job and enqueues it
-func (c *jobController) CreateJob(ctx context.Context, jobType string, payload interface{}, tokenID string) (*model.Job, error) {
+func (c *jobController) CreateJob(ctx context.Context, jobType string, payload interface{}, priority model.JobPriority, dryRun bool, tokenID string) (*model.Job, error) {
 	processor, ok := c.processRegistry.Get(jobType)
 	if !ok {
 		return nil, fmt.Errorf("job type not found in registry: %v", jobType)
 	}
 
-	if processor.IsActive() {
-		return nil, fmt.Errorf("job type already active in registry cannot start")
+	if err := processor.PayloadSchema().Validate(jobType, payload); err != nil {
+		return nil, err
+	}
+
+	if priority != "" && !priority.IsValid() {
+		return nil, fmt.Errorf("invalid job priority: %v", priority)
 	}
 
-	// Create a new job
-	job := &model.Job{
+	if dryRun && !processor.SupportsDryRun() {
+		return nil, fmt.Errorf("job type does not support dry runs: %v", jobType)
+	}
+
+	job := c.newJob(jobType, payload, tokenID)
+	if priority != "" {
+		job.Priority = priority
+	}
+	job.DryRun = dryRun
+
+	if err := c.submitJob(ctx, job); err != nil {
+		return job, err
+	}
+
+	log.Info().
+		Str("jobId", job.ID.Hex()).
+		Str("jobType", jobType).
+		Str("queue", job.Queue).
+		Str("priority", string(job.Priority)).
+		Bool("dryRun", job.DryRun).
+		Msg("Job created and enqueued")
+
+	return job, nil
+}
+
+// newJob builds a queued job record for the given type
+func (c *jobController) newJob(jobType string, payload interface{}, tokenID string) *model.Job {
+	priority := model.JobPriority(c.jobsConfig.JobTypeConfig(jobType).Priority)
+	if !priority.IsValid() {
+		priority = model.PriorityNormal
+	}
+
+	return &model.Job{
 		ID:        primitive.NewObjectID(),
 		Type:      jobType,
 		Status:    model.StatusQueued,
@@ -120,6 +305,8 @@ func (c *jobController) CreateJob(ctx context.Context, jobType string, payload i
 		UpdatedAt: time.Now(),
 		TokenID:   tokenID,
 		BatchSize: getBatchSize(c.jobsConfig, jobType),
+		Queue:     c.jobsConfig.QueueFor(jobType),
+		Priority:  priority,
 		Metrics: model.JobMetrics{
 			ProcessedItems:  0,
 			SuccessCount:    0,
@@ -130,11 +317,14 @@ func (c *jobController) CreateJob(ctx context.Context, jobType string, payload i
 			TotalBatches:    0,
 		},
 	}
+}
 
+// submitJob saves a job and publishes it to the queue
+func (c *jobController) submitJob(ctx context.Context, job *model.Job) error {
 	// Save the job to the database
 	err := c.db.CreateJob(ctx, job)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create job: %w", err)
+		return fmt.Errorf("failed to create job: %w", err)
 	}
 
 	// Enqueue the job
@@ -142,21 +332,23 @@ func (c *jobController) CreateJob(ctx context.Context, jobType string, payload i
 	if err != nil {
 		// Update job status to failed if enqueueing fails
 		c.db.UpdateJobStatus(ctx, job.ID, model.StatusFailed)
-		return job, fmt.Errorf("failed to enqueue job: %w", err)
+		return fmt.Errorf("failed to enqueue job: %w", err)
 	}
 
-	log.Info().
-		Str("jobId", job.ID.Hex()).
-		Str("jobType", jobType).
-		Msg("Job created and enqueued")
-
-	return job, nil
+	return nil
 }
 
-// enqueueJob publishes a job to RabbitMQ
+// enqueueJob publishes a job to the RabbitMQ queue of its job type
 func (c *jobController) enqueueJob(job *model.Job) error {
-	// Single queue for all jobs
-	queueName := c.rabbitConfig.QueueName
+	// Jobs created before job queues existed are routed by their type
+	queue := job.Queue
+	if queue == "" {
+		queue = c.jobsConfig.QueueFor(job.Type)
+	}
+	queueName, err := c.declareJobQueue(queue)
+	if err != nil {
+		return err
+	}
 
 	// Create message headers
 	headers := amqp.Table{
@@ -176,11 +368,12 @@ func (c *jobController) enqueueJob(job *model.Job) error {
 	}
 
 	// Publish the message to RabbitMQ
-	err = c.rabbitClient.Publish(
+	err = c.rabbitClient.PublishWithPriority(
 		c.rabbitConfig.ExchangeName,
 		queueName, // Using queue name as routing key
 		messageBytes,
 		headers,
+		job.Priority.MessagePriority(),
 	)
 
 	if err != nil {
@@ -190,6 +383,39 @@ func (c *jobController) enqueueJob(job *model.Job) error {
 	return nil
 }
 
+// jobQueueName returns the RabbitMQ queue name of a job queue, e.g. "jobs.default"
+func (c *jobController) jobQueueName(queue string) string {
+	prefix := c.rabbitConfig.QueueName
+	if prefix == "" {
+		prefix = defaultJobQueuePrefix
+	}
+	return fmt.Sprintf("%s.%s", prefix, queue)
+}
+
+// declareJobQueue declares and binds a job queue the first time it is used, so jobs published
+// before any worker consumes the queue aren't dropped
+func (c *jobController) declareJobQueue(queue string) (string, error) {
+	queueName := c.jobQueueName(queue)
+	if _, declared := c.declaredQueues.Load(queueName); declared {
+		return queueName, nil
+	}
+
+	if err := c.rabbitClient.DeclareExchange(c.rabbitConfig.ExchangeName, "direct"); err != nil {
+		return "", fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	if _, err := c.rabbitClient.DeclarePriorityQueue(queueName, model.MaxMessagePriority); err != nil {
+		return "", fmt.Errorf("failed to declare queue %s: %w", queueName, err)
+	}
+
+	if err := c.rabbitClient.BindQueue(queueName, c.rabbitConfig.ExchangeName, queueName); err != nil {
+		return "", fmt.Errorf("failed to bind queue %s: %w", queueName, err)
+	}
+
+	c.declaredQueues.Store(queueName, struct{}{})
+	return queueName, nil
+}
+
 // ProcessJobs starts consuming jobs from RabbitMQ
 func (c *jobController) ProcessJobs(ctx context.Context) error {
 	// Check if we have any registered processors
@@ -197,32 +423,43 @@ func (c *jobController) ProcessJobs(ctx context.Context) error {
 		return fmt.Errorf("no job processors registered")
 	}
 
-	// Single queue for all jobs
-	queueName := "jobs"
-
 	// Ensure the exchange exists
 	err := c.rabbitClient.DeclareExchange(c.rabbitConfig.ExchangeName, "direct")
 	if err != nil {
 		return fmt.Errorf("failed to declare exchange: %w", err)
 	}
 
-	// Ensure the queue exists
-	queue, err := c.rabbitClient.DeclareQueue(queueName)
-	if err != nil {
-		return fmt.Errorf("failed to declare queue %s: %w", queueName, err)
+	consumerID := primitive.NewObjectID().Hex()
+
+	// Each job queue has its own consumer, so a queue of long jobs doesn't hold up the others
+	queues := c.jobsConfig.ConsumedQueues()
+	for _, queue := range queues {
+		queueName, err := c.declareJobQueue(queue)
+		if err != nil {
+			return err
+		}
+
+		c.startConsumer(ctx, queueName, fmt.Sprintf("jobs-consumer-%s-%s", queue, consumerID))
 	}
 
-	// Bind the queue to the exchange
-	err = c.rabbitClient.BindQueue(queueName, c.rabbitConfig.ExchangeName, queueName)
-	if err != nil {
-		return fmt.Errorf("failed to bind queue %s: %w", queueName, err)
+	// Jobs enqueued before job queues existed are still in the single legacy queue
+	legacyQueue := c.rabbitConfig.QueueName
+	if legacyQueue == "" {
+		legacyQueue = defaultJobQueuePrefix
+	}
+
+	if _, err := c.rabbitClient.DeclareQueue(legacyQueue); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", legacyQueue, err)
 	}
 
-	// Start consumer for this queue
-	c.consumerTag = fmt.Sprintf("jobs-consumer-%s", primitive.NewObjectID().Hex())
-	c.startConsumer(ctx, queue.Name, c.consumerTag)
+	c.startConsumer(ctx, legacyQueue, fmt.Sprintf("jobs-consumer-%s", consumerID))
 
-	log.Info().Int("processors", len(c.processRegistry.AvailableProcessors())).Msg("Job processing started")
+	c.startWatchdog(ctx)
+
+	log.Info().
+		Int("processors", len(c.processRegistry.AvailableProcessors())).
+		Strs("queues", queues).
+		Msg("Job processing started")
 	return nil
 }
 
@@ -294,19 +531,25 @@ func (c *jobController) startConsumer(ctx context.Context, queueName, consumerTa
 // processDelivery handles a single delivery
 func (c *jobController) processDelivery(ctx context.Context, delivery amqp.Delivery) {
 	// Extract job ID from message headers
+	// Malformed messages are never requeued, they are parked on the dead-letter queue for inspection
 	jobIDStr, ok := delivery.Headers["job_id"].(string)
 	if !ok {
 		log.Error().Msg("Message missing job_id header, rejecting")
-		delivery.Nack(false, false) // Don't requeue malformed messages
+		c.rabbitClient.DeadLetter(delivery, DeadLetterInvalidMessage, fmt.Errorf("missing job_id header"))
+		return
+	}
+	jobID, err := primitive.ObjectIDFromHex(jobIDStr)
+	if err != nil {
+		log.Error().Str("jobId", jobIDStr).Msg("Message has an invalid job_id header, rejecting")
+		c.rabbitClient.DeadLetter(delivery, DeadLetterInvalidMessage, fmt.Errorf("invalid job_id header: %w", err))
 		return
 	}
-	jobID, _ := primitive.ObjectIDFromHex(jobIDStr)
 
 	// Extract job type from message headers
 	jobType, ok := delivery.Headers["job_type"].(string)
 	if !ok {
 		log.Error().Str("jobId", jobID.Hex()).Msg("Message missing job_type header, rejecting")
-		delivery.Nack(false, false) // Don't requeue malformed messages
+		c.rabbitClient.DeadLetter(delivery, DeadLetterInvalidMessage, fmt.Errorf("missing job_type header"))
 		return
 	}
 
@@ -321,56 +564,485 @@ func (c *jobController) processDelivery(ctx context.Context, delivery amqp.Deliv
 	job, err := c.db.GetJobByID(ctx, jobID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to retrieve job from database")
-		delivery.Nack(false, false)
+		c.rabbitClient.DeadLetter(delivery, DeadLetterLookupFailed, err)
+		return
+	}
+
+	if job == nil {
+		logger.Error().Msg("Job not found in database, rejecting")
+		c.rabbitClient.DeadLetter(delivery, DeadLetterJobNotFound, nil)
+		return
+	}
+
+	// Finished jobs are never run again. This drops jobs cancelled while still queued (e.g. by a
+	// pipeline cancel) and duplicate or replayed messages of jobs that already completed or failed.
+	if job.Status.IsFinished() {
+		logger.Info().Str("status", string(job.Status)).Msg("Job already finished, dropping message")
+		delivery.Ack(false)
+		return
+	}
+
+	// A resumed job whose cancel was requested before its instance stopped is not run again
+	if job.CancelRequestedAt != nil {
+		logger.Info().Msg("Job cancel was requested, skipping")
+		c.db.UpdateJobStatus(ctx, jobID, model.StatusCancelled)
+		c.updatePipelineStep(ctx, job, model.StatusCancelled, nil)
+		delivery.Ack(false)
 		return
 	}
 
 	// Check if the processor exists
-	processor, exists := c.processRegistry.Get(jobType)
-	if !exists {
+	if _, exists := c.processRegistry.Get(jobType); !exists {
 		logger.Error().Msg("No processor registered for job type")
+		err := fmt.Errorf("no processor registered for job type: %v", jobType)
 		c.db.UpdateJobStatus(ctx, jobID, model.StatusFailed)
-		delivery.Ack(false)
+		c.updatePipelineStep(ctx, job, model.StatusFailed, err)
+		c.rabbitClient.DeadLetter(delivery, DeadLetterUnknownType, err)
+		return
+	}
+
+	// Each job runs on its own worker instance, so jobs of the same type can run in parallel.
+	// The worker is created before the job or message is touched, so a redelivered message can't
+	// flip a running job back to queued.
+	processor, err := c.processRegistry.Spawn(jobType, jobID)
+	if errors.Is(err, orchestrator.ErrJobAlreadyActive) {
+		logger.Warn().Msg("Job is already running in this process, rejecting duplicate message")
+		c.rabbitClient.DeadLetter(delivery, DeadLetterAlreadyActive, err)
+		return
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Could not start worker for job")
+		c.db.SetJobLastError(ctx, jobID, err.Error())
+		c.db.UpdateJobStatus(ctx, jobID, model.StatusFailed)
+		c.updatePipelineStep(ctx, job, model.StatusFailed, err)
+		c.rabbitClient.DeadLetter(delivery, DeadLetterSpawnFailed, err)
 		return
 	}
 
+	// A job type that runs one job at a time takes its lease before anything else, so a job that
+	// can't run yet stays on the queue instead of holding a worker slot
+	leased, isLeased := processor.(orchestrator.LeasedWorker)
+	if isLeased {
+		acquired, err := leased.TryAcquireLease(ctx, job)
+		if err != nil || !acquired {
+			if err != nil {
+				logger.Error().Err(err).Msg("Failed to acquire job type lease")
+			} else {
+				logger.Info().Dur("delay", jobLeaseRetryDelay).Msg("Job type is running elsewhere, returning job to the queue")
+			}
+			c.processRegistry.Release(jobID)
+			c.requeueForLease(delivery)
+			return
+		}
+	}
+
+	// Gives up the worker and lease of a job that won't be started
+	abandon := func() {
+		if isLeased {
+			leased.ReleaseLease(job)
+		}
+		c.processRegistry.Release(jobID)
+	}
+
 	// Update job status to queued or pending in database
 	err = c.db.UpdateJobStatus(ctx, jobID, model.StatusQueued)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to update job status")
-		delivery.Nack(false, false)
+		abandon()
+		c.rabbitClient.DeadLetter(delivery, DeadLetterStatusFailed, err)
+		return
+	}
+
+	// Wait for a free worker slot before taking the message, so a busy process leaves
+	// jobs on the queue for other replicas
+	if !c.acquireSlot(ctx) {
+		logger.Info().Msg("Shutting down, returning job to the queue")
+		abandon()
+		delivery.Nack(false, true)
 		return
 	}
 
 	// Acknowledge the message immediately after storing in database
 	delivery.Ack(false)
 
+	if job.Checkpoint.CompletedBatches > 0 {
+		logger.Info().
+			Int("completedBatches", job.Checkpoint.CompletedBatches).
+			Int("resumeCount", job.Checkpoint.ResumeCount).
+			Msg("Resuming job from checkpoint")
+	}
+
 	// Now launch the actual processing in a separate pool or queue system
 	// This could be a worker pool, a job scheduler, etc.
 	go func() {
+		defer c.releaseSlot()
+		defer c.processRegistry.Release(jobID)
+
 		// Update job status to processing
 		c.db.UpdateJobStatus(ctx, jobID, model.StatusProcessing)
+		c.db.MarkJobStarted(ctx, jobID, c.workerID)
+		c.updatePipelineStep(ctx, job, model.StatusProcessing, nil)
 
 		// Process the job
+		stopWatching := c.watchCancellation(ctx, jobID, processor)
+		stopHeartbeat := c.startHeartbeat(ctx, jobID)
 		cancelled, err := processor.StartWorker(job)
+		stopHeartbeat()
+		stopWatching()
+
+		// The watchdog already failed the job, it must not be marked cancelled
+		if _, timedOut := c.timedOut.LoadAndDelete(jobID); timedOut {
+			return
+		}
+
+		// Transient PUBG API failures put the job back on the queue after a backoff
+		if err != nil && !cancelled && pubg.IsTransient(err) && job.RetryCount < c.rabbitConfig.MaxRetries {
+			c.scheduleRetry(ctx, job, err)
+			return
+		}
 
 		// Update final status
+		status := model.StatusCompleted
 		if err != nil {
-			c.db.UpdateJobStatus(ctx, jobID, model.StatusFailed)
-		} else {
-			status := model.StatusCompleted
-			if cancelled {
-				status = model.StatusCancelled
+			status = model.StatusFailed
+			c.db.SetJobLastError(ctx, jobID, err.Error())
+		} else if cancelled {
+			status = model.StatusCancelled
+		}
+		c.db.UpdateJobStatus(ctx, jobID, status)
+
+		// Start any pipeline steps that were waiting on this job
+		c.updatePipelineStep(ctx, job, status, err)
+	}()
+}
+
+// watchCancellation cancels a running job when a cancel request is broadcast by any instance.
+// The broadcast is best effort, so the job document is also checked periodically.
+// Workers check for cancellation between batches, so the job stops at the next batch.
+func (c *jobController) watchCancellation(ctx context.Context, jobID primitive.ObjectID, processor orchestrator.BatchWorker) func() {
+	jobEvents, unsubscribe := c.jobEvents.Subscribe(jobID.Hex())
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(cancelPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case event, ok := <-jobEvents:
+				if !ok {
+					return
+				}
+				if event.Type != model.JobEventCancel {
+					continue
+				}
+			case <-ticker.C:
+				requested, err := c.db.IsJobCancelRequested(ctx, jobID)
+				if err != nil || !requested {
+					continue
+				}
 			}
-			c.db.UpdateJobStatus(ctx, jobID, status)
+
+			log.Info().Str("jobId", jobID.Hex()).Msg("Cancel requested, stopping job")
+			processor.Cancel()
+			return
 		}
 	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			unsubscribe()
+		})
+	}
+}
+
+// scheduleRetry marks a job that failed with a transient error as retrying and re-enqueues it once
+// the backoff has passed. The checkpoint is kept, so the retry continues from the last completed batch.
+func (c *jobController) scheduleRetry(ctx context.Context, job *model.Job, jobErr error) {
+	delay := retryBackoff(job.RetryCount)
+	nextRetryAt := time.Now().Add(delay)
+
+	logger := log.With().
+		Str("jobId", job.ID.Hex()).
+		Str("jobType", job.Type).
+		Int("attempt", job.RetryCount+1).
+		Int("maxRetries", c.rabbitConfig.MaxRetries).
+		Logger()
+
+	if err := c.db.MarkJobRetrying(ctx, job.ID, jobErr.Error(), nextRetryAt); err != nil {
+		logger.Error().Err(err).Msg("Failed to schedule job retry, marking as failed")
+		c.db.UpdateJobStatus(ctx, job.ID, model.StatusFailed)
+		c.updatePipelineStep(ctx, job, model.StatusFailed, jobErr)
+		return
+	}
+
+	logger.Warn().
+		Err(jobErr).
+		Dur("delay", delay).
+		Msg("Job failed with a transient error, scheduling retry")
+
+	c.requeueAfter(ctx, job.ID, delay)
+}
+
+// requeueAfter puts a retrying job back on the queue once the delay has passed. If the process
+// stops first, ResumeJobs picks the job up on the next start.
+func (c *jobController) requeueAfter(ctx context.Context, jobID primitive.ObjectID, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger := log.With().Str("jobId", jobID.Hex()).Logger()
+
+		// The job may have been cancelled or picked up by another replica in the meantime
+		claimed, err := c.db.ClaimJobForResume(ctx, jobID, model.StatusRetrying)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to claim job for retry")
+			return
+		}
+
+		if !claimed {
+			logger.Debug().Msg("Retrying job already claimed")
+			return
+		}
+
+		job, err := c.db.GetJobByID(ctx, jobID)
+		if err != nil || job == nil {
+			logger.Error().Err(err).Msg("Failed to load job for retry")
+			return
+		}
+
+		if err := c.enqueueJob(job); err != nil {
+			logger.Error().Err(err).Msg("Failed to re-enqueue job for retry")
+			c.db.UpdateJobStatus(ctx, jobID, model.StatusFailed)
+			c.updatePipelineStep(ctx, job, model.StatusFailed, err)
+			return
+		}
+
+		logger.Info().Int("retryCount", job.RetryCount).Msg("Re-enqueued job for retry")
+	})
+}
+
+// retryBackoff returns the delay before the given retry attempt
+func retryBackoff(retryCount int) time.Duration {
+	delay := baseRetryDelay
+	for i := 0; i < retryCount && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay
+}
+
+// requeueForLease returns a job message whose job type is running elsewhere to the queue once
+// jobLeaseRetryDelay has passed, without holding up the consumer. The message stays unacknowledged
+// meanwhile, so it goes back to the queue right away if the process stops. It is published again
+// with a count of its requeues, and dead-lettered once the count reaches the limit. A message whose
+// job finished in the meantime is dropped by processDelivery when it comes back.
+func (c *jobController) requeueForLease(delivery amqp.Delivery) {
+	requeues := leaseRequeues(delivery.Headers)
+	if limit := c.jobsConfig.LeaseRequeueLimit(); requeues >= limit {
+		err := fmt.Errorf("job type lease not acquired after %d requeues", requeues)
+		log.Warn().Int("requeues", requeues).Msg("Job type lease stayed unavailable, rejecting job message")
+		c.rabbitClient.DeadLetter(delivery, DeadLetterLeaseUnavailable, err)
+		return
+	}
+
+	time.AfterFunc(jobLeaseRetryDelay, func() {
+		headers := amqp.Table{}
+		for key, value := range delivery.Headers {
+			headers[key] = value
+		}
+		headers[rabbitmq.HeaderLeaseRequeues] = int32(requeues + 1)
+
+		err := c.rabbitClient.PublishWithPriority(delivery.Exchange, delivery.RoutingKey, delivery.Body, headers, delivery.Priority)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to publish job message again, returning it to the queue")
+			if err := delivery.Nack(false, true); err != nil {
+				log.Warn().Err(err).Msg("Failed to return job message to the queue")
+			}
+			return
+		}
+
+		if err := delivery.Ack(false); err != nil {
+			log.Warn().Err(err).Msg("Failed to acknowledge requeued job message")
+		}
+	})
+}
+
+// leaseRequeues returns how often a job message was returned to the queue waiting for its lease
+func leaseRequeues(headers amqp.Table) int {
+	switch count := headers[rabbitmq.HeaderLeaseRequeues].(type) {
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	case int:
+		return count
+	}
+	return 0
+}
+
+// acquireSlot blocks until fewer than WorkerCount jobs are running. Returns false on shutdown.
+func (c *jobController) acquireSlot(ctx context.Context) bool {
+	if c.slots == nil {
+		return true
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-c.shutdown:
+		return false
+	}
+}
+
+func (c *jobController) releaseSlot() {
+	if c.slots != nil {
+		<-c.slots
+	}
+}
+
+// ResumeJobs finds jobs that were still processing when their instance stopped and puts them back
+// on the queue. A job is orphaned if it belonged to this worker or its heartbeat is older than the
+// heartbeat timeout. Workers read the job checkpoint and skip batches that were already completed.
+// Jobs that were waiting for an automatic retry are re-enqueued once their backoff has passed.
+func (c *jobController) ResumeJobs(ctx context.Context) error {
+	retrying, err := c.db.GetJobsByStatus(ctx, model.StatusRetrying)
+	if err != nil {
+		return fmt.Errorf("failed to get retrying jobs: %w", err)
+	}
+
+	for _, job := range retrying {
+		delay := time.Duration(0)
+		if job.NextRetryAt != nil {
+			delay = max(time.Until(*job.NextRetryAt), 0)
+		}
+
+		log.Info().
+			Str("jobId", job.ID.Hex()).
+			Str("jobType", job.Type).
+			Dur("delay", delay).
+			Msg("Rescheduling job retry")
+
+		c.requeueAfter(ctx, job.ID, delay)
+	}
+
+	jobs, err := c.db.GetJobsByStatus(ctx, model.StatusProcessing)
+	if err != nil {
+		return fmt.Errorf("failed to get orphaned jobs: %w", err)
+	}
+
+	staleBefore := time.Now().Add(-c.jobsConfig.HeartbeatTimeout())
+
+	for _, job := range jobs {
+		logger := log.With().
+			Str("jobId", job.ID.Hex()).
+			Str("jobType", job.Type).
+			Int("completedBatches", job.Checkpoint.CompletedBatches).
+			Logger()
+
+		if _, ok := c.processRegistry.Get(job.Type); !ok {
+			logger.Error().Msg("No processor registered for orphaned job, marking as failed")
+			err := fmt.Errorf("no processor registered for job type: %v", job.Type)
+			c.db.SetJobLastError(ctx, job.ID, err.Error())
+			c.db.UpdateJobStatus(ctx, job.ID, model.StatusFailed)
+			c.updatePipelineStep(ctx, &job, model.StatusFailed, err)
+			continue
+		}
+
+		// The job is still running in this process, so it isn't orphaned
+		if _, running := c.processRegistry.ActiveWorker(job.ID); running {
+			logger.Warn().Msg("Job still active in this process, skipping")
+			continue
+		}
+
+		c.resumeOrphanedJob(ctx, job, staleBefore)
+	}
+
+	return nil
+}
+
+// resumeOrphanedJob claims a processing job whose instance is gone and puts it back on the queue.
+// A job whose heartbeat isn't stale yet may belong to a replica that stopped moments ago, so it is
+// checked again once its heartbeat would be older than the timeout.
+func (c *jobController) resumeOrphanedJob(ctx context.Context, job model.Job, staleBefore time.Time) {
+	logger := log.With().
+		Str("jobId", job.ID.Hex()).
+		Str("jobType", job.Type).
+		Str("workerId", job.WorkerID).
+		Int("completedBatches", job.Checkpoint.CompletedBatches).
+		Logger()
+
+	// Another replica may still be running the job. It is only resumed if it belonged to this
+	// worker or its heartbeat is older than the timeout, since a running job keeps sending them.
+	claimed, err := c.db.ClaimOrphanedJob(ctx, job.ID, c.workerID, staleBefore)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to claim orphaned job")
+		return
+	}
+
+	if !claimed {
+		if job.HeartbeatAt == nil || !job.HeartbeatAt.After(staleBefore) {
+			logger.Debug().Msg("Orphaned job already claimed")
+			return
+		}
+
+		delay := job.HeartbeatAt.Sub(staleBefore)
+		logger.Debug().Dur("delay", delay).Msg("Job may still be running on another worker, checking again once its heartbeat is stale")
+
+		time.AfterFunc(delay, func() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			current, err := c.db.GetJobByID(ctx, job.ID)
+			if err != nil || current == nil || current.Status != model.StatusProcessing {
+				return
+			}
+
+			if _, running := c.processRegistry.ActiveWorker(job.ID); running {
+				return
+			}
+
+			c.resumeOrphanedJob(ctx, *current, time.Now().Add(-c.jobsConfig.HeartbeatTimeout()))
+		})
+		return
+	}
+
+	// The instance stopped before it could act on the cancel request
+	if job.CancelRequestedAt != nil {
+		logger.Info().Msg("Orphaned job had a cancel request, marking as cancelled")
+		c.db.UpdateJobStatus(ctx, job.ID, model.StatusCancelled)
+		c.updatePipelineStep(ctx, &job, model.StatusCancelled, nil)
+		return
+	}
+
+	if err := c.enqueueJob(&job); err != nil {
+		logger.Error().Err(err).Msg("Failed to re-enqueue orphaned job")
+		c.db.UpdateJobStatus(ctx, job.ID, model.StatusFailed)
+		return
+	}
+
+	logger.Info().Msg("Re-enqueued orphaned job for resume")
 }
 
-// Helper function to get batch size for a job type
+// Helper function to get batch size for a job type. Job types configured only for their queue,
+// priority or concurrency use the default batch size.
 func getBatchSize(config config.JobsConfig, jobType string) int {
 	for _, jt := range config.JobTypes {
-		if jt.Type == jobType {
+		if jt.Type == jobType && jt.BatchSize > 0 {
 			return jt.BatchSize
 		}
 	}
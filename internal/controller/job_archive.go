@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Interval between archive runs when RetentionConfig.ArchiveIntervalMinutes is not set
+const defaultArchiveInterval = time.Hour
+
+// StartArchiver periodically moves finished jobs older than the retention period to the archive.
+// It does nothing if no retention period is configured.
+func (c *jobController) StartArchiver(ctx context.Context) {
+	retention := c.jobsConfig.Retention
+	if retention.ArchiveAfterHours <= 0 {
+		log.Info().Msg("Job retention not configured, finished jobs are kept")
+		return
+	}
+
+	maxAge := time.Duration(retention.ArchiveAfterHours) * time.Hour
+	interval := defaultArchiveInterval
+	if retention.ArchiveIntervalMinutes > 0 {
+		interval = time.Duration(retention.ArchiveIntervalMinutes) * time.Minute
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		log.Info().Dur("maxAge", maxAge).Dur("interval", interval).Msg("Job archiver started")
+
+		for {
+			c.archiveJobs(ctx, maxAge)
+
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("Context cancelled, stopping job archiver")
+				return
+			case <-c.shutdown:
+				log.Info().Msg("Shutdown signal received, stopping job archiver")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// archiveJobs moves jobs that finished more than maxAge ago to the archive
+func (c *jobController) archiveJobs(ctx context.Context, maxAge time.Duration) {
+	cutoff := time.Now().Add(-maxAge)
+
+	archived, err := c.db.ArchiveJobs(ctx, cutoff)
+	if err != nil {
+		log.Error().Err(err).Int64("archived", archived).Msg("Failed to archive old jobs")
+		return
+	}
+
+	if archived > 0 {
+		log.Info().Int64("archived", archived).Time("completedBefore", cutoff).Msg("Archived old jobs")
+	}
+}
@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"harvest/internal/database"
+	"harvest/internal/model"
+	"harvest/internal/scheduler"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// How often the scheduler checks for due schedules
+const scheduleTickInterval = 30 * time.Second
+
+// JobScheduleController manages recurring job schedules and enqueues their jobs when due
+type JobScheduleController interface {
+	// CreateSchedule validates and stores a new schedule
+	CreateSchedule(ctx context.Context, schedule *model.JobSchedule) error
+
+	// GetSchedule returns a schedule by ID
+	GetSchedule(ctx context.Context, id string) (*model.JobSchedule, error)
+
+	// ListSchedules returns all schedules
+	ListSchedules(ctx context.Context) ([]model.JobSchedule, error)
+
+	// UpdateSchedule validates and replaces an existing schedule
+	UpdateSchedule(ctx context.Context, id string, schedule *model.JobSchedule) (*model.JobSchedule, error)
+
+	// DeleteSchedule removes a schedule
+	DeleteSchedule(ctx context.Context, id string) error
+
+	// StartScheduler begins checking for due schedules in the background
+	StartScheduler(ctx context.Context)
+
+	// StopScheduler stops the background scheduler
+	StopScheduler()
+}
+
+type jobScheduleController struct {
+	db       database.JobScheduleDatabase
+	jc       JobController
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewJobScheduleController creates a new job schedule controller
+func NewJobScheduleController(db database.JobScheduleDatabase, jc JobController) JobScheduleController {
+	return &jobScheduleController{
+		db:       db,
+		jc:       jc,
+		shutdown: make(chan struct{}),
+	}
+}
+
+// validateSchedule checks the job type, payload and cron expression and sets the next run time
+func (c *jobScheduleController) validateSchedule(schedule *model.JobSchedule) error {
+	processor, ok := c.jc.GetAvailableJobTypes()[schedule.JobType]
+	if !ok {
+		return fmt.Errorf("job type not found in registry: %v", schedule.JobType)
+	}
+
+	if err := processor.PayloadSchema().Validate(schedule.JobType, schedule.Payload); err != nil {
+		return err
+	}
+
+	cron, err := scheduler.ParseCron(schedule.CronExpression)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	next := cron.Next(time.Now().UTC())
+	if next.IsZero() {
+		return fmt.Errorf("cron expression never fires: %v", schedule.CronExpression)
+	}
+	schedule.NextRunAt = &next
+
+	return nil
+}
+
+func (c *jobScheduleController) CreateSchedule(ctx context.Context, schedule *model.JobSchedule) error {
+	if err := c.validateSchedule(schedule); err != nil {
+		return err
+	}
+
+	return c.db.CreateJobSchedule(ctx, schedule)
+}
+
+func (c *jobScheduleController) GetSchedule(ctx context.Context, id string) (*model.JobSchedule, error) {
+	scheduleID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.db.GetJobScheduleByID(ctx, scheduleID)
+}
+
+func (c *jobScheduleController) ListSchedules(ctx context.Context) ([]model.JobSchedule, error) {
+	return c.db.ListJobSchedules(ctx)
+}
+
+func (c *jobScheduleController) UpdateSchedule(ctx context.Context, id string, schedule *model.JobSchedule) (*model.JobSchedule, error) {
+	scheduleID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.validateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	schedule.ID = scheduleID
+	if err := c.db.UpdateJobSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return c.db.GetJobScheduleByID(ctx, scheduleID)
+}
+
+func (c *jobScheduleController) DeleteSchedule(ctx context.Context, id string) error {
+	scheduleID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	return c.db.DeleteJobSchedule(ctx, scheduleID)
+}
+
+// StartScheduler starts a goroutine that enqueues jobs for due schedules
+func (c *jobScheduleController) StartScheduler(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(scheduleTickInterval)
+		defer ticker.Stop()
+
+		log.Info().Dur("interval", scheduleTickInterval).Msg("Job scheduler started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("Context cancelled, stopping job scheduler")
+				return
+			case <-c.shutdown:
+				log.Info().Msg("Shutdown signal received, stopping job scheduler")
+				return
+			case <-ticker.C:
+				c.runDueSchedules(ctx)
+			}
+		}
+	}()
+}
+
+// StopScheduler stops the scheduler goroutine
+func (c *jobScheduleController) StopScheduler() {
+	close(c.shutdown)
+	c.wg.Wait()
+	log.Info().Msg("Job scheduler stopped")
+}
+
+// runDueSchedules enqueues a job for every schedule that is due, skipping the tick
+// when the job from the schedule's previous run is still running
+func (c *jobScheduleController) runDueSchedules(ctx context.Context) {
+	now := time.Now().UTC()
+
+	schedules, err := c.db.GetDueJobSchedules(ctx, now)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get due job schedules")
+		return
+	}
+
+	for _, schedule := range schedules {
+		logger := log.With().
+			Str("scheduleId", schedule.ID.Hex()).
+			Str("jobType", schedule.JobType).
+			Logger()
+
+		cron, err := scheduler.ParseCron(schedule.CronExpression)
+		if err != nil {
+			logger.Error().Err(err).Msg("Stored schedule has an invalid cron expression")
+			continue
+		}
+
+		next := cron.Next(now)
+		if next.IsZero() {
+			logger.Error().Msg("Stored schedule never fires again")
+			continue
+		}
+
+		// Only one instance gets to act on each tick
+		claimed, err := c.db.ClaimJobScheduleRun(ctx, schedule.ID, *schedule.NextRunAt, next)
+		if err != nil || !claimed {
+			continue
+		}
+
+		if _, ok := c.jc.GetAvailableJobTypes()[schedule.JobType]; !ok {
+			logger.Warn().Msg("Scheduled job type is no longer registered, skipping tick")
+			c.db.RecordJobScheduleRun(ctx, schedule.ID, nil, "job type not registered")
+			continue
+		}
+
+		if c.previousRunActive(ctx, schedule) {
+			logger.Info().Msg("Previous scheduled job still running, skipping scheduled tick")
+			c.db.RecordJobScheduleRun(ctx, schedule.ID, nil, "previous job still running")
+			continue
+		}
+
+		job, err := c.jc.CreateJob(ctx, schedule.JobType, schedule.Payload, "", false, schedule.TokenID)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to create scheduled job")
+			c.db.RecordJobScheduleRun(ctx, schedule.ID, nil, err.Error())
+			continue
+		}
+
+		c.db.RecordJobScheduleRun(ctx, schedule.ID, &job.ID, "")
+		logger.Info().
+			Str("jobId", job.ID.Hex()).
+			Time("nextRunAt", next).
+			Msg("Scheduled job enqueued")
+	}
+}
+
+// previousRunActive reports whether the job created by the schedule's last run has not finished yet
+func (c *jobScheduleController) previousRunActive(ctx context.Context, schedule model.JobSchedule) bool {
+	if schedule.LastJobID == nil {
+		return false
+	}
+
+	job, err := c.jc.GetJob(ctx, schedule.LastJobID.Hex())
+	if err != nil || job == nil {
+		return false
+	}
+
+	// A job waiting for an automatic retry hasn't finished either
+	switch job.Status {
+	case model.StatusQueued, model.StatusProcessing, model.StatusRetrying:
+		return true
+	default:
+		return false
+	}
+}
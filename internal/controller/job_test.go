@@ -0,0 +1,661 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"harvest/internal/config"
+	"harvest/internal/database"
+	"harvest/internal/model"
+	"harvest/internal/orchestrator"
+	"harvest/internal/rabbitmq"
+	"harvest/pkg/pubg"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeJobDB keeps jobs in memory. Methods a test doesn't expect panic on the nil embedded interface.
+type fakeJobDB struct {
+	database.JobDatabase
+
+	mu           sync.Mutex
+	jobs         map[primitive.ObjectID]*model.Job
+	statuses     []model.JobStatus // Every status set, in order
+	resumeClaims int
+}
+
+func newFakeJobDB(jobs ...*model.Job) *fakeJobDB {
+	db := &fakeJobDB{jobs: make(map[primitive.ObjectID]*model.Job)}
+	for _, job := range jobs {
+		db.jobs[job.ID] = job
+	}
+	return db
+}
+
+func (db *fakeJobDB) CreateJob(ctx context.Context, job *model.Job) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	copied := *job
+	db.jobs[job.ID] = &copied
+	return nil
+}
+
+func (db *fakeJobDB) GetJobByID(ctx context.Context, id primitive.ObjectID) (*model.Job, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	job, ok := db.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (db *fakeJobDB) UpdateJobStatus(ctx context.Context, id primitive.ObjectID, status model.JobStatus) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.statuses = append(db.statuses, status)
+	if job, ok := db.jobs[id]; ok {
+		job.Status = status
+	}
+	return nil
+}
+
+func (db *fakeJobDB) SetJobLastError(ctx context.Context, id primitive.ObjectID, lastError string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if job, ok := db.jobs[id]; ok {
+		job.LastError = lastError
+	}
+	return nil
+}
+
+func (db *fakeJobDB) GetJobsByStatus(ctx context.Context, status model.JobStatus) ([]model.Job, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	jobs := make([]model.Job, 0)
+	for _, job := range db.jobs {
+		if job.Status == status {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
+}
+
+func (db *fakeJobDB) ClaimOrphanedJob(ctx context.Context, id primitive.ObjectID, workerID string, staleBefore time.Time) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	job, ok := db.jobs[id]
+	if !ok || job.Status != model.StatusProcessing {
+		return false, nil
+	}
+	if job.WorkerID != workerID && job.HeartbeatAt != nil && !job.HeartbeatAt.Before(staleBefore) {
+		return false, nil
+	}
+
+	job.Status = model.StatusQueued
+	db.statuses = append(db.statuses, model.StatusQueued)
+	return true, nil
+}
+
+func (db *fakeJobDB) FailStuckJob(ctx context.Context, id primitive.ObjectID, reason string) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	job, ok := db.jobs[id]
+	if !ok || job.Status != model.StatusProcessing {
+		return false, nil
+	}
+
+	job.Status = model.StatusFailed
+	job.LastError = reason
+	db.statuses = append(db.statuses, model.StatusFailed)
+	return true, nil
+}
+
+func (db *fakeJobDB) MarkJobStarted(ctx context.Context, id primitive.ObjectID, workerID string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if job, ok := db.jobs[id]; ok {
+		job.WorkerID = workerID
+	}
+	return nil
+}
+
+func (db *fakeJobDB) MarkJobRetrying(ctx context.Context, id primitive.ObjectID, lastError string, nextRetryAt time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	job, ok := db.jobs[id]
+	if !ok {
+		return errors.New("job not found")
+	}
+
+	job.Status = model.StatusRetrying
+	job.LastError = lastError
+	job.NextRetryAt = &nextRetryAt
+	job.RetryCount++
+	db.statuses = append(db.statuses, model.StatusRetrying)
+	return nil
+}
+
+func (db *fakeJobDB) ClaimJobForResume(ctx context.Context, id primitive.ObjectID, expected model.JobStatus) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.resumeClaims++
+	job, ok := db.jobs[id]
+	if !ok || job.Status != expected {
+		return false, nil
+	}
+
+	job.Status = model.StatusQueued
+	db.statuses = append(db.statuses, model.StatusQueued)
+	return true, nil
+}
+
+func (db *fakeJobDB) job(id primitive.ObjectID) model.Job {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return *db.jobs[id]
+}
+
+func (db *fakeJobDB) resumeClaimCount() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.resumeClaims
+}
+
+func (db *fakeJobDB) statusHistory() []model.JobStatus {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return append([]model.JobStatus(nil), db.statuses...)
+}
+
+// fakeRabbit records the messages the controller dead-letters and publishes
+type fakeRabbit struct {
+	rabbitmq.Client
+
+	mu          sync.Mutex
+	deadLetters []string // Reasons, in order
+	published   []amqp.Table
+	stored      []amqp.Delivery // Messages on the dead-letter queue
+	replayed    []string
+}
+
+func (r *fakeRabbit) DeadLetter(delivery amqp.Delivery, reason string, cause error) error {
+	r.mu.Lock()
+	r.deadLetters = append(r.deadLetters, reason)
+	r.mu.Unlock()
+
+	return delivery.Ack(false)
+}
+
+func (r *fakeRabbit) PublishWithPriority(exchange, routingKey string, body []byte, headers amqp.Table, priority uint8) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.published = append(r.published, headers)
+	return nil
+}
+
+func (r *fakeRabbit) DeclareExchange(name, kind string) error { return nil }
+
+func (r *fakeRabbit) DeclarePriorityQueue(name string, maxPriority uint8) (amqp.Queue, error) {
+	return amqp.Queue{Name: name}, nil
+}
+
+func (r *fakeRabbit) BindQueue(queueName, exchangeName, routingKey string) error { return nil }
+
+func (r *fakeRabbit) PeekDeadLetters(limit int) ([]amqp.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.stored) > limit {
+		return r.stored[:limit], nil
+	}
+	return r.stored, nil
+}
+
+func (r *fakeRabbit) ReplayDeadLetter(messageID string) (*amqp.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, msg := range r.stored {
+		if msg.MessageId == messageID {
+			r.stored = append(r.stored[:i], r.stored[i+1:]...)
+			r.replayed = append(r.replayed, messageID)
+			return &msg, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRabbit) publishedCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.published)
+}
+
+func (r *fakeRabbit) deadLetterReasons() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.deadLetters...)
+}
+
+// fakeJobEvents is a job event bus that never delivers an event
+type fakeJobEvents struct{}
+
+func (fakeJobEvents) PublishJobEvent(event model.JobEvent) {}
+
+func (fakeJobEvents) Subscribe(jobID string) (<-chan model.JobEvent, func()) {
+	return make(chan model.JobEvent), func() {}
+}
+
+func (fakeJobEvents) Start(ctx context.Context) error { return nil }
+
+// fakeAcknowledger records what happened to a delivery
+type fakeAcknowledger struct {
+	mu      sync.Mutex
+	acked   bool
+	nacked  bool
+	requeue bool
+	done    chan struct{}
+}
+
+func newFakeAcknowledger() *fakeAcknowledger {
+	return &fakeAcknowledger{done: make(chan struct{})}
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.acked = true
+	close(a.done)
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nacked = true
+	a.requeue = requeue
+	close(a.done)
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+func (a *fakeAcknowledger) result() (acked, nacked bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.acked, a.nacked
+}
+
+// fakeWorker is a batch worker that records whether it was started or cancelled. A started job
+// finishes with err.
+type fakeWorker struct {
+	jobType   string
+	started   chan *model.Job
+	cancelled int32
+	err       error
+}
+
+func (w *fakeWorker) StartWorker(job *model.Job) (bool, error) {
+	if w.started != nil {
+		w.started <- job
+	}
+	return false, w.err
+}
+
+func (w *fakeWorker) Cancel() error {
+	atomic.AddInt32(&w.cancelled, 1)
+	return nil
+}
+
+func (w *fakeWorker) Name() string                              { return w.jobType }
+func (w *fakeWorker) IsActive() bool                            { return false }
+func (w *fakeWorker) Type() string                              { return w.jobType }
+func (w *fakeWorker) Description() string                       { return "" }
+func (w *fakeWorker) PayloadSchema() orchestrator.PayloadSchema { return nil }
+func (w *fakeWorker) SupportsDryRun() bool                      { return false }
+func (w *fakeWorker) ActiveJobID() *primitive.ObjectID          { return nil }
+
+func newTestJobController(db *fakeJobDB, rabbit *fakeRabbit, factories ...orchestrator.WorkerFactory) *jobController {
+	registry := orchestrator.NewWorkerRegistry(factories...)
+	return NewJobController(db, nil, rabbit, fakeJobEvents{}, config.RabbitMQConfig{}, config.JobsConfig{}, registry).(*jobController)
+}
+
+// waitFor polls until done returns true, failing the test after a few seconds
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func jobDelivery(job *model.Job, ack amqp.Acknowledger) amqp.Delivery {
+	return amqp.Delivery{
+		Acknowledger: ack,
+		DeliveryTag:  1,
+		Headers: amqp.Table{
+			"job_id":   job.ID.Hex(),
+			"job_type": job.Type,
+		},
+	}
+}
+
+func TestProcessDeliveryFinishedJobs(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         model.JobStatus
+		wantDeadLetter string // Empty when the message is acknowledged and dropped
+	}{
+		{name: "completed job", status: model.StatusCompleted},
+		{name: "failed job", status: model.StatusFailed},
+		{name: "cancelled job", status: model.StatusCancelled},
+		{name: "queued job runs the checks that follow", status: model.StatusQueued, wantDeadLetter: DeadLetterUnknownType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The job type isn't registered, a job that gets past the guard is dead-lettered
+			job := &model.Job{ID: primitive.NewObjectID(), Type: "unregistered", Status: tt.status}
+			db := newFakeJobDB(job)
+			rabbit := &fakeRabbit{}
+			c := newTestJobController(db, rabbit, func() orchestrator.BatchWorker {
+				return &fakeWorker{jobType: "registered"}
+			})
+
+			ack := newFakeAcknowledger()
+			c.processDelivery(context.Background(), jobDelivery(job, ack))
+
+			if acked, nacked := ack.result(); !acked || nacked {
+				t.Errorf("acked, nacked = %v, %v, want the message acknowledged", acked, nacked)
+			}
+
+			reasons := rabbit.deadLetterReasons()
+			if tt.wantDeadLetter == "" {
+				if len(reasons) != 0 {
+					t.Errorf("dead-lettered with %v, want the message dropped", reasons)
+				}
+				if statuses := db.statusHistory(); len(statuses) != 0 {
+					t.Errorf("statuses set = %v, want the job left %v", statuses, tt.status)
+				}
+				return
+			}
+
+			if len(reasons) != 1 || reasons[0] != tt.wantDeadLetter {
+				t.Errorf("dead-lettered with %v, want %v", reasons, tt.wantDeadLetter)
+			}
+		})
+	}
+}
+
+// fakeLeasedWorker is a worker whose job type lease is always held by another job
+type fakeLeasedWorker struct {
+	fakeWorker
+}
+
+func (w *fakeLeasedWorker) TryAcquireLease(ctx context.Context, job *model.Job) (bool, error) {
+	return false, nil
+}
+
+func (w *fakeLeasedWorker) ReleaseLease(job *model.Job) {}
+
+func TestProcessDeliveryLeaseRequeueLimit(t *testing.T) {
+	tests := []struct {
+		name           string
+		requeues       interface{} // Value of the requeue header, nil for none
+		wantDeadLetter bool
+	}{
+		{name: "first delivery", requeues: nil},
+		{name: "below the limit", requeues: int32(2)},
+		{name: "at the limit", requeues: int32(3), wantDeadLetter: true},
+		{name: "past the limit as int64", requeues: int64(5), wantDeadLetter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &model.Job{ID: primitive.NewObjectID(), Type: "leased", Status: model.StatusQueued}
+			db := newFakeJobDB(job)
+			rabbit := &fakeRabbit{}
+			c := newTestJobController(db, rabbit, func() orchestrator.BatchWorker {
+				return &fakeLeasedWorker{fakeWorker{jobType: "leased"}}
+			})
+			c.jobsConfig.MaxLeaseRequeues = 3
+
+			ack := newFakeAcknowledger()
+			delivery := jobDelivery(job, ack)
+			if tt.requeues != nil {
+				delivery.Headers[rabbitmq.HeaderLeaseRequeues] = tt.requeues
+			}
+
+			c.processDelivery(context.Background(), delivery)
+
+			reasons := rabbit.deadLetterReasons()
+			acked, nacked := ack.result()
+			if tt.wantDeadLetter {
+				if len(reasons) != 1 || reasons[0] != DeadLetterLeaseUnavailable {
+					t.Errorf("dead-lettered with %v, want %v", reasons, DeadLetterLeaseUnavailable)
+				}
+			} else {
+				// The message is held until the retry delay has passed
+				if len(reasons) != 0 || acked || nacked {
+					t.Errorf("dead-lettered with %v, acked %v, nacked %v, want the message held", reasons, acked, nacked)
+				}
+			}
+
+			if statuses := db.statusHistory(); len(statuses) != 0 {
+				t.Errorf("statuses set = %v, want the job left queued", statuses)
+			}
+			if _, active := c.processRegistry.ActiveWorker(job.ID); active {
+				t.Error("worker still active, want it released")
+			}
+		})
+	}
+}
+
+func TestLeaseRequeues(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers amqp.Table
+		want    int
+	}{
+		{name: "no header", headers: amqp.Table{}, want: 0},
+		{name: "int32", headers: amqp.Table{rabbitmq.HeaderLeaseRequeues: int32(4)}, want: 4},
+		{name: "int64", headers: amqp.Table{rabbitmq.HeaderLeaseRequeues: int64(7)}, want: 7},
+		{name: "wrong type", headers: amqp.Table{rabbitmq.HeaderLeaseRequeues: "3"}, want: 0},
+		{name: "nil headers", headers: nil, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := leaseRequeues(tt.headers); got != tt.want {
+				t.Errorf("leaseRequeues = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBatchSize(t *testing.T) {
+	jobsConfig := config.JobsConfig{
+		DefaultBatchSize: 10,
+		JobTypes: []config.JobTypeConfig{
+			{Type: "sized", BatchSize: 25},
+			{Type: "queue-only", Queue: "bulk", Priority: "low"},
+			{Type: "negative", BatchSize: -1},
+		},
+	}
+
+	tests := []struct {
+		jobType string
+		want    int
+	}{
+		{jobType: "sized", want: 25},
+		{jobType: "queue-only", want: 10},
+		{jobType: "negative", want: 10},
+		{jobType: "unconfigured", want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jobType, func(t *testing.T) {
+			if got := getBatchSize(jobsConfig, tt.jobType); got != tt.want {
+				t.Errorf("getBatchSize(%q) = %d, want %d", tt.jobType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessDeliveryRetry(t *testing.T) {
+	transient := fmt.Errorf("%w: status 503", pubg.ErrTransient)
+
+	tests := []struct {
+		name       string
+		retryCount int
+		err        error
+		wantStatus model.JobStatus
+		wantRetry  bool
+	}{
+		{name: "success", err: nil, wantStatus: model.StatusCompleted},
+		{name: "transient error is retried", err: transient, wantStatus: model.StatusRetrying, wantRetry: true},
+		{name: "transient error below the limit", retryCount: 2, err: transient, wantStatus: model.StatusRetrying, wantRetry: true},
+		{name: "transient error at the limit", retryCount: 3, err: transient, wantStatus: model.StatusFailed},
+		{name: "other error", err: errors.New("bad payload"), wantStatus: model.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // Stops the scheduled requeue
+
+			job := &model.Job{ID: primitive.NewObjectID(), Type: "flaky", Status: model.StatusQueued, RetryCount: tt.retryCount}
+			db := newFakeJobDB(job)
+			rabbit := &fakeRabbit{}
+			c := newTestJobController(db, rabbit, func() orchestrator.BatchWorker {
+				return &fakeWorker{jobType: "flaky", err: tt.err}
+			})
+			c.rabbitConfig.MaxRetries = 3
+
+			ack := newFakeAcknowledger()
+			c.processDelivery(ctx, jobDelivery(job, ack))
+
+			if acked, nacked := ack.result(); !acked || nacked {
+				t.Fatalf("acked, nacked = %v, %v, want the message acknowledged", acked, nacked)
+			}
+			waitFor(t, "the job to finish", func() bool {
+				_, active := c.processRegistry.ActiveWorker(job.ID)
+				return !active
+			})
+
+			stored := db.job(job.ID)
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %v, want %v (history %v)", stored.Status, tt.wantStatus, db.statusHistory())
+			}
+			if tt.err != nil && stored.LastError != tt.err.Error() {
+				t.Errorf("last error = %q, want %q", stored.LastError, tt.err.Error())
+			}
+
+			if tt.wantRetry {
+				if stored.RetryCount != tt.retryCount+1 {
+					t.Errorf("retry count = %d, want %d", stored.RetryCount, tt.retryCount+1)
+				}
+				if stored.NextRetryAt == nil || time.Until(*stored.NextRetryAt) <= 0 {
+					t.Errorf("next retry at = %v, want a time after the backoff", stored.NextRetryAt)
+				}
+			} else if stored.RetryCount != tt.retryCount {
+				t.Errorf("retry count = %d, want it left at %d", stored.RetryCount, tt.retryCount)
+			}
+
+			if len(rabbit.deadLetterReasons()) != 0 || rabbit.publishedCount() != 0 {
+				t.Error("message dead-lettered or published, want the job to wait for its backoff")
+			}
+		})
+	}
+}
+
+func TestRequeueAfter(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      model.JobStatus
+		wantRequeue bool
+	}{
+		{name: "retrying job", status: model.StatusRetrying, wantRequeue: true},
+		{name: "cancelled while waiting", status: model.StatusCancelled},
+		{name: "already claimed by another replica", status: model.StatusQueued},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &model.Job{ID: primitive.NewObjectID(), Type: "flaky", Status: tt.status}
+			db := newFakeJobDB(job)
+			rabbit := &fakeRabbit{}
+			c := newTestJobController(db, rabbit)
+
+			c.requeueAfter(context.Background(), job.ID, 0)
+
+			if tt.wantRequeue {
+				waitFor(t, "the job to be published", func() bool { return rabbit.publishedCount() == 1 })
+				if status := db.job(job.ID).Status; status != model.StatusQueued {
+					t.Errorf("status = %v, want %v", status, model.StatusQueued)
+				}
+				return
+			}
+
+			waitFor(t, "the claim", func() bool { return db.resumeClaimCount() == 1 })
+			if rabbit.publishedCount() != 0 {
+				t.Error("job published, want it left alone")
+			}
+			if status := db.job(job.ID).Status; status != tt.status {
+				t.Errorf("status = %v, want it left %v", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		retryCount int
+		want       time.Duration
+	}{
+		{retryCount: 0, want: 30 * time.Second},
+		{retryCount: 1, want: time.Minute},
+		{retryCount: 2, want: 2 * time.Minute},
+		{retryCount: 4, want: 8 * time.Minute},
+		{retryCount: 5, want: 15 * time.Minute},
+		{retryCount: 50, want: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := retryBackoff(tt.retryCount); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", tt.retryCount, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"harvest/internal/model"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// How often the watchdog looks for stuck jobs
+const watchdogInterval = time.Minute
+
+// Heartbeats sent per heartbeat timeout, so a few missed writes don't fail a healthy job
+const heartbeatsPerTimeout = 3
+
+// startHeartbeat records a heartbeat for a local job until the returned function is called. The
+// heartbeat is sent on a timer while the worker runs, so a batch that takes longer than the
+// heartbeat timeout doesn't get a healthy job failed.
+func (c *jobController) startHeartbeat(ctx context.Context, jobID primitive.ObjectID) func() {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(c.jobsConfig.HeartbeatTimeout() / heartbeatsPerTimeout)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.db.RecordJobHeartbeat(ctx, jobID)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// startWatchdog periodically fails processing jobs that ran past their max runtime and resumes
+// jobs whose instance stopped sending heartbeats
+func (c *jobController) startWatchdog(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(watchdogInterval)
+		defer ticker.Stop()
+
+		log.Info().Dur("heartbeatTimeout", c.jobsConfig.HeartbeatTimeout()).Msg("Job watchdog started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("Context cancelled, stopping job watchdog")
+				return
+			case <-c.shutdown:
+				log.Info().Msg("Shutdown signal received, stopping job watchdog")
+				return
+			case <-ticker.C:
+				c.checkStuckJobs(ctx)
+			}
+		}
+	}()
+}
+
+// checkStuckJobs stops local jobs that exceeded their max runtime. A job of another instance that
+// stopped sending heartbeats is orphaned: it is put back on the queue to continue from its checkpoint,
+// or failed if it has already run longer than its max runtime.
+func (c *jobController) checkStuckJobs(ctx context.Context) {
+	jobs, err := c.db.GetJobsByStatus(ctx, model.StatusProcessing)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get processing jobs for the watchdog")
+		return
+	}
+
+	now := time.Now()
+	heartbeatTimeout := c.jobsConfig.HeartbeatTimeout()
+
+	for _, job := range jobs {
+		// Waiting for the job type lock isn't running, the job hasn't started its work yet
+		if job.IsWaitingForLock() {
+			continue
+		}
+
+		runningSince := job.RunningSince()
+		if runningSince == nil {
+			continue
+		}
+
+		lastBeat := *runningSince
+		if job.HeartbeatAt != nil && job.HeartbeatAt.After(lastBeat) {
+			lastBeat = *job.HeartbeatAt
+		}
+
+		logger := log.With().Str("jobId", job.ID.Hex()).Str("jobType", job.Type).Str("workerId", job.WorkerID).Logger()
+
+		reason := ""
+		if maxRuntime := c.jobsConfig.MaxRuntime(job.Type); maxRuntime > 0 && now.Sub(*runningSince) > maxRuntime {
+			reason = fmt.Sprintf("job exceeded its max runtime of %v", maxRuntime)
+		}
+
+		processor, local := c.processRegistry.ActiveWorker(job.ID)
+		if !local {
+			// The instance running the job is still sending heartbeats and stops the job itself.
+			// A job of this instance that isn't running here was left by a previous run.
+			if job.WorkerID != c.workerID && now.Sub(lastBeat) <= heartbeatTimeout {
+				continue
+			}
+
+			if reason == "" {
+				logger.Warn().Dur("sinceHeartbeat", now.Sub(lastBeat).Round(time.Second)).Msg("Worker of job is gone, resuming job")
+				c.resumeOrphanedJob(ctx, job, now.Add(-heartbeatTimeout))
+				continue
+			}
+
+			reason = fmt.Sprintf("%v, worker %v is gone", reason, job.WorkerID)
+			failed, err := c.db.FailStuckJob(ctx, job.ID, reason)
+			if err != nil || !failed {
+				continue
+			}
+
+			logger.Warn().Str("reason", reason).Msg("Failed abandoned job")
+			c.updatePipelineStep(ctx, &job, model.StatusFailed, fmt.Errorf("%v", reason))
+			continue
+		}
+
+		if reason == "" {
+			continue
+		}
+
+		// Keeps the job goroutine from overwriting the failed status once the worker returns
+		if _, alreadyStopping := c.timedOut.LoadOrStore(job.ID, reason); alreadyStopping {
+			continue
+		}
+
+		logger.Warn().Str("reason", reason).Msg("Stopping stuck job")
+		processor.Cancel()
+
+		// The job is failed right away, a worker stuck in a call that ignores cancellation may never return
+		if _, err := c.db.FailStuckJob(ctx, job.ID, reason); err != nil {
+			continue
+		}
+		c.updatePipelineStep(ctx, &job, model.StatusFailed, fmt.Errorf("%v", reason))
+	}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"harvest/internal/config"
+	"harvest/internal/model"
+	"harvest/internal/orchestrator"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckStuckJobs(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name          string
+		workerID      string
+		local         bool // Running in the process under test
+		startedAt     *time.Time
+		heartbeatAt   *time.Time
+		wantStatus    model.JobStatus
+		wantPublished bool
+		wantCancelled bool
+	}{
+		{
+			name:        "other worker with a recent heartbeat is left alone",
+			workerID:    "replica-b",
+			startedAt:   ago(30 * time.Minute),
+			heartbeatAt: ago(time.Minute),
+			wantStatus:  model.StatusProcessing,
+		},
+		{
+			name:          "other worker without a heartbeat is resumed",
+			workerID:      "replica-b",
+			startedAt:     ago(30 * time.Minute),
+			heartbeatAt:   ago(20 * time.Minute),
+			wantStatus:    model.StatusQueued,
+			wantPublished: true,
+		},
+		{
+			name:        "other worker past the max runtime and without a heartbeat is failed",
+			workerID:    "replica-b",
+			startedAt:   ago(2 * time.Hour),
+			heartbeatAt: ago(20 * time.Minute),
+			wantStatus:  model.StatusFailed,
+		},
+		{
+			name:        "other worker past the max runtime is left to that worker",
+			workerID:    "replica-b",
+			startedAt:   ago(2 * time.Hour),
+			heartbeatAt: ago(time.Minute),
+			wantStatus:  model.StatusProcessing,
+		},
+		{
+			name:          "job of this worker left by a previous run is resumed",
+			workerID:      "replica-a",
+			startedAt:     ago(30 * time.Minute),
+			heartbeatAt:   ago(time.Minute),
+			wantStatus:    model.StatusQueued,
+			wantPublished: true,
+		},
+		{
+			name:        "local job without a heartbeat keeps running",
+			workerID:    "replica-a",
+			local:       true,
+			startedAt:   ago(30 * time.Minute),
+			heartbeatAt: ago(20 * time.Minute),
+			wantStatus:  model.StatusProcessing,
+		},
+		{
+			name:          "local job past the max runtime is failed and cancelled",
+			workerID:      "replica-a",
+			local:         true,
+			startedAt:     ago(2 * time.Hour),
+			heartbeatAt:   ago(time.Minute),
+			wantStatus:    model.StatusFailed,
+			wantCancelled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &model.Job{
+				ID:          primitive.NewObjectID(),
+				Type:        "test",
+				Status:      model.StatusProcessing,
+				WorkerID:    tt.workerID,
+				StartedAt:   tt.startedAt,
+				HeartbeatAt: tt.heartbeatAt,
+			}
+			db := newFakeJobDB(job)
+			rabbit := &fakeRabbit{}
+
+			worker := &fakeWorker{jobType: "test"}
+			c := newTestJobController(db, rabbit, func() orchestrator.BatchWorker { return worker })
+			c.workerID = "replica-a"
+			c.jobsConfig = config.JobsConfig{
+				HeartbeatTimeoutMinutes: 15,
+				JobTypes:                []config.JobTypeConfig{{Type: "test", MaxRuntimeMinutes: 60}},
+			}
+
+			if tt.local {
+				if _, err := c.processRegistry.Spawn("test", job.ID); err != nil {
+					t.Fatalf("Spawn returned error: %v", err)
+				}
+			}
+
+			c.checkStuckJobs(context.Background())
+
+			current, _ := db.GetJobByID(context.Background(), job.ID)
+			if current.Status != tt.wantStatus {
+				t.Errorf("status = %v, want %v", current.Status, tt.wantStatus)
+			}
+			if published := rabbit.publishedCount() > 0; published != tt.wantPublished {
+				t.Errorf("published = %v, want %v", published, tt.wantPublished)
+			}
+			if cancelled := atomic.LoadInt32(&worker.cancelled) > 0; cancelled != tt.wantCancelled {
+				t.Errorf("cancelled = %v, want %v", cancelled, tt.wantCancelled)
+			}
+		})
+	}
+}
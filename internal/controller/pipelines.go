@@ -0,0 +1,323 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"harvest/internal/model"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreatePipeline validates the step graph, stores the pipeline and enqueues every step without dependencies
+func (c *jobController) CreatePipeline(ctx context.Context, name string, steps []model.PipelineStep, tokenID string) (*model.Pipeline, error) {
+	if err := c.validatePipelineSteps(steps); err != nil {
+		return nil, err
+	}
+
+	pipeline := &model.Pipeline{
+		ID:      primitive.NewObjectID(),
+		Name:    name,
+		Status:  model.PipelineRunning,
+		TokenID: tokenID,
+		Steps:   make([]model.PipelineStep, len(steps)),
+	}
+
+	for i, step := range steps {
+		dependsOn := step.DependsOn
+		if dependsOn == nil {
+			dependsOn = []string{}
+		}
+
+		pipeline.Steps[i] = model.PipelineStep{
+			Name:      step.Name,
+			JobType:   step.JobType,
+			Payload:   step.Payload,
+			DependsOn: dependsOn,
+			Status:    model.StepPending,
+		}
+	}
+
+	if err := c.pipelineDB.CreatePipeline(ctx, pipeline); err != nil {
+		return nil, fmt.Errorf("failed to create pipeline: %w", err)
+	}
+
+	log.Info().
+		Str("pipelineId", pipeline.ID.Hex()).
+		Int("steps", len(pipeline.Steps)).
+		Msg("Pipeline created")
+
+	c.advancePipeline(ctx, pipeline.ID)
+
+	return c.pipelineDB.GetPipelineByID(ctx, pipeline.ID)
+}
+
+// validatePipelineSteps checks that the steps form a DAG of registered job types
+func (c *jobController) validatePipelineSteps(steps []model.PipelineStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("pipeline must have at least one step")
+	}
+
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		if step.Name == "" {
+			return fmt.Errorf("pipeline step %d is missing a name", i)
+		}
+		if _, exists := index[step.Name]; exists {
+			return fmt.Errorf("duplicate pipeline step name: %v", step.Name)
+		}
+		processor, ok := c.processRegistry.Get(step.JobType)
+		if !ok {
+			return fmt.Errorf("job type not found in registry: %v", step.JobType)
+		}
+		if err := processor.PayloadSchema().Validate(step.JobType, step.Payload); err != nil {
+			return fmt.Errorf("step %v: %w", step.Name, err)
+		}
+		index[step.Name] = i
+	}
+
+	for _, step := range steps {
+		for _, dep := range step.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return fmt.Errorf("step %v depends on unknown step: %v", step.Name, dep)
+			}
+			if dep == step.Name {
+				return fmt.Errorf("step %v depends on itself", step.Name)
+			}
+		}
+	}
+
+	// reaches[i][j] is true when step j can only start after step i has completed
+	reaches := make([][]bool, len(steps))
+	for i := range reaches {
+		reaches[i] = make([]bool, len(steps))
+	}
+	for j, step := range steps {
+		for _, dep := range step.DependsOn {
+			reaches[index[dep]][j] = true
+		}
+	}
+	for k := range steps {
+		for i := range steps {
+			for j := range steps {
+				if reaches[i][k] && reaches[k][j] {
+					reaches[i][j] = true
+				}
+			}
+		}
+	}
+
+	for i, step := range steps {
+		if reaches[i][i] {
+			return fmt.Errorf("pipeline contains a dependency cycle through step: %v", step.Name)
+		}
+	}
+
+	return nil
+}
+
+func (c *jobController) GetPipeline(ctx context.Context, id string) (*model.Pipeline, error) {
+	pipelineID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.pipelineDB.GetPipelineByID(ctx, pipelineID)
+}
+
+func (c *jobController) ListPipelines(ctx context.Context) ([]model.Pipeline, error) {
+	return c.pipelineDB.ListPipelines(ctx)
+}
+
+// CancelPipeline stops a running pipeline. Pending steps are skipped, running steps are cancelled
+// and queued steps are marked cancelled so the consumer drops them.
+func (c *jobController) CancelPipeline(ctx context.Context, id string) error {
+	pipelineID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := c.pipelineDB.GetPipelineByID(ctx, pipelineID)
+	if err != nil {
+		return err
+	}
+
+	if pipeline == nil {
+		return mongo.ErrNoDocuments
+	}
+
+	if pipeline.Status != model.PipelineRunning {
+		return fmt.Errorf("pipeline is not running: %v", pipeline.Status)
+	}
+
+	// Mark the pipeline first so finishing steps don't start their successors
+	if err := c.pipelineDB.UpdatePipelineStatus(ctx, pipelineID, model.PipelineCancelled); err != nil {
+		return err
+	}
+
+	if err := c.pipelineDB.SkipPendingPipelineSteps(ctx, pipelineID); err != nil {
+		return err
+	}
+
+	for _, step := range pipeline.Steps {
+		if step.IsFinished() || step.JobID == nil {
+			continue
+		}
+
+		if err := c.CancelJob(ctx, step.JobID.Hex()); err != nil {
+			log.Error().Err(err).Str("pipelineId", id).Str("step", step.Name).Msg("Failed to cancel pipeline step")
+		}
+	}
+
+	log.Info().Str("pipelineId", id).Msg("Pipeline cancelled")
+	return nil
+}
+
+// updatePipelineStep mirrors a job status change onto its pipeline step and moves the pipeline forward
+func (c *jobController) updatePipelineStep(ctx context.Context, job *model.Job, status model.JobStatus, jobErr error) {
+	if job == nil || job.PipelineID == nil {
+		return
+	}
+
+	stepErr := ""
+	if jobErr != nil {
+		stepErr = jobErr.Error()
+	}
+
+	err := c.pipelineDB.UpdatePipelineStepStatus(ctx, *job.PipelineID, job.PipelineStep, model.StepStatusFromJob(status), stepErr)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("pipelineId", job.PipelineID.Hex()).
+			Str("step", job.PipelineStep).
+			Msg("Failed to update pipeline step")
+		return
+	}
+
+	switch status {
+	case model.StatusCompleted:
+		c.advancePipeline(ctx, *job.PipelineID)
+	case model.StatusFailed, model.StatusCancelled:
+		c.pipelineDB.SkipPendingPipelineSteps(ctx, *job.PipelineID)
+		c.refreshPipelineStatus(ctx, *job.PipelineID)
+	}
+}
+
+// advancePipeline enqueues every pending step whose dependencies have all completed
+func (c *jobController) advancePipeline(ctx context.Context, pipelineID primitive.ObjectID) {
+	pipeline, err := c.pipelineDB.GetPipelineByID(ctx, pipelineID)
+	if err != nil || pipeline == nil {
+		log.Error().Err(err).Str("pipelineId", pipelineID.Hex()).Msg("Failed to load pipeline")
+		return
+	}
+
+	if pipeline.Status != model.PipelineRunning {
+		return
+	}
+
+	stepStatus := make(map[string]model.PipelineStepStatus, len(pipeline.Steps))
+	for _, step := range pipeline.Steps {
+		stepStatus[step.Name] = step.Status
+	}
+
+	failed := false
+	for _, step := range pipeline.Steps {
+		if step.Status != model.StepPending {
+			continue
+		}
+
+		ready := true
+		for _, dep := range step.DependsOn {
+			if stepStatus[dep] != model.StepCompleted {
+				ready = false
+				break
+			}
+		}
+
+		if !ready {
+			continue
+		}
+
+		if err := c.startPipelineStep(ctx, pipeline, step); err != nil {
+			log.Error().
+				Err(err).
+				Str("pipelineId", pipelineID.Hex()).
+				Str("step", step.Name).
+				Msg("Failed to start pipeline step")
+			c.pipelineDB.UpdatePipelineStepStatus(ctx, pipelineID, step.Name, model.StepFailed, err.Error())
+			failed = true
+		}
+	}
+
+	if failed {
+		c.pipelineDB.SkipPendingPipelineSteps(ctx, pipelineID)
+	}
+
+	c.refreshPipelineStatus(ctx, pipelineID)
+}
+
+// startPipelineStep creates and enqueues the job for a single step
+func (c *jobController) startPipelineStep(ctx context.Context, pipeline *model.Pipeline, step model.PipelineStep) error {
+	if _, ok := c.processRegistry.Get(step.JobType); !ok {
+		return fmt.Errorf("job type not found in registry: %v", step.JobType)
+	}
+
+	job := c.newJob(step.JobType, step.Payload, pipeline.TokenID)
+	job.PipelineID = &pipeline.ID
+	job.PipelineStep = step.Name
+
+	// Another predecessor may have finished at the same moment and already started this step
+	claimed, err := c.pipelineDB.ClaimPipelineStep(ctx, pipeline.ID, step.Name, job.ID)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		return nil
+	}
+
+	if err := c.submitJob(ctx, job); err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("pipelineId", pipeline.ID.Hex()).
+		Str("step", step.Name).
+		Str("jobId", job.ID.Hex()).
+		Str("jobType", job.Type).
+		Msg("Pipeline step enqueued")
+
+	return nil
+}
+
+// refreshPipelineStatus sets the final pipeline status once every step has finished
+func (c *jobController) refreshPipelineStatus(ctx context.Context, pipelineID primitive.ObjectID) {
+	pipeline, err := c.pipelineDB.GetPipelineByID(ctx, pipelineID)
+	if err != nil || pipeline == nil || pipeline.Status != model.PipelineRunning {
+		return
+	}
+
+	status := model.PipelineCompleted
+	for _, step := range pipeline.Steps {
+		if !step.IsFinished() {
+			return
+		}
+
+		switch step.Status {
+		case model.StepFailed:
+			status = model.PipelineFailed
+		case model.StepCancelled:
+			if status != model.PipelineFailed {
+				status = model.PipelineCancelled
+			}
+		}
+	}
+
+	c.pipelineDB.UpdatePipelineStatus(ctx, pipelineID, status)
+
+	log.Info().
+		Str("pipelineId", pipelineID.Hex()).
+		Str("status", string(status)).
+		Msg("Pipeline finished")
+}
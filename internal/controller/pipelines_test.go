@@ -0,0 +1,366 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"harvest/internal/database"
+	"harvest/internal/model"
+	"harvest/internal/orchestrator"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakePipelineDB keeps pipelines in memory, claiming and skipping steps the way the MongoDB updates do
+type fakePipelineDB struct {
+	database.PipelineDatabase
+
+	mu        sync.Mutex
+	pipelines map[primitive.ObjectID]*model.Pipeline
+}
+
+func newFakePipelineDB() *fakePipelineDB {
+	return &fakePipelineDB{pipelines: make(map[primitive.ObjectID]*model.Pipeline)}
+}
+
+func (db *fakePipelineDB) CreatePipeline(ctx context.Context, pipeline *model.Pipeline) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	copied := *pipeline
+	copied.Steps = append([]model.PipelineStep(nil), pipeline.Steps...)
+	db.pipelines[pipeline.ID] = &copied
+	return nil
+}
+
+func (db *fakePipelineDB) GetPipelineByID(ctx context.Context, id primitive.ObjectID) (*model.Pipeline, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	pipeline, ok := db.pipelines[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *pipeline
+	copied.Steps = append([]model.PipelineStep(nil), pipeline.Steps...)
+	return &copied, nil
+}
+
+func (db *fakePipelineDB) ClaimPipelineStep(ctx context.Context, id primitive.ObjectID, stepName string, jobID primitive.ObjectID) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	step := db.step(id, stepName)
+	if step == nil || step.Status != model.StepPending {
+		return false, nil
+	}
+
+	step.Status = model.StepQueued
+	step.JobID = &jobID
+	return true, nil
+}
+
+func (db *fakePipelineDB) UpdatePipelineStepStatus(ctx context.Context, id primitive.ObjectID, stepName string, status model.PipelineStepStatus, stepErr string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	step := db.step(id, stepName)
+	if step == nil {
+		return mongo.ErrNoDocuments
+	}
+
+	step.Status = status
+	if stepErr != "" {
+		step.Error = stepErr
+	}
+	return nil
+}
+
+func (db *fakePipelineDB) SkipPendingPipelineSteps(ctx context.Context, id primitive.ObjectID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range db.pipelines[id].Steps {
+		if step := &db.pipelines[id].Steps[i]; step.Status == model.StepPending {
+			step.Status = model.StepSkipped
+		}
+	}
+	return nil
+}
+
+func (db *fakePipelineDB) UpdatePipelineStatus(ctx context.Context, id primitive.ObjectID, status model.PipelineStatus) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.pipelines[id].Status = status
+	return nil
+}
+
+// step returns a step of a stored pipeline, the caller holds the lock
+func (db *fakePipelineDB) step(id primitive.ObjectID, name string) *model.PipelineStep {
+	pipeline, ok := db.pipelines[id]
+	if !ok {
+		return nil
+	}
+	for i := range pipeline.Steps {
+		if pipeline.Steps[i].Name == name {
+			return &pipeline.Steps[i]
+		}
+	}
+	return nil
+}
+
+func newTestPipelineController(t *testing.T) (*jobController, *fakeJobDB, *fakePipelineDB, *fakeRabbit) {
+	t.Helper()
+
+	db := newFakeJobDB()
+	pipelineDB := newFakePipelineDB()
+	rabbit := &fakeRabbit{}
+	c := newTestJobController(db, rabbit,
+		func() orchestrator.BatchWorker { return &fakeWorker{jobType: "fetch"} },
+		func() orchestrator.BatchWorker { return &fakeWorker{jobType: "process"} },
+	)
+	c.pipelineDB = pipelineDB
+
+	return c, db, pipelineDB, rabbit
+}
+
+// diamondSteps is a pipeline where b and c wait on a, and d waits on both b and c
+func diamondSteps() []model.PipelineStep {
+	return []model.PipelineStep{
+		{Name: "a", JobType: "fetch"},
+		{Name: "b", JobType: "process", DependsOn: []string{"a"}},
+		{Name: "c", JobType: "process", DependsOn: []string{"a"}},
+		{Name: "d", JobType: "fetch", DependsOn: []string{"b", "c"}},
+	}
+}
+
+func stepStatuses(pipeline *model.Pipeline) map[string]model.PipelineStepStatus {
+	statuses := make(map[string]model.PipelineStepStatus, len(pipeline.Steps))
+	for _, step := range pipeline.Steps {
+		statuses[step.Name] = step.Status
+	}
+	return statuses
+}
+
+// finishStep reports the job of a pipeline step as finished, the way the job consumer does
+func finishStep(t *testing.T, c *jobController, db *fakeJobDB, pipelineDB *fakePipelineDB, pipelineID primitive.ObjectID, name string, status model.JobStatus) {
+	t.Helper()
+
+	pipeline, _ := pipelineDB.GetPipelineByID(context.Background(), pipelineID)
+	for _, step := range pipeline.Steps {
+		if step.Name != name {
+			continue
+		}
+		if step.JobID == nil {
+			t.Fatalf("step %v has no job, want it started", name)
+		}
+
+		job := db.job(*step.JobID)
+		if job.PipelineID == nil || *job.PipelineID != pipelineID || job.PipelineStep != name {
+			t.Fatalf("job of step %v belongs to pipeline %v step %q", name, job.PipelineID, job.PipelineStep)
+		}
+
+		var jobErr error
+		if status == model.StatusFailed {
+			jobErr = errors.New("step failed")
+		}
+		c.updatePipelineStep(context.Background(), &job, status, jobErr)
+		return
+	}
+
+	t.Fatalf("pipeline has no step %v", name)
+}
+
+func TestPipelineAdvancement(t *testing.T) {
+	c, db, pipelineDB, rabbit := newTestPipelineController(t)
+	ctx := context.Background()
+
+	pipeline, err := c.CreatePipeline(ctx, "diamond", diamondSteps(), "")
+	if err != nil {
+		t.Fatalf("CreatePipeline returned error: %v", err)
+	}
+
+	steps := []struct {
+		finish       string // Step whose job completes, empty for the state after creation
+		wantSteps    map[string]model.PipelineStepStatus
+		wantJobs     int
+		wantPipeline model.PipelineStatus
+	}{
+		{
+			wantSteps:    map[string]model.PipelineStepStatus{"a": model.StepQueued, "b": model.StepPending, "c": model.StepPending, "d": model.StepPending},
+			wantJobs:     1,
+			wantPipeline: model.PipelineRunning,
+		},
+		{
+			finish:       "a",
+			wantSteps:    map[string]model.PipelineStepStatus{"a": model.StepCompleted, "b": model.StepQueued, "c": model.StepQueued, "d": model.StepPending},
+			wantJobs:     3,
+			wantPipeline: model.PipelineRunning,
+		},
+		{
+			// A duplicate completion doesn't start the successors again
+			finish:       "a",
+			wantSteps:    map[string]model.PipelineStepStatus{"a": model.StepCompleted, "b": model.StepQueued, "c": model.StepQueued, "d": model.StepPending},
+			wantJobs:     3,
+			wantPipeline: model.PipelineRunning,
+		},
+		{
+			// d still waits on c
+			finish:       "b",
+			wantSteps:    map[string]model.PipelineStepStatus{"a": model.StepCompleted, "b": model.StepCompleted, "c": model.StepQueued, "d": model.StepPending},
+			wantJobs:     3,
+			wantPipeline: model.PipelineRunning,
+		},
+		{
+			finish:       "c",
+			wantSteps:    map[string]model.PipelineStepStatus{"a": model.StepCompleted, "b": model.StepCompleted, "c": model.StepCompleted, "d": model.StepQueued},
+			wantJobs:     4,
+			wantPipeline: model.PipelineRunning,
+		},
+		{
+			finish:       "d",
+			wantSteps:    map[string]model.PipelineStepStatus{"a": model.StepCompleted, "b": model.StepCompleted, "c": model.StepCompleted, "d": model.StepCompleted},
+			wantJobs:     4,
+			wantPipeline: model.PipelineCompleted,
+		},
+	}
+
+	for _, step := range steps {
+		if step.finish != "" {
+			finishStep(t, c, db, pipelineDB, pipeline.ID, step.finish, model.StatusCompleted)
+		}
+
+		stored, _ := pipelineDB.GetPipelineByID(ctx, pipeline.ID)
+		if got := stepStatuses(stored); !reflect.DeepEqual(got, step.wantSteps) {
+			t.Errorf("after %q: steps = %v, want %v", step.finish, got, step.wantSteps)
+		}
+		if stored.Status != step.wantPipeline {
+			t.Errorf("after %q: pipeline status = %v, want %v", step.finish, stored.Status, step.wantPipeline)
+		}
+		if got := rabbit.publishedCount(); got != step.wantJobs {
+			t.Errorf("after %q: %d jobs enqueued, want %d", step.finish, got, step.wantJobs)
+		}
+	}
+}
+
+func TestPipelineStepFailure(t *testing.T) {
+	c, db, pipelineDB, rabbit := newTestPipelineController(t)
+	ctx := context.Background()
+
+	pipeline, err := c.CreatePipeline(ctx, "diamond", diamondSteps(), "")
+	if err != nil {
+		t.Fatalf("CreatePipeline returned error: %v", err)
+	}
+
+	finishStep(t, c, db, pipelineDB, pipeline.ID, "a", model.StatusCompleted)
+	finishStep(t, c, db, pipelineDB, pipeline.ID, "b", model.StatusFailed)
+
+	// d is skipped, but the pipeline keeps running until c has finished
+	stored, _ := pipelineDB.GetPipelineByID(ctx, pipeline.ID)
+	want := map[string]model.PipelineStepStatus{"a": model.StepCompleted, "b": model.StepFailed, "c": model.StepQueued, "d": model.StepSkipped}
+	if got := stepStatuses(stored); !reflect.DeepEqual(got, want) {
+		t.Errorf("steps = %v, want %v", got, want)
+	}
+	if stored.Status != model.PipelineRunning {
+		t.Errorf("pipeline status = %v, want %v", stored.Status, model.PipelineRunning)
+	}
+	for _, step := range stored.Steps {
+		if step.Name == "b" && step.Error != "step failed" {
+			t.Errorf("step error = %q, want the job error", step.Error)
+		}
+	}
+
+	finishStep(t, c, db, pipelineDB, pipeline.ID, "c", model.StatusCompleted)
+
+	stored, _ = pipelineDB.GetPipelineByID(ctx, pipeline.ID)
+	if stored.Status != model.PipelineFailed {
+		t.Errorf("pipeline status = %v, want %v", stored.Status, model.PipelineFailed)
+	}
+	if got := rabbit.publishedCount(); got != 3 {
+		t.Errorf("%d jobs enqueued, want 3", got)
+	}
+}
+
+func TestStartPipelineStepClaimedOnce(t *testing.T) {
+	c, db, _, rabbit := newTestPipelineController(t)
+	ctx := context.Background()
+
+	pipeline, err := c.CreatePipeline(ctx, "single", []model.PipelineStep{{Name: "a", JobType: "fetch"}}, "")
+	if err != nil {
+		t.Fatalf("CreatePipeline returned error: %v", err)
+	}
+
+	// A second predecessor finishing with the same pending snapshot loses the claim
+	stale := *pipeline
+	stale.Steps = []model.PipelineStep{{Name: "a", JobType: "fetch", Status: model.StepPending}}
+	if err := c.startPipelineStep(ctx, &stale, stale.Steps[0]); err != nil {
+		t.Fatalf("startPipelineStep returned error: %v", err)
+	}
+
+	if got := rabbit.publishedCount(); got != 1 {
+		t.Errorf("%d jobs enqueued, want 1", got)
+	}
+	if got := len(db.jobs); got != 1 {
+		t.Errorf("%d jobs created, want 1", got)
+	}
+}
+
+func TestValidatePipelineSteps(t *testing.T) {
+	c, _, _, _ := newTestPipelineController(t)
+
+	tests := []struct {
+		name    string
+		steps   []model.PipelineStep
+		wantErr string // Substring of the error, empty for a valid pipeline
+	}{
+		{name: "diamond", steps: diamondSteps()},
+		{name: "no steps", steps: nil, wantErr: "at least one step"},
+		{name: "missing name", steps: []model.PipelineStep{{JobType: "fetch"}}, wantErr: "missing a name"},
+		{
+			name:    "duplicate name",
+			steps:   []model.PipelineStep{{Name: "a", JobType: "fetch"}, {Name: "a", JobType: "process"}},
+			wantErr: "duplicate pipeline step name",
+		},
+		{name: "unknown job type", steps: []model.PipelineStep{{Name: "a", JobType: "unknown"}}, wantErr: "job type not found"},
+		{
+			name:    "unknown dependency",
+			steps:   []model.PipelineStep{{Name: "a", JobType: "fetch", DependsOn: []string{"z"}}},
+			wantErr: "depends on unknown step",
+		},
+		{
+			name:    "depends on itself",
+			steps:   []model.PipelineStep{{Name: "a", JobType: "fetch", DependsOn: []string{"a"}}},
+			wantErr: "depends on itself",
+		},
+		{
+			name: "cycle",
+			steps: []model.PipelineStep{
+				{Name: "a", JobType: "fetch"},
+				{Name: "b", JobType: "fetch", DependsOn: []string{"a", "d"}},
+				{Name: "c", JobType: "fetch", DependsOn: []string{"b"}},
+				{Name: "d", JobType: "fetch", DependsOn: []string{"c"}},
+			},
+			wantErr: "dependency cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.validatePipelineSteps(tt.steps)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validatePipelineSteps returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePipelineSteps returned %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
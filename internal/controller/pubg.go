@@ -25,9 +25,13 @@ type PubgController interface {
 	GetFilteredMatches(context.Context, MatchFilter) ([]model.Match, error)
 	GetFilteredRandomMatch(context.Context, MatchFilter) (*model.Match, error)
 	GetMatchByID(context.Context, string) (*model.Match, error)
+	GetMatchKills(context.Context, string) ([]model.KillEvent, error)
 
 	GetPlayers(context.Context, int, int) ([]model.Entity, error)
 	GetTournaments(context.Context, int, int) ([]model.Entity, error)
+
+	// Request, retry and failure counts of this process's PUBG API client
+	GetAPIStats() pubg.ClientStats
 }
 
 type pubgController struct {
@@ -42,6 +46,10 @@ func NewPUBG(db database.Database, client pubg.Client) PubgController {
 	}
 }
 
+func (pc *pubgController) GetAPIStats() pubg.ClientStats {
+	return pc.client.Stats()
+}
+
 func (pc *pubgController) GetPlayers(ctx context.Context, page, pageSize int) ([]model.Entity, error) {
 	return pc.db.GetPlayers(ctx, page, pageSize)
 }
@@ -236,3 +244,8 @@ func (pc *pubgController) GetFilteredRandomMatch(ctx context.Context, filter Mat
 func (pc *pubgController) GetMatchByID(ctx context.Context, matchID string) (*model.Match, error) {
 	return pc.db.GetMatchByID(ctx, matchID)
 }
+
+// GetMatchKills retrieves the kills extracted from the telemetry of a match
+func (pc *pubgController) GetMatchKills(ctx context.Context, matchID string) ([]model.KillEvent, error) {
+	return pc.db.GetKillEventsByMatchID(ctx, matchID)
+}
@@ -19,6 +19,10 @@ type Database interface {
 	TeamDatabase
 	TeamRotationDatabase
 	DropSpotLocationDatabase
+	JobScheduleDatabase
+	PipelineDatabase
+	JobLeaseDatabase
+	KillEventDatabase
 }
 
 type mongoDB struct {
@@ -32,6 +36,12 @@ type mongoDB struct {
 	teamsCol             *mongo.Collection
 	dropSpotLocationsCol *mongo.Collection
 	teamRotationsCol     *mongo.Collection
+	jobSchedulesCol      *mongo.Collection
+	pipelinesCol         *mongo.Collection
+	jobItemsCol          *mongo.Collection
+	jobLeasesCol         *mongo.Collection
+	jobsArchiveCol       *mongo.Collection
+	killEventsCol        *mongo.Collection
 }
 
 func New(config *config.Config) (Database, error) {
@@ -61,6 +71,12 @@ func New(config *config.Config) (Database, error) {
 		teamRotationsCol:     db.Collection("team_rotations"),
 		teamsCol:             db.Collection("teams"),
 		dropSpotLocationsCol: db.Collection("drop_spot_locations"),
+		jobSchedulesCol:      db.Collection("job_schedules"),
+		pipelinesCol:         db.Collection("pipelines"),
+		jobItemsCol:          db.Collection("job_items"),
+		jobLeasesCol:         db.Collection("job_leases"),
+		jobsArchiveCol:       db.Collection("jobs_archive"),
+		killEventsCol:        db.Collection("kill_events"),
 		jobsCol:              jobsCol,
 		tokensCol:            tokensCol,
 	}, nil
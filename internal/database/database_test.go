@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newTestDB connects to the MongoDB in HARVEST_TEST_MONGO_URI and uses a database that is dropped
+// when the test finishes. Tests that need it are skipped when the variable isn't set.
+func newTestDB(t *testing.T) *mongoDB {
+	t.Helper()
+
+	uri := os.Getenv("HARVEST_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("HARVEST_TEST_MONGO_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("mongo.Connect returned error: %v", err)
+	}
+
+	db := client.Database("harvest_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	return &mongoDB{
+		client:       client,
+		db:           db,
+		jobsCol:      db.Collection("jobs"),
+		jobLeasesCol: db.Collection("job_leases"),
+		matchesCol:   db.Collection("matches"),
+	}
+}
@@ -19,6 +19,8 @@ type DropSpotLocationDatabase interface {
 	UpdateDropSpotLocation(ctx context.Context, id string, dropSpotLocation *model.DropSpotLocation) error
 	DeleteDropSpotLocation(ctx context.Context, id string) error
 	BulkUpsertDropSpotLocations(ctx context.Context, dropSpotLocations []model.DropSpotLocation) error
+	GetDropSpotLandings(ctx context.Context, mapName string, matchTypes []string, startDate *time.Time, endBefore *time.Time) ([]model.DropSpotLanding, error)
+	CountDropSpotMatches(ctx context.Context, mapName string, matchTypes []string, startDate *time.Time, endBefore *time.Time) (int64, error)
 }
 
 func (m *mongoDB) CreateDropSpotLocation(ctx context.Context, dropSpotLocation *model.DropSpotLocation) (*model.DropSpotLocation, error) {
@@ -132,4 +134,74 @@ func (m *mongoDB) BulkUpsertDropSpotLocations(ctx context.Context, dropSpotLocat
 
 	return nil
 }
- 
\ No newline at end of file
+
+// GetDropSpotLandings returns the team landings resolved to a drop spot in the processed matches
+// of a map, optionally filtered by match type and creation date
+func (m *mongoDB) GetDropSpotLandings(ctx context.Context, mapName string, matchTypes []string, startDate *time.Time, endBefore *time.Time) ([]model.DropSpotLanding, error) {
+	filter := dropSpotMatchFilter(mapName, matchTypes, startDate, endBefore)
+	filter["telemetry_data.landings.drop_spot_id"] = bson.M{"$exists": true}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: filter}},
+		bson.D{{Key: "$unwind", Value: "$telemetry_data.landings"}},
+		bson.D{{Key: "$match", Value: bson.M{"telemetry_data.landings.drop_spot_id": bson.M{"$exists": true}}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"_id":        0,
+			"match_id":   1,
+			"plane_path": "$telemetry_data.plane_path",
+			"landing":    "$telemetry_data.landings",
+		}}},
+	}
+
+	cursor, err := m.matchesCol.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate drop spot landings: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	landings := []model.DropSpotLanding{}
+	if err := cursor.All(ctx, &landings); err != nil {
+		return nil, fmt.Errorf("failed to decode drop spot landings: %w", err)
+	}
+
+	return landings, nil
+}
+
+// CountDropSpotMatches counts the processed matches of a map with the same filters as
+// GetDropSpotLandings, including matches in which no team landed at a drop spot
+func (m *mongoDB) CountDropSpotMatches(ctx context.Context, mapName string, matchTypes []string, startDate *time.Time, endBefore *time.Time) (int64, error) {
+	count, err := m.matchesCol.CountDocuments(ctx, dropSpotMatchFilter(mapName, matchTypes, startDate, endBefore))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count drop spot matches: %w", err)
+	}
+
+	return count, nil
+}
+
+// dropSpotMatchFilter selects the processed matches of a map that have their landings recorded,
+// optionally filtered by match type and a creation date range that excludes endBefore. Matches processed before landings were
+// recorded can't contain one, so they are left out until they are processed again.
+func dropSpotMatchFilter(mapName string, matchTypes []string, startDate *time.Time, endBefore *time.Time) bson.M {
+	filter := bson.M{
+		"processed":               true,
+		"map_name":                mapName,
+		"telemetry_data.landings": bson.M{"$exists": true},
+	}
+
+	if len(matchTypes) > 0 {
+		filter["match_type"] = bson.M{"$in": matchTypes}
+	}
+
+	if startDate != nil || endBefore != nil {
+		dateFilter := bson.M{}
+		if startDate != nil {
+			dateFilter["$gte"] = *startDate
+		}
+		if endBefore != nil {
+			dateFilter["$lt"] = *endBefore
+		}
+		filter["created_at"] = dateFilter
+	}
+
+	return filter
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCountDropSpotMatches(t *testing.T) {
+	m := newTestDB(t)
+	ctx := context.Background()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	landing := bson.A{bson.M{"team_id": 1}}
+
+	matches := []interface{}{
+		bson.M{"_id": "with-landings", "map_name": "Erangel_Main", "processed": true, "created_at": day, "telemetry_data": bson.M{"landings": landing}},
+		bson.M{"_id": "no-landing-found", "map_name": "Erangel_Main", "processed": true, "created_at": day, "telemetry_data": bson.M{"landings": bson.A{}}},
+		bson.M{"_id": "processed-before-landings", "map_name": "Erangel_Main", "processed": true, "created_at": day, "telemetry_data": bson.M{}},
+		bson.M{"_id": "not-processed", "map_name": "Erangel_Main", "processed": false, "created_at": day},
+		bson.M{"_id": "other-map", "map_name": "Desert_Main", "processed": true, "created_at": day, "telemetry_data": bson.M{"landings": landing}},
+		bson.M{"_id": "last-second-of-the-day", "map_name": "Erangel_Main", "processed": true, "created_at": day.Add(24*time.Hour - time.Millisecond), "telemetry_data": bson.M{"landings": landing}},
+		bson.M{"_id": "next-day", "map_name": "Erangel_Main", "processed": true, "created_at": day.Add(24 * time.Hour), "telemetry_data": bson.M{"landings": landing}},
+	}
+	if _, err := m.matchesCol.InsertMany(ctx, matches); err != nil {
+		t.Fatalf("InsertMany returned error: %v", err)
+	}
+
+	endBefore := day.Add(24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		mapName string
+		start   *time.Time
+		end     *time.Time
+		want    int64
+	}{
+		{name: "matches with recorded landings", mapName: "Erangel_Main", want: 4},
+		{name: "whole day", mapName: "Erangel_Main", start: &day, end: &endBefore, want: 3},
+		{name: "from the next day", mapName: "Erangel_Main", start: &endBefore, want: 1},
+		{name: "other map", mapName: "Desert_Main", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.CountDropSpotMatches(ctx, tt.mapName, nil, tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("CountDropSpotMatches returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CountDropSpotMatches = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
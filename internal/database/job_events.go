@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"harvest/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobEventPublisher receives a job progress event every time job progress is written
+type JobEventPublisher interface {
+	PublishJobEvent(event model.JobEvent)
+}
+
+// jobEventDatabase publishes an event after each successful job progress write, so every
+// worker reports live progress without knowing about the event bus
+type jobEventDatabase struct {
+	Database
+	publisher JobEventPublisher
+}
+
+// WithJobEvents wraps a database so job metric, batch and status updates and cancel requests are published
+func WithJobEvents(db Database, publisher JobEventPublisher) Database {
+	return &jobEventDatabase{
+		Database:  db,
+		publisher: publisher,
+	}
+}
+
+func (d *jobEventDatabase) UpdateJobStatus(ctx context.Context, id primitive.ObjectID, status model.JobStatus) error {
+	if err := d.Database.UpdateJobStatus(ctx, id, status); err != nil {
+		return err
+	}
+
+	d.publish(id, model.JobEvent{Type: model.JobEventStatus, Status: status})
+	return nil
+}
+
+func (d *jobEventDatabase) MarkJobRetrying(ctx context.Context, id primitive.ObjectID, lastError string, nextRetryAt time.Time) error {
+	if err := d.Database.MarkJobRetrying(ctx, id, lastError, nextRetryAt); err != nil {
+		return err
+	}
+
+	d.publish(id, model.JobEvent{Type: model.JobEventStatus, Status: model.StatusRetrying})
+	return nil
+}
+
+func (d *jobEventDatabase) UpdateJobMetrics(ctx context.Context, id primitive.ObjectID, metrics model.JobMetrics) error {
+	if err := d.Database.UpdateJobMetrics(ctx, id, metrics); err != nil {
+		return err
+	}
+
+	d.publish(id, model.JobEvent{Type: model.JobEventMetrics, Metrics: &metrics})
+	return nil
+}
+
+func (d *jobEventDatabase) IncrementJobBatchesComplete(ctx context.Context, id primitive.ObjectID, increment int) error {
+	if err := d.Database.IncrementJobBatchesComplete(ctx, id, increment); err != nil {
+		return err
+	}
+
+	d.publish(id, model.JobEvent{Type: model.JobEventBatch, BatchesComplete: increment})
+	return nil
+}
+
+func (d *jobEventDatabase) RequestJobCancel(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	requested, err := d.Database.RequestJobCancel(ctx, id)
+	if err != nil || !requested {
+		return requested, err
+	}
+
+	d.publish(id, model.JobEvent{Type: model.JobEventCancel})
+	return true, nil
+}
+
+func (d *jobEventDatabase) FailStuckJob(ctx context.Context, id primitive.ObjectID, reason string) (bool, error) {
+	failed, err := d.Database.FailStuckJob(ctx, id, reason)
+	if err != nil || !failed {
+		return failed, err
+	}
+
+	d.publish(id, model.JobEvent{Type: model.JobEventStatus, Status: model.StatusFailed})
+	return true, nil
+}
+
+func (d *jobEventDatabase) publish(id primitive.ObjectID, event model.JobEvent) {
+	event.JobID = id.Hex()
+	event.Timestamp = time.Now()
+	d.publisher.PublishJobEvent(event)
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"harvest/internal/model"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobItemDatabase defines per-item job result database operations
+type JobItemDatabase interface {
+	// Insert the results of processed items
+	InsertJobItems(ctx context.Context, items []model.JobItem) error
+
+	// List the item results of a job, optionally filtered by result type, with the total number of matches
+	ListJobItems(ctx context.Context, jobID primitive.ObjectID, result model.JobResultType, page, limit int) ([]model.JobItem, int64, error)
+
+	// Get the distinct items of a job that failed and never succeeded
+	GetFailedJobItems(ctx context.Context, jobID primitive.ObjectID) ([]model.JobItemRef, error)
+}
+
+// InsertJobItems stores a set of item results in a single unordered write
+func (m *mongoDB) InsertJobItems(ctx context.Context, items []model.JobItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(items))
+	for i, item := range items {
+		documents[i] = item
+	}
+
+	_, err := m.jobItemsCol.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil {
+		log.Error().Err(err).Str("jobID", items[0].JobID.Hex()).Msg("Failed to insert job items")
+		return err
+	}
+
+	log.Debug().Str("jobID", items[0].JobID.Hex()).Int("count", len(items)).Msg("Inserted job items")
+	return nil
+}
+
+// ListJobItems retrieves a page of item results for a job in the order they were recorded
+func (m *mongoDB) ListJobItems(ctx context.Context, jobID primitive.ObjectID, result model.JobResultType, page, limit int) ([]model.JobItem, int64, error) {
+	filter := bson.M{"job_id": jobID}
+	if result != "" {
+		filter["result"] = result
+	}
+
+	total, err := m.jobItemsCol.CountDocuments(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", jobID.Hex()).Msg("Failed to count job items")
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+	if page >= 0 {
+		findOptions.SetSkip(int64(page * limit))
+	}
+
+	cursor, err := m.jobItemsCol.Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", jobID.Hex()).Msg("Failed to list job items")
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var items []model.JobItem
+	if err = cursor.All(ctx, &items); err != nil {
+		log.Error().Err(err).Str("jobID", jobID.Hex()).Msg("Failed to decode job items")
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// GetFailedJobItems returns every item that failed in the job. An item can be processed several
+// times in one job (e.g. a match shared by two players), so items that also succeeded are left out.
+func (m *mongoDB) GetFailedJobItems(ctx context.Context, jobID primitive.ObjectID) ([]model.JobItemRef, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"job_id": jobID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"item_type": "$item_type",
+				"item_id":   "$item_id",
+				"parent_id": "$parent_id",
+			},
+			"results": bson.M{"$addToSet": "$result"},
+		}}},
+		{{Key: "$match", Value: bson.M{"results": bson.M{
+			"$in":  []model.JobResultType{model.ResultFailure},
+			"$nin": []model.JobResultType{model.ResultSuccess},
+		}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$_id"}}},
+		{{Key: "$sort", Value: bson.D{{Key: "item_type", Value: 1}, {Key: "item_id", Value: 1}}}},
+	}
+
+	cursor, err := m.jobItemsCol.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", jobID.Hex()).Msg("Failed to get failed job items")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var items []model.JobItemRef
+	if err = cursor.All(ctx, &items); err != nil {
+		log.Error().Err(err).Str("jobID", jobID.Hex()).Msg("Failed to decode failed job items")
+		return nil, err
+	}
+
+	log.Debug().Str("jobID", jobID.Hex()).Int("count", len(items)).Msg("Retrieved failed job items")
+	return items, nil
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"harvest/internal/model"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobLeaseDatabase defines the lease operations used to run a job type on one instance at a time
+type JobLeaseDatabase interface {
+	// Take the lease for a job if it is free, expired or already held by the job on this holder.
+	// Returns the current lease and whether the job holds it.
+	AcquireJobLease(ctx context.Context, key string, holder string, jobID primitive.ObjectID, ttl time.Duration) (*model.JobLease, bool, error)
+
+	// Extend a lease held by the job on this holder. Returns false if it no longer holds it.
+	RenewJobLease(ctx context.Context, key string, holder string, jobID primitive.ObjectID, ttl time.Duration) (bool, error)
+
+	// Give up a lease held by the job on this holder
+	ReleaseJobLease(ctx context.Context, key string, holder string, jobID primitive.ObjectID) error
+
+	// Record the lock state on a job, nil clears it
+	SetJobLock(ctx context.Context, id primitive.ObjectID, lock *model.JobLock) error
+}
+
+// AcquireJobLease takes a lease with an upsert. A lease held by another job, or by the same job on
+// another instance, makes the upsert collide with the existing document, in which case the current
+// lease is returned. Matching the holder stops a redelivered message from taking over a lease that
+// another instance is still renewing.
+func (m *mongoDB) AcquireJobLease(ctx context.Context, key string, holder string, jobID primitive.ObjectID, ttl time.Duration) (*model.JobLease, bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": key,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lte": now}},
+			bson.M{"job_id": jobID, "holder": holder},
+		},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"holder":      holder,
+			"job_id":      jobID,
+			"acquired_at": now,
+			"expires_at":  now.Add(ttl),
+		},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var lease model.JobLease
+	err := m.jobLeasesCol.FindOneAndUpdate(ctx, filter, update, opts).Decode(&lease)
+	if err == nil {
+		log.Debug().Str("key", key).Str("jobID", jobID.Hex()).Msg("Acquired job lease")
+		return &lease, true, nil
+	}
+
+	if !mongo.IsDuplicateKeyError(err) {
+		log.Error().Err(err).Str("key", key).Str("jobID", jobID.Hex()).Msg("Failed to acquire job lease")
+		return nil, false, err
+	}
+
+	err = m.jobLeasesCol.FindOne(ctx, bson.M{"_id": key}).Decode(&lease)
+	if err == mongo.ErrNoDocuments {
+		// Released between the two calls, the caller tries again
+		return nil, false, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to get job lease")
+		return nil, false, err
+	}
+
+	return &lease, false, nil
+}
+
+// RenewJobLease extends the expiry of a lease held by the job on this holder
+func (m *mongoDB) RenewJobLease(ctx context.Context, key string, holder string, jobID primitive.ObjectID, ttl time.Duration) (bool, error) {
+	filter := bson.M{
+		"_id":    key,
+		"holder": holder,
+		"job_id": jobID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"expires_at": time.Now().Add(ttl),
+		},
+	}
+
+	result, err := m.jobLeasesCol.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Str("jobID", jobID.Hex()).Msg("Failed to renew job lease")
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// ReleaseJobLease removes the lease if the job still holds it on this holder
+func (m *mongoDB) ReleaseJobLease(ctx context.Context, key string, holder string, jobID primitive.ObjectID) error {
+	_, err := m.jobLeasesCol.DeleteOne(ctx, bson.M{"_id": key, "holder": holder, "job_id": jobID})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Str("jobID", jobID.Hex()).Msg("Failed to release job lease")
+		return err
+	}
+
+	log.Debug().Str("key", key).Str("jobID", jobID.Hex()).Msg("Released job lease")
+	return nil
+}
+
+// SetJobLock records the lock state on a job
+func (m *mongoDB) SetJobLock(ctx context.Context, id primitive.ObjectID, lock *model.JobLock) error {
+	update := bson.M{
+		"$set": bson.M{
+			"lock":       lock,
+			"updated_at": time.Now(),
+		},
+	}
+
+	if lock == nil {
+		update = bson.M{
+			"$unset": bson.M{"lock": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		}
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to set job lock")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
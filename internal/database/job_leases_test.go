@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestJobLeaseHolders(t *testing.T) {
+	m := newTestDB(t)
+	ctx := context.Background()
+
+	const key = "test-job"
+	jobID := primitive.NewObjectID()
+	otherJobID := primitive.NewObjectID()
+
+	type step struct {
+		name   string
+		action string // acquire, renew or release
+		holder string
+		jobID  primitive.ObjectID
+		ttl    time.Duration
+		want   bool // Acquired or renewed, unused for release
+	}
+
+	steps := []step{
+		{name: "first holder takes the free lease", action: "acquire", holder: "replica-a", jobID: jobID, ttl: time.Minute, want: true},
+		{name: "first holder takes its lease again", action: "acquire", holder: "replica-a", jobID: jobID, ttl: time.Minute, want: true},
+		{name: "second holder can't take the same job's lease", action: "acquire", holder: "replica-b", jobID: jobID, ttl: time.Minute, want: false},
+		{name: "another job can't take the lease", action: "acquire", holder: "replica-b", jobID: otherJobID, ttl: time.Minute, want: false},
+		{name: "second holder can't renew it", action: "renew", holder: "replica-b", jobID: jobID, ttl: time.Minute, want: false},
+		{name: "first holder renews it", action: "renew", holder: "replica-a", jobID: jobID, ttl: time.Minute, want: true},
+		{name: "second holder's release leaves it", action: "release", holder: "replica-b", jobID: jobID},
+		{name: "lease is still held after the second holder's release", action: "acquire", holder: "replica-b", jobID: jobID, ttl: time.Minute, want: false},
+		{name: "first holder releases it", action: "release", holder: "replica-a", jobID: jobID},
+		{name: "second holder takes the released lease", action: "acquire", holder: "replica-b", jobID: jobID, ttl: -time.Second, want: true},
+		{name: "first holder can't renew the lease it lost", action: "renew", holder: "replica-a", jobID: jobID, ttl: time.Minute, want: false},
+		{name: "first holder takes the expired lease", action: "acquire", holder: "replica-a", jobID: jobID, ttl: time.Minute, want: true},
+	}
+
+	for _, s := range steps {
+		switch s.action {
+		case "acquire":
+			lease, acquired, err := m.AcquireJobLease(ctx, key, s.holder, s.jobID, s.ttl)
+			if err != nil {
+				t.Fatalf("%s: AcquireJobLease returned error: %v", s.name, err)
+			}
+			if acquired != s.want {
+				t.Fatalf("%s: acquired = %v, want %v", s.name, acquired, s.want)
+			}
+			if acquired && (lease.Holder != s.holder || lease.JobID != s.jobID) {
+				t.Fatalf("%s: lease = %+v, want it held by %s", s.name, lease, s.holder)
+			}
+			if !acquired && lease != nil && lease.Holder == s.holder && lease.JobID == s.jobID {
+				t.Fatalf("%s: lease = %+v, want it held by someone else", s.name, lease)
+			}
+		case "renew":
+			renewed, err := m.RenewJobLease(ctx, key, s.holder, s.jobID, s.ttl)
+			if err != nil {
+				t.Fatalf("%s: RenewJobLease returned error: %v", s.name, err)
+			}
+			if renewed != s.want {
+				t.Fatalf("%s: renewed = %v, want %v", s.name, renewed, s.want)
+			}
+		case "release":
+			if err := m.ReleaseJobLease(ctx, key, s.holder, s.jobID); err != nil {
+				t.Fatalf("%s: ReleaseJobLease returned error: %v", s.name, err)
+			}
+		}
+	}
+}
+
+func TestJobLeaseConcurrentHolders(t *testing.T) {
+	m := newTestDB(t)
+	ctx := context.Background()
+
+	// Every replica receives a message for the same job at once, only one of them may run it
+	jobID := primitive.NewObjectID()
+	holders := []string{"replica-a", "replica-b", "replica-c", "replica-d"}
+
+	var wg sync.WaitGroup
+	results := make([]bool, len(holders))
+	errs := make([]error, len(holders))
+	for i, holder := range holders {
+		wg.Add(1)
+		go func(i int, holder string) {
+			defer wg.Done()
+			_, results[i], errs[i] = m.AcquireJobLease(ctx, "test-job", holder, jobID, time.Minute)
+		}(i, holder)
+	}
+	wg.Wait()
+
+	acquired := 0
+	for i := range holders {
+		if errs[i] != nil {
+			t.Fatalf("AcquireJobLease for %s returned error: %v", holders[i], errs[i])
+		}
+		if results[i] {
+			acquired++
+		}
+	}
+
+	if acquired != 1 {
+		t.Errorf("%d holders acquired the lease, want 1", acquired)
+	}
+}
@@ -0,0 +1,224 @@
+package database
+
+import (
+	"context"
+	"harvest/internal/model"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobScheduleDatabase defines recurring job schedule database operations
+type JobScheduleDatabase interface {
+	// Create a new schedule
+	CreateJobSchedule(ctx context.Context, schedule *model.JobSchedule) error
+
+	// Get a schedule by ID
+	GetJobScheduleByID(ctx context.Context, id primitive.ObjectID) (*model.JobSchedule, error)
+
+	// List all schedules
+	ListJobSchedules(ctx context.Context) ([]model.JobSchedule, error)
+
+	// Update a schedule
+	UpdateJobSchedule(ctx context.Context, schedule *model.JobSchedule) error
+
+	// Delete a schedule by ID
+	DeleteJobSchedule(ctx context.Context, id primitive.ObjectID) error
+
+	// Get enabled schedules whose next run is at or before the given time
+	GetDueJobSchedules(ctx context.Context, now time.Time) ([]model.JobSchedule, error)
+
+	// Advance a schedule's next run time if it still matches the expected value
+	ClaimJobScheduleRun(ctx context.Context, id primitive.ObjectID, expectedNextRun time.Time, nextRun time.Time) (bool, error)
+
+	// Record the outcome of a schedule tick
+	RecordJobScheduleRun(ctx context.Context, id primitive.ObjectID, jobID *primitive.ObjectID, skipReason string) error
+}
+
+// CreateJobSchedule creates a new job schedule in the database
+func (m *mongoDB) CreateJobSchedule(ctx context.Context, schedule *model.JobSchedule) error {
+	if schedule.ID.IsZero() {
+		schedule.ID = primitive.NewObjectID()
+	}
+
+	now := time.Now()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	_, err := m.jobSchedulesCol.InsertOne(ctx, schedule)
+	if err != nil {
+		log.Error().Err(err).Str("scheduleID", schedule.ID.Hex()).Msg("Failed to create job schedule")
+		return err
+	}
+
+	log.Debug().Str("scheduleID", schedule.ID.Hex()).Str("jobType", schedule.JobType).Msg("Created job schedule")
+	return nil
+}
+
+// GetJobScheduleByID retrieves a job schedule by its ID
+func (m *mongoDB) GetJobScheduleByID(ctx context.Context, id primitive.ObjectID) (*model.JobSchedule, error) {
+	var schedule model.JobSchedule
+
+	err := m.jobSchedulesCol.FindOne(ctx, bson.M{"_id": id}).Decode(&schedule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			log.Debug().Str("scheduleID", id.Hex()).Msg("Job schedule not found")
+			return nil, nil
+		}
+		log.Error().Err(err).Str("scheduleID", id.Hex()).Msg("Failed to get job schedule")
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+// ListJobSchedules retrieves all job schedules, sorted by name
+func (m *mongoDB) ListJobSchedules(ctx context.Context) ([]model.JobSchedule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := m.jobSchedulesCol.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list job schedules")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var schedules []model.JobSchedule
+	if err = cursor.All(ctx, &schedules); err != nil {
+		log.Error().Err(err).Msg("Failed to decode job schedules")
+		return nil, err
+	}
+
+	log.Debug().Int("count", len(schedules)).Msg("Retrieved job schedules")
+	return schedules, nil
+}
+
+// UpdateJobSchedule replaces the editable fields of a job schedule
+func (m *mongoDB) UpdateJobSchedule(ctx context.Context, schedule *model.JobSchedule) error {
+	if schedule.ID.IsZero() {
+		log.Error().Msg("Cannot update job schedule with zero ID")
+		return mongo.ErrNoDocuments
+	}
+
+	schedule.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"name":            schedule.Name,
+			"job_type":        schedule.JobType,
+			"payload":         schedule.Payload,
+			"cron_expression": schedule.CronExpression,
+			"enabled":         schedule.Enabled,
+			"next_run_at":     schedule.NextRunAt,
+			"updated_at":      schedule.UpdatedAt,
+		},
+	}
+
+	result, err := m.jobSchedulesCol.UpdateOne(ctx, bson.M{"_id": schedule.ID}, update)
+	if err != nil {
+		log.Error().Err(err).Str("scheduleID", schedule.ID.Hex()).Msg("Failed to update job schedule")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("scheduleID", schedule.ID.Hex()).Msg("Job schedule not found for update")
+		return mongo.ErrNoDocuments
+	}
+
+	log.Debug().Str("scheduleID", schedule.ID.Hex()).Msg("Updated job schedule")
+	return nil
+}
+
+// DeleteJobSchedule deletes a job schedule by its ID
+func (m *mongoDB) DeleteJobSchedule(ctx context.Context, id primitive.ObjectID) error {
+	result, err := m.jobSchedulesCol.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		log.Error().Err(err).Str("scheduleID", id.Hex()).Msg("Failed to delete job schedule")
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		log.Debug().Str("scheduleID", id.Hex()).Msg("Job schedule not found for deletion")
+		return mongo.ErrNoDocuments
+	}
+
+	log.Debug().Str("scheduleID", id.Hex()).Msg("Deleted job schedule")
+	return nil
+}
+
+// GetDueJobSchedules retrieves enabled schedules that should have fired by now
+func (m *mongoDB) GetDueJobSchedules(ctx context.Context, now time.Time) ([]model.JobSchedule, error) {
+	filter := bson.M{
+		"enabled":     true,
+		"next_run_at": bson.M{"$lte": now},
+	}
+
+	cursor, err := m.jobSchedulesCol.Find(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get due job schedules")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var schedules []model.JobSchedule
+	if err = cursor.All(ctx, &schedules); err != nil {
+		log.Error().Err(err).Msg("Failed to decode due job schedules")
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// ClaimJobScheduleRun moves a schedule's next run forward only if nobody else has already done so,
+// which keeps a tick from firing twice when more than one API instance is running
+func (m *mongoDB) ClaimJobScheduleRun(ctx context.Context, id primitive.ObjectID, expectedNextRun time.Time, nextRun time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":         id,
+		"next_run_at": expectedNextRun,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"next_run_at": nextRun,
+			"updated_at":  time.Now(),
+		},
+	}
+
+	result, err := m.jobSchedulesCol.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Error().Err(err).Str("scheduleID", id.Hex()).Msg("Failed to claim job schedule run")
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// RecordJobScheduleRun stores the job created by a tick, or the reason the tick was skipped
+func (m *mongoDB) RecordJobScheduleRun(ctx context.Context, id primitive.ObjectID, jobID *primitive.ObjectID, skipReason string) error {
+	now := time.Now()
+	set := bson.M{
+		"last_run_at":      now,
+		"last_skip_reason": skipReason,
+		"updated_at":       now,
+	}
+
+	if jobID != nil {
+		set["last_job_id"] = *jobID
+	}
+
+	result, err := m.jobSchedulesCol.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		log.Error().Err(err).Str("scheduleID", id.Hex()).Msg("Failed to record job schedule run")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("scheduleID", id.Hex()).Msg("Job schedule not found for run update")
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"harvest/internal/model"
 	"time"
 
@@ -14,6 +15,8 @@ import (
 
 // JobDatabase defines job-related database operations
 type JobDatabase interface {
+	JobItemDatabase
+
 	// Create a new job
 	CreateJob(ctx context.Context, job *model.Job) error
 
@@ -23,8 +26,11 @@ type JobDatabase interface {
 	// Update a job's status
 	UpdateJobStatus(ctx context.Context, id primitive.ObjectID, status model.JobStatus) error
 
-	// List all jobs with optional filtering
-	ListJobs(ctx context.Context) ([]model.Job, error)
+	// List a page of jobs matching the filter, with the total number of matches
+	ListJobs(ctx context.Context, filter model.JobFilter) ([]model.Job, int64, error)
+
+	// Move finished jobs that completed before the cutoff to the archive. Returns the number moved.
+	ArchiveJobs(ctx context.Context, completedBefore time.Time) (int64, error)
 
 	// Update a job's metrics and recalculate progress
 	UpdateJobMetrics(ctx context.Context, id primitive.ObjectID, metrics model.JobMetrics) error
@@ -34,6 +40,46 @@ type JobDatabase interface {
 
 	// Increment the batches complete count
 	IncrementJobBatchesComplete(ctx context.Context, id primitive.ObjectID, increment int) error
+
+	// Add the projected counts of a dry run batch to the job's dry run result
+	IncrementJobDryRunResult(ctx context.Context, id primitive.ObjectID, result model.DryRunResult) error
+
+	// Record the number of batches a job has fully completed. This also counts as a heartbeat.
+	SaveJobCheckpoint(ctx context.Context, id primitive.ObjectID, completedBatches int) error
+
+	// Record the completed batches and the ID of the last completed item of a job
+	SaveJobItemCheckpoint(ctx context.Context, id primitive.ObjectID, completedBatches int, lastItemID string) error
+
+	// Record that the instance running a job is still alive
+	RecordJobHeartbeat(ctx context.Context, id primitive.ObjectID) error
+
+	// List all jobs currently in the given status
+	GetJobsByStatus(ctx context.Context, status model.JobStatus) ([]model.Job, error)
+
+	// Move a job to the queued status for resuming if it is still in the expected status
+	ClaimJobForResume(ctx context.Context, id primitive.ObjectID, expected model.JobStatus) (bool, error)
+
+	// Move a processing job to the queued status for resuming if it belonged to the worker or its
+	// heartbeat is older than staleBefore
+	ClaimOrphanedJob(ctx context.Context, id primitive.ObjectID, workerID string, staleBefore time.Time) (bool, error)
+
+	// Mark a job as waiting for an automatic retry and count the attempt
+	MarkJobRetrying(ctx context.Context, id primitive.ObjectID, lastError string, nextRetryAt time.Time) error
+
+	// Record the error that made a job fail
+	SetJobLastError(ctx context.Context, id primitive.ObjectID, lastError string) error
+
+	// Record which worker instance is processing a job and when it started
+	MarkJobStarted(ctx context.Context, id primitive.ObjectID, workerID string) error
+
+	// Fail a processing job that stopped making progress. Returns false if it is no longer processing.
+	FailStuckJob(ctx context.Context, id primitive.ObjectID, reason string) (bool, error)
+
+	// Persist a cancel request on a processing job. Returns false if the job isn't processing.
+	RequestJobCancel(ctx context.Context, id primitive.ObjectID) (bool, error)
+
+	// Check whether a cancel was requested for a job
+	IsJobCancelRequested(ctx context.Context, id primitive.ObjectID) (bool, error)
 }
 
 // CreateJob creates a new job in the database
@@ -108,26 +154,127 @@ func (m *mongoDB) UpdateJobStatus(ctx context.Context, id primitive.ObjectID, st
 	return nil
 }
 
-// ListJobs retrieves all jobs from the database, sorted by most recently updated first
-func (m *mongoDB) ListJobs(ctx context.Context) ([]model.Job, error) {
-	// Create options to sort by updated_at in descending order
-	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+// ListJobs retrieves a page of jobs matching the filter, sorted by most recently updated first by default
+func (m *mongoDB) ListJobs(ctx context.Context, filter model.JobFilter) ([]model.Job, int64, error) {
+	query := bson.M{}
+	if len(filter.Statuses) > 0 {
+		query["status"] = bson.M{"$in": filter.Statuses}
+	}
+	if len(filter.Types) > 0 {
+		query["type"] = bson.M{"$in": filter.Types}
+	}
+	if filter.TokenID != "" {
+		query["user_id"] = filter.TokenID
+	}
+	if filter.CreatedAfter != nil || filter.CreatedBefore != nil {
+		createdAt := bson.M{}
+		if filter.CreatedAfter != nil {
+			createdAt["$gte"] = *filter.CreatedAfter
+		}
+		if filter.CreatedBefore != nil {
+			createdAt["$lte"] = *filter.CreatedBefore
+		}
+		query["created_at"] = createdAt
+	}
+
+	col := m.jobsCol
+	if filter.Archived {
+		col = m.jobsArchiveCol
+	}
+
+	total, err := col.CountDocuments(ctx, query)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count jobs")
+		return nil, 0, err
+	}
+
+	sortBy := filter.SortBy
+	if sortBy != model.JobSortCreatedAt {
+		sortBy = model.JobSortUpdatedAt
+	}
+	order := -1
+	if filter.Ascending {
+		order = 1
+	}
 
-	cursor, err := m.jobsCol.Find(ctx, bson.M{}, opts)
+	// _id breaks ties so pages don't overlap
+	findOptions := options.Find().SetSort(bson.D{{Key: sortBy, Value: order}, {Key: "_id", Value: order}})
+	if filter.Size > 0 {
+		findOptions.SetLimit(int64(filter.Size))
+		if filter.Page > 0 {
+			findOptions.SetSkip(int64(filter.Page * filter.Size))
+		}
+	}
+
+	cursor, err := col.Find(ctx, query, findOptions)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to list jobs")
-		return nil, err
+		return nil, 0, err
 	}
 	defer cursor.Close(ctx)
 
 	var jobs []model.Job
 	if err = cursor.All(ctx, &jobs); err != nil {
 		log.Error().Err(err).Msg("Failed to decode jobs")
-		return nil, err
+		return nil, 0, err
 	}
 
-	log.Debug().Int("count", len(jobs)).Msg("Retrieved jobs list")
-	return jobs, nil
+	log.Debug().Int("count", len(jobs)).Int64("total", total).Msg("Retrieved jobs list")
+	return jobs, total, nil
+}
+
+// Jobs moved to the archive per round trip
+const archiveBatchSize = 500
+
+// ArchiveJobs copies finished jobs to the archive collection and then removes them from the jobs
+// collection, in batches. Finished jobs are no longer written to, so nothing is lost between the two steps.
+func (m *mongoDB) ArchiveJobs(ctx context.Context, completedBefore time.Time) (int64, error) {
+	filter := bson.M{
+		"status":       bson.M{"$in": []model.JobStatus{model.StatusCompleted, model.StatusFailed, model.StatusCancelled}},
+		"completed_at": bson.M{"$lt": completedBefore},
+	}
+
+	var archived int64
+	for {
+		cursor, err := m.jobsCol.Find(ctx, filter, options.Find().SetLimit(archiveBatchSize))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to find jobs to archive")
+			return archived, err
+		}
+
+		var jobs []model.Job
+		if err = cursor.All(ctx, &jobs); err != nil {
+			log.Error().Err(err).Msg("Failed to decode jobs to archive")
+			return archived, err
+		}
+
+		if len(jobs) == 0 {
+			return archived, nil
+		}
+
+		documents := make([]interface{}, len(jobs))
+		ids := make([]primitive.ObjectID, len(jobs))
+		for i, job := range jobs {
+			documents[i] = job
+			ids[i] = job.ID
+		}
+
+		// A previous run may have stopped after copying, so jobs already in the archive are skipped
+		_, err = m.jobsArchiveCol.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+		if err != nil && !onlyDuplicateKeyErrors(err) {
+			log.Error().Err(err).Msg("Failed to copy jobs to the archive")
+			return archived, err
+		}
+
+		result, err := m.jobsCol.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to remove archived jobs")
+			return archived, err
+		}
+
+		archived += result.DeletedCount
+		log.Debug().Int64("count", result.DeletedCount).Msg("Archived jobs")
+	}
 }
 
 // UpdateJobMetrics increments the metrics of a job by the provided values
@@ -215,3 +362,355 @@ func (m *mongoDB) IncrementJobBatchesComplete(ctx context.Context, id primitive.
 	log.Debug().Str("jobID", id.Hex()).Int("increment", increment).Msg("Incremented job batches complete")
 	return nil
 }
+
+// IncrementJobDryRunResult increments the dry run result of a job by the provided values
+func (m *mongoDB) IncrementJobDryRunResult(ctx context.Context, id primitive.ObjectID, result model.DryRunResult) error {
+	update := bson.M{
+		"$inc": bson.M{
+			"dry_run_result.discovered_matches": result.DiscoveredMatches,
+			"dry_run_result.existing_matches":   result.ExistingMatches,
+			"dry_run_result.new_matches":        result.NewMatches,
+			"dry_run_result.discovery_requests": result.DiscoveryRequests,
+			"dry_run_result.match_requests":     result.MatchRequests,
+		},
+		"$set": bson.M{
+			"updated_at": time.Now(),
+		},
+	}
+
+	updateResult, err := m.jobsCol.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to increment job dry run result")
+		return err
+	}
+
+	if updateResult.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// SaveJobCheckpoint records the number of completed batches for a job so it can be resumed later
+func (m *mongoDB) SaveJobCheckpoint(ctx context.Context, id primitive.ObjectID, completedBatches int) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"checkpoint.completed_batches": completedBatches,
+			"checkpoint.updated_at":        now,
+			"heartbeat_at":                 now,
+			"updated_at":                   now,
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Int("completedBatches", completedBatches).Msg("Failed to save job checkpoint")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("jobID", id.Hex()).Msg("Job not found for checkpoint update")
+		return mongo.ErrNoDocuments
+	}
+
+	log.Debug().Str("jobID", id.Hex()).Int("completedBatches", completedBatches).Msg("Saved job checkpoint")
+	return nil
+}
+
+// GetJobsByStatus retrieves all jobs with the given status, oldest first
+func (m *mongoDB) GetJobsByStatus(ctx context.Context, status model.JobStatus) ([]model.Job, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := m.jobsCol.Find(ctx, bson.M{"status": status}, opts)
+	if err != nil {
+		log.Error().Err(err).Str("status", string(status)).Msg("Failed to get jobs by status")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []model.Job
+	if err = cursor.All(ctx, &jobs); err != nil {
+		log.Error().Err(err).Str("status", string(status)).Msg("Failed to decode jobs")
+		return nil, err
+	}
+
+	log.Debug().Str("status", string(status)).Int("count", len(jobs)).Msg("Retrieved jobs by status")
+	return jobs, nil
+}
+
+// ClaimJobForResume atomically moves a job back to queued and bumps its resume count, but only
+// if the job is still in the expected status. Returns false if another caller already claimed it.
+func (m *mongoDB) ClaimJobForResume(ctx context.Context, id primitive.ObjectID, expected model.JobStatus) (bool, error) {
+	filter := bson.M{
+		"_id":    id,
+		"status": expected,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     model.StatusQueued,
+			"updated_at": time.Now(),
+		},
+		"$inc": bson.M{
+			"checkpoint.resume_count": 1,
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to claim job for resume")
+		return false, err
+	}
+
+	claimed := result.ModifiedCount > 0
+	log.Debug().Str("jobID", id.Hex()).Bool("claimed", claimed).Msg("Claim job for resume")
+	return claimed, nil
+}
+
+// ClaimOrphanedJob claims a processing job whose instance is gone. The check and the status change
+// happen in one update, so only one instance resumes the job and a job that is still sending
+// heartbeats is left alone.
+func (m *mongoDB) ClaimOrphanedJob(ctx context.Context, id primitive.ObjectID, workerID string, staleBefore time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":    id,
+		"status": model.StatusProcessing,
+		"$or": bson.A{
+			bson.M{"worker_id": workerID},
+			bson.M{"heartbeat_at": bson.M{"$lt": staleBefore}},
+			bson.M{"heartbeat_at": bson.M{"$exists": false}},
+		},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     model.StatusQueued,
+			"updated_at": time.Now(),
+		},
+		"$inc": bson.M{
+			"checkpoint.resume_count": 1,
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to claim orphaned job")
+		return false, err
+	}
+
+	claimed := result.ModifiedCount > 0
+	log.Debug().Str("jobID", id.Hex()).Bool("claimed", claimed).Msg("Claim orphaned job")
+	return claimed, nil
+}
+
+// MarkJobRetrying moves a job to the retrying status, bumps its retry count and records when it will run again
+func (m *mongoDB) MarkJobRetrying(ctx context.Context, id primitive.ObjectID, lastError string, nextRetryAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":        model.StatusRetrying,
+			"last_error":    lastError,
+			"next_retry_at": nextRetryAt,
+			"updated_at":    time.Now(),
+		},
+		"$inc": bson.M{
+			"retry_count": 1,
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to mark job for retry")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("jobID", id.Hex()).Msg("Job not found for retry update")
+		return mongo.ErrNoDocuments
+	}
+
+	log.Debug().Str("jobID", id.Hex()).Time("nextRetryAt", nextRetryAt).Msg("Marked job for retry")
+	return nil
+}
+
+// SetJobLastError records the error a job finished with
+func (m *mongoDB) SetJobLastError(ctx context.Context, id primitive.ObjectID, lastError string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"last_error": lastError,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to set job error")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("jobID", id.Hex()).Msg("Job not found for error update")
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// MarkJobStarted records the worker instance that is processing a job and when it started.
+// The heartbeat starts from the same moment.
+func (m *mongoDB) MarkJobStarted(ctx context.Context, id primitive.ObjectID, workerID string) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"worker_id":    workerID,
+			"started_at":   now,
+			"heartbeat_at": now,
+			"updated_at":   now,
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Str("workerID", workerID).Msg("Failed to assign job worker")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("jobID", id.Hex()).Msg("Job not found for worker assignment")
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// SaveJobItemCheckpoint records the completed batches and the last completed item of a job, so it
+// can be resumed after that item
+func (m *mongoDB) SaveJobItemCheckpoint(ctx context.Context, id primitive.ObjectID, completedBatches int, lastItemID string) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"checkpoint.completed_batches": completedBatches,
+			"checkpoint.last_item_id":      lastItemID,
+			"checkpoint.updated_at":        now,
+			"heartbeat_at":                 now,
+			"updated_at":                   now,
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Str("lastItemID", lastItemID).Msg("Failed to save job checkpoint")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("jobID", id.Hex()).Msg("Job not found for checkpoint update")
+		return mongo.ErrNoDocuments
+	}
+
+	log.Debug().Str("jobID", id.Hex()).Str("lastItemID", lastItemID).Msg("Saved job checkpoint")
+	return nil
+}
+
+// RecordJobHeartbeat updates the heartbeat of a job that is still being processed
+func (m *mongoDB) RecordJobHeartbeat(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{
+			"heartbeat_at": time.Now(),
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, bson.M{"_id": id, "status": model.StatusProcessing}, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to record job heartbeat")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("jobID", id.Hex()).Msg("Processing job not found for heartbeat")
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// RequestJobCancel records a cancel request on a processing job
+func (m *mongoDB) RequestJobCancel(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":    id,
+		"status": model.StatusProcessing,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"cancel_requested_at": now,
+			"updated_at":          now,
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to request job cancel")
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// IsJobCancelRequested reports whether a cancel request was recorded on a job
+func (m *mongoDB) IsJobCancelRequested(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":                 id,
+		"cancel_requested_at": bson.M{"$exists": true},
+	}
+
+	count, err := m.jobsCol.CountDocuments(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to check job cancel request")
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// onlyDuplicateKeyErrors reports whether every failed insert was a document that already exists
+func onlyDuplicateKeyErrors(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return false
+	}
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FailStuckJob marks a processing job as failed with the reason it was stopped
+func (m *mongoDB) FailStuckJob(ctx context.Context, id primitive.ObjectID, reason string) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":    id,
+		"status": model.StatusProcessing,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":       model.StatusFailed,
+			"last_error":   reason,
+			"completed_at": now,
+			"updated_at":   now,
+		},
+	}
+
+	result, err := m.jobsCol.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Error().Err(err).Str("jobID", id.Hex()).Msg("Failed to fail stuck job")
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
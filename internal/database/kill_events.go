@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"harvest/internal/model"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// KillEventDatabase defines kill event related database operations
+type KillEventDatabase interface {
+	// Replace the kill events of a match, so processing a match again doesn't duplicate them
+	ReplaceMatchKillEvents(ctx context.Context, matchID string, kills []model.KillEvent) error
+
+	// Get the kill events of a match in the order they happened
+	GetKillEventsByMatchID(ctx context.Context, matchID string) ([]model.KillEvent, error)
+}
+
+// ReplaceMatchKillEvents deletes the stored kill events of a match and inserts the given ones
+func (m *mongoDB) ReplaceMatchKillEvents(ctx context.Context, matchID string, kills []model.KillEvent) error {
+	filter := bson.M{"match_id": matchID}
+	if _, err := m.killEventsCol.DeleteMany(ctx, filter); err != nil {
+		log.Error().Err(err).Str("matchID", matchID).Msg("Failed to delete kill events")
+		return err
+	}
+
+	if len(kills) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(kills))
+	for i, kill := range kills {
+		kill.MatchID = matchID
+		documents[i] = kill
+	}
+
+	if _, err := m.killEventsCol.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false)); err != nil {
+		log.Error().Err(err).Str("matchID", matchID).Msg("Failed to insert kill events")
+		return err
+	}
+
+	log.Debug().Str("matchID", matchID).Int("count", len(kills)).Msg("Stored kill events")
+	return nil
+}
+
+// GetKillEventsByMatchID retrieves the kill events of a match sorted by time
+func (m *mongoDB) GetKillEventsByMatchID(ctx context.Context, matchID string) ([]model.KillEvent, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	cursor, err := m.killEventsCol.Find(ctx, bson.M{"match_id": matchID}, findOptions)
+	if err != nil {
+		log.Error().Err(err).Str("matchID", matchID).Msg("Failed to get kill events")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	kills := []model.KillEvent{}
+	if err := cursor.All(ctx, &kills); err != nil {
+		log.Error().Err(err).Str("matchID", matchID).Msg("Failed to decode kill events")
+		return nil, err
+	}
+
+	return kills, nil
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"harvest/internal/model"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PipelineDatabase defines job pipeline database operations
+type PipelineDatabase interface {
+	// Create a new pipeline
+	CreatePipeline(ctx context.Context, pipeline *model.Pipeline) error
+
+	// Get a pipeline by ID
+	GetPipelineByID(ctx context.Context, id primitive.ObjectID) (*model.Pipeline, error)
+
+	// List all pipelines, newest first
+	ListPipelines(ctx context.Context) ([]model.Pipeline, error)
+
+	// Attach a job to a pending step, returning false if the step was already started
+	ClaimPipelineStep(ctx context.Context, id primitive.ObjectID, stepName string, jobID primitive.ObjectID) (bool, error)
+
+	// Update the status of a single step
+	UpdatePipelineStepStatus(ctx context.Context, id primitive.ObjectID, stepName string, status model.PipelineStepStatus, stepErr string) error
+
+	// Mark every step that has not started yet as skipped
+	SkipPendingPipelineSteps(ctx context.Context, id primitive.ObjectID) error
+
+	// Update the overall status of a pipeline
+	UpdatePipelineStatus(ctx context.Context, id primitive.ObjectID, status model.PipelineStatus) error
+}
+
+// CreatePipeline creates a new pipeline in the database
+func (m *mongoDB) CreatePipeline(ctx context.Context, pipeline *model.Pipeline) error {
+	if pipeline.ID.IsZero() {
+		pipeline.ID = primitive.NewObjectID()
+	}
+
+	now := time.Now()
+	pipeline.CreatedAt = now
+	pipeline.UpdatedAt = now
+
+	_, err := m.pipelinesCol.InsertOne(ctx, pipeline)
+	if err != nil {
+		log.Error().Err(err).Str("pipelineID", pipeline.ID.Hex()).Msg("Failed to create pipeline")
+		return err
+	}
+
+	log.Debug().Str("pipelineID", pipeline.ID.Hex()).Int("steps", len(pipeline.Steps)).Msg("Created pipeline")
+	return nil
+}
+
+// GetPipelineByID retrieves a pipeline by its ID
+func (m *mongoDB) GetPipelineByID(ctx context.Context, id primitive.ObjectID) (*model.Pipeline, error) {
+	var pipeline model.Pipeline
+
+	err := m.pipelinesCol.FindOne(ctx, bson.M{"_id": id}).Decode(&pipeline)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			log.Debug().Str("pipelineID", id.Hex()).Msg("Pipeline not found")
+			return nil, nil
+		}
+		log.Error().Err(err).Str("pipelineID", id.Hex()).Msg("Failed to get pipeline")
+		return nil, err
+	}
+
+	return &pipeline, nil
+}
+
+// ListPipelines retrieves all pipelines, sorted by creation time descending
+func (m *mongoDB) ListPipelines(ctx context.Context) ([]model.Pipeline, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := m.pipelinesCol.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list pipelines")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pipelines []model.Pipeline
+	if err = cursor.All(ctx, &pipelines); err != nil {
+		log.Error().Err(err).Msg("Failed to decode pipelines")
+		return nil, err
+	}
+
+	log.Debug().Int("count", len(pipelines)).Msg("Retrieved pipelines")
+	return pipelines, nil
+}
+
+// ClaimPipelineStep moves a step from pending to queued and records its job. Only one caller can
+// win the claim, so a step with several predecessors finishing at once is started exactly once.
+func (m *mongoDB) ClaimPipelineStep(ctx context.Context, id primitive.ObjectID, stepName string, jobID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id": id,
+		"steps": bson.M{"$elemMatch": bson.M{
+			"name":   stepName,
+			"status": model.StepPending,
+		}},
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"steps.$.status":     model.StepQueued,
+			"steps.$.job_id":     jobID,
+			"steps.$.started_at": now,
+			"updated_at":         now,
+		},
+	}
+
+	result, err := m.pipelinesCol.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Error().Err(err).Str("pipelineID", id.Hex()).Str("step", stepName).Msg("Failed to claim pipeline step")
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// UpdatePipelineStepStatus updates the status of a step, recording the completion time once it finishes
+func (m *mongoDB) UpdatePipelineStepStatus(ctx context.Context, id primitive.ObjectID, stepName string, status model.PipelineStepStatus, stepErr string) error {
+	now := time.Now()
+	set := bson.M{
+		"steps.$.status": status,
+		"updated_at":     now,
+	}
+
+	if stepErr != "" {
+		set["steps.$.error"] = stepErr
+	}
+
+	if (model.PipelineStep{Status: status}).IsFinished() {
+		set["steps.$.completed_at"] = now
+	}
+
+	filter := bson.M{"_id": id, "steps.name": stepName}
+	result, err := m.pipelinesCol.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		log.Error().Err(err).Str("pipelineID", id.Hex()).Str("step", stepName).Msg("Failed to update pipeline step status")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("pipelineID", id.Hex()).Str("step", stepName).Msg("Pipeline step not found for status update")
+		return mongo.ErrNoDocuments
+	}
+
+	log.Debug().
+		Str("pipelineID", id.Hex()).
+		Str("step", stepName).
+		Str("status", string(status)).
+		Msg("Updated pipeline step status")
+	return nil
+}
+
+// SkipPendingPipelineSteps marks all steps that were never started as skipped
+func (m *mongoDB) SkipPendingPipelineSteps(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"steps.$[step].status":       model.StepSkipped,
+			"steps.$[step].completed_at": now,
+			"updated_at":                 now,
+		},
+	}
+
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"step.status": model.StepPending}},
+	})
+
+	_, err := m.pipelinesCol.UpdateOne(ctx, bson.M{"_id": id}, update, opts)
+	if err != nil {
+		log.Error().Err(err).Str("pipelineID", id.Hex()).Msg("Failed to skip pending pipeline steps")
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePipelineStatus updates the overall status of a pipeline
+func (m *mongoDB) UpdatePipelineStatus(ctx context.Context, id primitive.ObjectID, status model.PipelineStatus) error {
+	now := time.Now()
+	set := bson.M{
+		"status":     status,
+		"updated_at": now,
+	}
+
+	if status != model.PipelineRunning {
+		set["completed_at"] = now
+	}
+
+	result, err := m.pipelinesCol.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		log.Error().Err(err).Str("pipelineID", id.Hex()).Msg("Failed to update pipeline status")
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Debug().Str("pipelineID", id.Hex()).Msg("Pipeline not found for status update")
+		return mongo.ErrNoDocuments
+	}
+
+	log.Debug().Str("pipelineID", id.Hex()).Str("status", string(status)).Msg("Updated pipeline status")
+	return nil
+}
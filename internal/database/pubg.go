@@ -22,10 +22,12 @@ type PubgDatabase interface {
 	ImportMatch(context.Context, model.Match) (bool, error)
 	ImportMatches(context.Context, []model.Match) (int, error)
 	GetProcessedMatchIDs(context.Context) ([]string, error)
-	GetUnProcessedMatches(ctx context.Context, minDuration int) ([]model.Match, error)
+	GetUnProcessedMatches(ctx context.Context, minDuration int, startDate *time.Time, endDate *time.Time) ([]model.Match, error)
+	GetUnProcessedMatchesByIDs(ctx context.Context, matchIDs []string) ([]model.Match, error)
 	GetMatchesByType(context.Context, string, int) ([]model.Match, error)
 	MarkMatchAsProcessed(context.Context, string) error
 	BulkImportMatches(ctx context.Context, matches []model.Match) (model.BulkImportResult, error)
+	GetExistingMatchIDs(ctx context.Context, matchIDs []string) ([]string, error)
 
 	UpdateMatchesWithTelemetryData(context.Context, map[string]*model.TelemetryData) (int, error)
 	GetMatchesByFilters(ctx context.Context, mapName string, matchTypes []string, startDate *time.Time, endDate *time.Time, limit int) ([]model.Match, error)
@@ -140,7 +142,8 @@ func (m *mongoDB) getEntities(ctx context.Context, col *mongo.Collection, page,
 func (m *mongoDB) getActiveEntities(ctx context.Context, col *mongo.Collection, limit int) ([]model.Entity, error) {
 	filter := bson.M{"active": true}
 
-	findOptions := options.Find()
+	// Sort by ID so batches are built in the same order each run and job checkpoints stay valid
+	findOptions := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
 
 	if limit > 0 {
 		findOptions.SetLimit(int64(limit))
@@ -352,13 +355,58 @@ func (m *mongoDB) BulkImportMatches(ctx context.Context, matches []model.Match)
 	}, nil
 }
 
-func (m *mongoDB) GetUnProcessedMatches(ctx context.Context, minDuration int) ([]model.Match, error) {
+// GetExistingMatchIDs returns the given match IDs that are already stored
+func (m *mongoDB) GetExistingMatchIDs(ctx context.Context, matchIDs []string) ([]string, error) {
+	if len(matchIDs) == 0 {
+		return []string{}, nil
+	}
+
+	filter := bson.M{"match_id": bson.M{"$in": matchIDs}}
+	findOptions := options.Find().SetProjection(bson.M{"match_id": 1, "_id": 0})
+
+	cursor, err := m.matchesCol.Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Error().Err(err).Int("count", len(matchIDs)).Msg("Error retrieving existing match IDs")
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var matches []struct {
+		MatchID string `bson:"match_id"`
+	}
+
+	if err = cursor.All(ctx, &matches); err != nil {
+		log.Error().Err(err).Msg("Error decoding existing match IDs")
+		return nil, err
+	}
+
+	existing := make([]string, len(matches))
+	for i, match := range matches {
+		existing[i] = match.MatchID
+	}
+
+	return existing, nil
+}
+
+func (m *mongoDB) GetUnProcessedMatches(ctx context.Context, minDuration int, startDate *time.Time, endDate *time.Time) ([]model.Match, error) {
 	// Define filter for unprocessed matches with minimum duration
 	filter := bson.M{
 		"processed": bson.M{"$ne": true},
 		"duration":  bson.M{"$gt": minDuration},
 	}
 
+	// Optionally restrict to matches played within a date window
+	if startDate != nil || endDate != nil {
+		dateFilter := bson.M{}
+		if startDate != nil {
+			dateFilter["$gte"] = *startDate
+		}
+		if endDate != nil {
+			dateFilter["$lte"] = *endDate
+		}
+		filter["created_at"] = dateFilter
+	}
+
 	// Execute the find operation
 	cursor, err := m.matchesCol.Find(ctx, filter)
 	if err != nil {
@@ -377,6 +425,29 @@ func (m *mongoDB) GetUnProcessedMatches(ctx context.Context, minDuration int) ([
 	return matches, nil
 }
 
+// GetUnProcessedMatchesByIDs returns the matches from the given list that have not been processed yet
+func (m *mongoDB) GetUnProcessedMatchesByIDs(ctx context.Context, matchIDs []string) ([]model.Match, error) {
+	filter := bson.M{
+		"processed": bson.M{"$ne": true},
+		"match_id":  bson.M{"$in": matchIDs},
+	}
+
+	cursor, err := m.matchesCol.Find(ctx, filter)
+	if err != nil {
+		log.Error().Msgf("Error retrieving unprocessed matches by ID: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var matches []model.Match
+	if err = cursor.All(ctx, &matches); err != nil {
+		log.Error().Msgf("Error decoding matches: %v", err)
+		return nil, err
+	}
+
+	return matches, nil
+}
+
 // UpdateMatchesWithTelemetryData updates multiple matches with processed telemetry data in a batch
 func (m *mongoDB) UpdateMatchesWithTelemetryData(ctx context.Context, updates map[string]*model.TelemetryData) (int, error) {
 	if len(updates) == 0 {
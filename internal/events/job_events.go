@@ -0,0 +1,186 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"harvest/internal/config"
+	"harvest/internal/model"
+	"harvest/internal/rabbitmq"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultEventsExchange = "job_events"
+
+	// Events buffered per subscriber before new events are dropped for a slow reader
+	subscriberBufferSize = 64
+)
+
+// JobEventBus broadcasts job progress events to every process and hands them to local subscribers
+type JobEventBus interface {
+	// PublishJobEvent broadcasts an event. Failures are logged, progress updates are best effort.
+	PublishJobEvent(event model.JobEvent)
+
+	// Subscribe returns the events of a single job until the returned function is called
+	Subscribe(jobID string) (<-chan model.JobEvent, func())
+
+	// Start consumes broadcast events until the context is cancelled
+	Start(ctx context.Context) error
+}
+
+// rabbitJobEventBus publishes events to a fanout exchange. Each process binds its own exclusive
+// queue, so workers and HTTP handlers in different processes all see every event.
+type rabbitJobEventBus struct {
+	client      rabbitmq.Client
+	exchange    string
+	subscribers map[string]map[chan model.JobEvent]struct{}
+	mu          sync.RWMutex
+}
+
+// NewRabbitJobEventBus creates an event bus on top of the RabbitMQ client
+func NewRabbitJobEventBus(client rabbitmq.Client, cfg config.RabbitMQConfig) JobEventBus {
+	exchange := cfg.EventsExchange
+	if exchange == "" {
+		exchange = defaultEventsExchange
+	}
+
+	return &rabbitJobEventBus{
+		client:      client,
+		exchange:    exchange,
+		subscribers: make(map[string]map[chan model.JobEvent]struct{}),
+	}
+}
+
+func (b *rabbitJobEventBus) PublishJobEvent(event model.JobEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Str("jobId", event.JobID).Msg("Failed to marshal job event")
+		return
+	}
+
+	headers := amqp.Table{
+		"job_id":     event.JobID,
+		"event_type": string(event.Type),
+	}
+
+	if err := b.client.Publish(b.exchange, "", body, headers); err != nil {
+		log.Error().Err(err).Str("jobId", event.JobID).Msg("Failed to publish job event")
+	}
+}
+
+func (b *rabbitJobEventBus) Subscribe(jobID string) (<-chan model.JobEvent, func()) {
+	events := make(chan model.JobEvent, subscriberBufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[jobID] == nil {
+		b.subscribers[jobID] = make(map[chan model.JobEvent]struct{})
+	}
+	b.subscribers[jobID][events] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subscribers[jobID], events)
+			if len(b.subscribers[jobID]) == 0 {
+				delete(b.subscribers, jobID)
+			}
+			close(events)
+		})
+	}
+
+	return events, unsubscribe
+}
+
+func (b *rabbitJobEventBus) Start(ctx context.Context) error {
+	if err := b.client.DeclareExchange(b.exchange, "fanout"); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", b.exchange, err)
+	}
+
+	consumerTag := fmt.Sprintf("job-events-%s", primitive.NewObjectID().Hex())
+
+	go func() {
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			// The exclusive queue disappears with the connection, so it is declared again on every reconnect
+			if err := b.consume(ctx, consumerTag); err != nil {
+				log.Error().Err(err).Str("exchange", b.exchange).Msg("Failed to consume job events")
+			} else if ctx.Err() == nil {
+				log.Warn().Str("exchange", b.exchange).Msg("Job event channel closed, reconnecting...")
+			}
+
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	log.Info().Str("exchange", b.exchange).Msg("Job event bus started")
+	return nil
+}
+
+// consume binds a fresh exclusive queue and dispatches events until the delivery channel closes
+func (b *rabbitJobEventBus) consume(ctx context.Context, consumerTag string) error {
+	queue, err := b.client.DeclareExclusiveQueue()
+	if err != nil {
+		return err
+	}
+
+	if err := b.client.BindQueue(queue.Name, b.exchange, ""); err != nil {
+		return err
+	}
+
+	deliveries, err := b.client.Consume(queue.Name, consumerTag)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return nil
+			}
+
+			delivery.Ack(false)
+
+			var event model.JobEvent
+			if err := json.Unmarshal(delivery.Body, &event); err != nil {
+				log.Warn().Err(err).Msg("Dropping malformed job event")
+				continue
+			}
+
+			b.dispatch(event)
+		}
+	}
+}
+
+// dispatch hands an event to the local subscribers of its job without blocking on slow readers
+func (b *rabbitJobEventBus) dispatch(event model.JobEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for subscriber := range b.subscribers[event.JobID] {
+		select {
+		case subscriber <- event:
+		default:
+			log.Debug().Str("jobId", event.JobID).Msg("Job event subscriber is full, dropping event")
+		}
+	}
+}
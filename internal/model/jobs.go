@@ -18,6 +18,35 @@ const (
 	StatusCancelled  JobStatus = "cancelled"
 )
 
+// JobPriority decides which queued jobs of the same queue are run first
+type JobPriority string
+
+const (
+	PriorityLow    JobPriority = "low"
+	PriorityNormal JobPriority = "normal"
+	PriorityHigh   JobPriority = "high"
+)
+
+// MaxMessagePriority is the highest RabbitMQ message priority a job queue supports
+const MaxMessagePriority = 9
+
+// IsValid reports whether the priority is one of the known levels
+func (p JobPriority) IsValid() bool {
+	return p == PriorityLow || p == PriorityNormal || p == PriorityHigh
+}
+
+// MessagePriority maps the level to a RabbitMQ message priority. Unknown levels count as normal.
+func (p JobPriority) MessagePriority() uint8 {
+	switch p {
+	case PriorityLow:
+		return 1
+	case PriorityHigh:
+		return MaxMessagePriority
+	default:
+		return 5
+	}
+}
+
 // JobResultType represents the outcome of a processed item
 type JobResultType string
 
@@ -25,8 +54,37 @@ const (
 	ResultSuccess JobResultType = "success"
 	ResultWarning JobResultType = "warning"
 	ResultFailure JobResultType = "failure"
+	ResultInvalid JobResultType = "invalid"
+	ResultSkipped JobResultType = "skipped"
 )
 
+// Kinds of entity a job item can refer to
+const (
+	ItemMatch      = "match"
+	ItemPlayer     = "player"
+	ItemTeam       = "team"
+	ItemTournament = "tournament"
+)
+
+// JobItem records the outcome of a single item processed by a job
+type JobItem struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JobID     primitive.ObjectID `bson:"job_id" json:"job_id"`
+	ItemType  string             `bson:"item_type" json:"item_type"`
+	ItemID    string             `bson:"item_id" json:"item_id"`
+	ParentID  string             `bson:"parent_id,omitempty" json:"parent_id,omitempty"` // e.g. the team a match was processed for
+	Result    JobResultType      `bson:"result" json:"result"`
+	Message   string             `bson:"message,omitempty" json:"message,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// JobItemRef identifies an item to be processed again by a retry job
+type JobItemRef struct {
+	ItemType string `bson:"item_type" json:"item_type"`
+	ItemID   string `bson:"item_id" json:"item_id"`
+	ParentID string `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+}
+
 // JobMetrics tracks the processing statistics for a job
 type JobMetrics struct {
 	ProcessedItems  int `bson:"processed_items" json:"processed_items"`
@@ -38,6 +96,50 @@ type JobMetrics struct {
 	TotalBatches    int `bson:"total_batches" json:"total_batches"`
 }
 
+// JobCheckpoint records how far a job has progressed so it can be resumed
+// from the last completed batch after a restart
+type JobCheckpoint struct {
+	CompletedBatches int        `bson:"completed_batches" json:"completed_batches"`
+	ResumeCount      int        `bson:"resume_count" json:"resume_count"`
+	UpdatedAt        *time.Time `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+
+	// ID of the last completed item, for workers that resume after an item instead of skipping a
+	// number of batches, so items added or removed in the meantime don't shift the position
+	LastItemID string `bson:"last_item_id,omitempty" json:"last_item_id,omitempty"`
+}
+
+// DryRunResult holds what an expander job would have imported. A dry run discovers matches
+// and looks them up in the database, but neither fetches nor imports them.
+type DryRunResult struct {
+	DiscoveredMatches int `bson:"discovered_matches" json:"discovered_matches"` // Unique matches found
+	ExistingMatches   int `bson:"existing_matches" json:"existing_matches"`     // Already stored
+	NewMatches        int `bson:"new_matches" json:"new_matches"`               // Would be imported
+
+	// PUBG API requests made to discover the matches and the match requests a real run would add
+	DiscoveryRequests int `bson:"discovery_requests" json:"discovery_requests"`
+	MatchRequests     int `bson:"match_requests" json:"match_requests"`
+}
+
+// JobLease is the lease document that gives one job at a time the right to run its job type
+type JobLease struct {
+	Key        string             `bson:"_id" json:"key"`
+	Holder     string             `bson:"holder" json:"holder"` // Worker instance running the job
+	JobID      primitive.ObjectID `bson:"job_id" json:"job_id"`
+	AcquiredAt time.Time          `bson:"acquired_at" json:"acquired_at"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expires_at"`
+}
+
+// JobLock shows a job's view of the lease on its job type. While the job is waiting,
+// Holder and HolderJobID point at the job that currently has the lease.
+type JobLock struct {
+	Key         string             `bson:"key" json:"key"`
+	Acquired    bool               `bson:"acquired" json:"acquired"`
+	Holder      string             `bson:"holder" json:"holder"`
+	HolderJobID primitive.ObjectID `bson:"holder_job_id" json:"holder_job_id"`
+	AcquiredAt  time.Time          `bson:"acquired_at" json:"acquired_at"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"expires_at"`
+}
+
 // Job represents a background processing task
 type Job struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -50,4 +152,224 @@ type Job struct {
 	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
 	TokenID     string             `bson:"user_id" json:"user_id"`
 	BatchSize   int                `bson:"batch_size" json:"batch_size"`
+	Checkpoint  JobCheckpoint      `bson:"checkpoint" json:"checkpoint"`
+
+	// Dry runs only project what the job would do, the projection is stored in DryRunResult
+	DryRun       bool          `bson:"dry_run,omitempty" json:"dry_run,omitempty"`
+	DryRunResult *DryRunResult `bson:"dry_run_result,omitempty" json:"dry_run_result,omitempty"`
+
+	// Queue the job is routed to and its priority within that queue
+	Queue    string      `bson:"queue,omitempty" json:"queue,omitempty"`
+	Priority JobPriority `bson:"priority,omitempty" json:"priority,omitempty"`
+
+	// Set when the job was started as a step of a pipeline
+	PipelineID   *primitive.ObjectID `bson:"pipeline_id,omitempty" json:"pipeline_id,omitempty"`
+	PipelineStep string              `bson:"pipeline_step,omitempty" json:"pipeline_step,omitempty"`
+
+	// Instance that is processing the job, when it started and when it last completed a batch
+	WorkerID    string     `bson:"worker_id,omitempty" json:"worker_id,omitempty"`
+	StartedAt   *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	HeartbeatAt *time.Time `bson:"heartbeat_at,omitempty" json:"heartbeat_at,omitempty"`
+
+	// Set when a cancel was requested while the job was processing. The instance running it
+	// stops the job between batches.
+	CancelRequestedAt *time.Time `bson:"cancel_requested_at,omitempty" json:"cancel_requested_at,omitempty"`
+
+	// Cluster-wide lock on the job type, set while the job holds or waits for it
+	Lock *JobLock `bson:"lock,omitempty" json:"lock,omitempty"`
+
+	// Automatic retries of the whole job after a transient failure
+	RetryCount  int        `bson:"retry_count" json:"retry_count"`
+	NextRetryAt *time.Time `bson:"next_retry_at,omitempty" json:"next_retry_at,omitempty"`
+	LastError   string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+
+	// Set when the job only processes the failed items of an earlier job
+	RetryOf    *primitive.ObjectID `bson:"retry_of,omitempty" json:"retry_of,omitempty"`
+	RetryItems []JobItemRef        `bson:"retry_items,omitempty" json:"retry_items,omitempty"`
+}
+
+// RunningSince returns when the job started doing work. A job that waited for its job type lock
+// only counts from the moment it acquired it.
+func (j *Job) RunningSince() *time.Time {
+	if j.Lock != nil && j.Lock.Acquired && (j.StartedAt == nil || j.Lock.AcquiredAt.After(*j.StartedAt)) {
+		return &j.Lock.AcquiredAt
+	}
+	return j.StartedAt
+}
+
+// IsWaitingForLock reports whether the job is waiting for another job of its type to finish
+func (j *Job) IsWaitingForLock() bool {
+	return j.Lock != nil && !j.Lock.Acquired
+}
+
+// IsItemRetry reports whether the job is limited to the failed items of an earlier job
+func (j *Job) IsItemRetry() bool {
+	return j.RetryOf != nil
+}
+
+// RetryItemIDs returns the IDs of the retried items of the given type
+func (j *Job) RetryItemIDs(itemType string) []string {
+	ids := make([]string, 0)
+	for _, item := range j.RetryItems {
+		if item.ItemType == itemType {
+			ids = append(ids, item.ItemID)
+		}
+	}
+	return ids
+}
+
+// Fields a job listing can be sorted by
+const (
+	JobSortCreatedAt = "created_at"
+	JobSortUpdatedAt = "updated_at"
+)
+
+// JobFilter narrows down a job listing. Empty fields don't filter.
+type JobFilter struct {
+	Statuses      []JobStatus `json:"statuses,omitempty"`
+	Types         []string    `json:"types,omitempty"`
+	TokenID       string      `json:"token_id,omitempty"`
+	CreatedAfter  *time.Time  `json:"created_after,omitempty"`
+	CreatedBefore *time.Time  `json:"created_before,omitempty"`
+	Archived      bool        `json:"archived"` // List jobs moved to the archive instead of current jobs
+
+	SortBy    string `json:"sort_by"` // JobSortCreatedAt or JobSortUpdatedAt
+	Ascending bool   `json:"ascending"`
+	Page      int    `json:"page"`
+	Size      int    `json:"size"`
+}
+
+// JobEventType identifies the kind of change a job event describes
+type JobEventType string
+
+const (
+	JobEventMetrics JobEventType = "metrics" // Metrics holds the delta added to the job metrics
+	JobEventBatch   JobEventType = "batch"   // BatchesComplete holds the number of batches just completed
+	JobEventStatus  JobEventType = "status"  // Status holds the new job status
+	JobEventCancel  JobEventType = "cancel"  // A cancel was requested, the instance running the job stops it
+)
+
+// JobEvent is a single progress update of a running job, broadcast to every API instance
+type JobEvent struct {
+	JobID           string       `json:"job_id"`
+	Type            JobEventType `json:"type"`
+	Status          JobStatus    `json:"status,omitempty"`
+	Metrics         *JobMetrics  `json:"metrics,omitempty"`
+	BatchesComplete int          `json:"batches_complete,omitempty"`
+	Timestamp       time.Time    `json:"timestamp"`
+}
+
+// IsFinal reports whether the event moves the job into a status it won't leave
+func (e JobEvent) IsFinal() bool {
+	return e.Type == JobEventStatus && e.Status.IsFinished()
+}
+
+// IsFinished reports whether a job in this status has stopped for good
+func (s JobStatus) IsFinished() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
+// JobSchedule represents a recurring job that is enqueued on a cron schedule
+type JobSchedule struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name           string              `bson:"name" json:"name"`
+	JobType        string              `bson:"job_type" json:"job_type"`
+	Payload        interface{}         `bson:"payload" json:"payload"`
+	CronExpression string              `bson:"cron_expression" json:"cron_expression"`
+	Enabled        bool                `bson:"enabled" json:"enabled"`
+	TokenID        string              `bson:"user_id" json:"user_id"`
+	NextRunAt      *time.Time          `bson:"next_run_at,omitempty" json:"next_run_at,omitempty"`
+	LastRunAt      *time.Time          `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	LastJobID      *primitive.ObjectID `bson:"last_job_id,omitempty" json:"last_job_id,omitempty"`
+	LastSkipReason string              `bson:"last_skip_reason,omitempty" json:"last_skip_reason,omitempty"`
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// PipelineStatus represents the overall state of a pipeline
+type PipelineStatus string
+
+const (
+	PipelineRunning   PipelineStatus = "running"
+	PipelineCompleted PipelineStatus = "completed"
+	PipelineFailed    PipelineStatus = "failed"
+	PipelineCancelled PipelineStatus = "cancelled"
+)
+
+// PipelineStepStatus represents the state of a single pipeline step. Steps that have a job
+// mirror the job status; pending and skipped steps never had a job created.
+type PipelineStepStatus string
+
+const (
+	StepPending    PipelineStepStatus = "pending"
+	StepQueued     PipelineStepStatus = "queued"
+	StepProcessing PipelineStepStatus = "processing"
+	StepCompleted  PipelineStepStatus = "completed"
+	StepFailed     PipelineStepStatus = "failed"
+	StepCancelled  PipelineStepStatus = "cancelled"
+	StepSkipped    PipelineStepStatus = "skipped"
+)
+
+// PipelineStep is a job in a pipeline that starts once every step it depends on has completed
+type PipelineStep struct {
+	Name        string              `bson:"name" json:"name"`
+	JobType     string              `bson:"job_type" json:"job_type"`
+	Payload     interface{}         `bson:"payload" json:"payload"`
+	DependsOn   []string            `bson:"depends_on" json:"depends_on"`
+	Status      PipelineStepStatus  `bson:"status" json:"status"`
+	JobID       *primitive.ObjectID `bson:"job_id,omitempty" json:"job_id,omitempty"`
+	Error       string              `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt   *time.Time          `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// Pipeline is a DAG of job steps where each step is enqueued when its predecessors complete
+type Pipeline struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Status      PipelineStatus     `bson:"status" json:"status"`
+	Steps       []PipelineStep     `bson:"steps" json:"steps"`
+	TokenID     string             `bson:"user_id" json:"user_id"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// StepStatusFromJob maps a job status onto the equivalent pipeline step status
+func StepStatusFromJob(status JobStatus) PipelineStepStatus {
+	switch status {
+	case StatusProcessing, StatusRetrying:
+		return StepProcessing
+	case StatusCompleted:
+		return StepCompleted
+	case StatusFailed:
+		return StepFailed
+	case StatusCancelled:
+		return StepCancelled
+	default:
+		return StepQueued
+	}
+}
+
+// IsFinished reports whether the step will not change state again
+func (s PipelineStep) IsFinished() bool {
+	switch s.Status {
+	case StepCompleted, StepFailed, StepCancelled, StepSkipped:
+		return true
+	}
+	return false
+}
+
+// DeadLetter is a job message that was rejected by the consumer and parked on the dead-letter queue
+type DeadLetter struct {
+	MessageID          string                 `json:"message_id"`
+	JobID              string                 `json:"job_id,omitempty"`
+	JobType            string                 `json:"job_type,omitempty"`
+	Reason             string                 `json:"reason"`
+	Error              string                 `json:"error,omitempty"`
+	OriginalExchange   string                 `json:"original_exchange"`
+	OriginalRoutingKey string                 `json:"original_routing_key"`
+	DeadLetteredAt     *time.Time             `json:"dead_lettered_at,omitempty"`
+	Headers            map[string]interface{} `json:"headers"`
+	Body               string                 `json:"body"`
 }
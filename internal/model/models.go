@@ -73,6 +73,37 @@ type MatchTiny struct {
 type TelemetryData struct {
 	SafeZones []SafeZone `bson:"safe_zones,omitempty"` // Array of safety zones, starting with phase 1
 	PlanePath PlanePath  `bson:"plane_path,omitempty"` // Plane path coordinates
+
+	// Where each team landed, ordered by team ID. Stored even when empty, so matches processed before
+	// landings were recorded can be told apart from matches in which no landing was found.
+	Landings []TeamLanding `bson:"landings"`
+}
+
+// TeamLanding is where a roster landed, resolved to the nearest drop spot of the map
+type TeamLanding struct {
+	TeamID    int             `bson:"team_id"`   // Team ID of the roster in the telemetry
+	Placement int             `bson:"placement"` // Final placement of the team, 0 if unknown
+	X         float64         `bson:"x"`         // Average landing X coordinate of the players
+	Y         float64         `bson:"y"`         // Average landing Y coordinate of the players
+	Players   []PlayerLanding `bson:"players"`
+
+	// Drop spot most of the team's players landed at, unset when none of them landed near one
+	DropSpotID       *primitive.ObjectID `bson:"drop_spot_id,omitempty"`
+	DropSpotName     string              `bson:"drop_spot_name,omitempty"`
+	DropSpotDistance float64             `bson:"drop_spot_distance,omitempty"` // Average distance of the players that landed there
+}
+
+// PlayerLanding is where a player first landed
+type PlayerLanding struct {
+	Name      string    `bson:"name"`
+	AccountID string    `bson:"account_id"`
+	X         float64   `bson:"x"`
+	Y         float64   `bson:"y"`
+	LandedAt  time.Time `bson:"landed_at"`
+
+	// Nearest drop spot, unset when the player landed further than the drop spot radius from every spot
+	DropSpotID       *primitive.ObjectID `bson:"drop_spot_id,omitempty"`
+	DropSpotDistance float64             `bson:"drop_spot_distance,omitempty"`
 }
 
 // SafeZone represents data for a single circle phase
@@ -172,3 +203,78 @@ type DropSpotLocation struct {
 	X         int                `json:"x" bson:"x"`
 	Y         int                `json:"y" bson:"y"`
 }
+
+// KillEvent is a kill extracted from match telemetry, stored in the kill_events collection
+type KillEvent struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	MatchID   string             `json:"match_id" bson:"match_id"`
+	Timestamp time.Time          `json:"timestamp" bson:"timestamp"`
+	Phase     int                `json:"phase" bson:"phase"` // Circle phase, 0 before the first circle closes
+
+	Killer    *KillParticipant `json:"killer,omitempty" bson:"killer,omitempty"` // Nil for deaths to the zone, falls and the like
+	Victim    KillParticipant  `json:"victim" bson:"victim"`
+	KnockedBy *KillParticipant `json:"knocked_by,omitempty" bson:"knocked_by,omitempty"` // Nil if the victim wasn't knocked first
+
+	Weapon         string  `json:"weapon" bson:"weapon"`                   // Damage causer of the killing blow, e.g. WeapHK416_C
+	DamageReason   string  `json:"damage_reason" bson:"damage_reason"`     // Body part hit, e.g. HeadShot
+	DamageCategory string  `json:"damage_category" bson:"damage_category"` // e.g. Damage_Gun
+	Distance       float64 `json:"distance" bson:"distance"`               // Distance between killer and victim in centimetres
+	IsSuicide      bool    `json:"is_suicide" bson:"is_suicide"`
+	IsTeamKill     bool    `json:"is_team_kill" bson:"is_team_kill"`
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// KillParticipant is a player taking part in a kill and where they were at the time
+type KillParticipant struct {
+	Name      string   `json:"name" bson:"name"`
+	AccountID string   `json:"account_id" bson:"account_id"`
+	TeamID    int      `json:"team_id" bson:"team_id"`
+	Position  Position `json:"position" bson:"position"`
+}
+
+// DropSpotLanding is a team landing resolved to a drop spot, with the plane path of its match
+type DropSpotLanding struct {
+	MatchID   string      `bson:"match_id"`
+	PlanePath PlanePath   `bson:"plane_path"`
+	Landing   TeamLanding `bson:"landing"`
+}
+
+// DropSpotStats describes how often a drop spot was landed at and contested
+type DropSpotStats struct {
+	DropSpotID primitive.ObjectID `json:"drop_spot_id"`
+	Names      []string           `json:"names"`
+	X          int                `json:"x"`
+	Y          int                `json:"y"`
+
+	Landings         int     `json:"landings"`          // Team landings at the drop spot
+	Matches          int     `json:"matches"`           // Matches in which at least one team landed there
+	LandingRate      float64 `json:"landing_rate"`      // Share of the matches in which at least one team landed there
+	ContestedMatches int     `json:"contested_matches"` // Matches in which more than one team landed there
+	ContestRate      float64 `json:"contest_rate"`      // Share of the matches landed at that were contested
+	AverageTeams     float64 `json:"average_teams"`     // Teams landing there per match landed at
+	AveragePlacement float64 `json:"average_placement"` // Of the teams that landed there, 0 if unknown
+
+	PlanePath PlanePathDistribution `json:"plane_path"`
+}
+
+// PlanePathDistribution groups the landings at a drop spot by their distance from the plane path
+type PlanePathDistribution struct {
+	AverageDistance float64          `json:"average_distance_m"` // In metres, of the landings of matches with a plane path
+	Buckets         []DistanceBucket `json:"buckets"`
+}
+
+// DistanceBucket counts the landings between two distances from the plane path
+type DistanceBucket struct {
+	Label    string  `json:"label"`
+	MinMetre float64 `json:"min_m"`
+	MaxMetre float64 `json:"max_m,omitempty"` // 0 for the last, open ended bucket
+	Landings int     `json:"landings"`
+}
+
+// MapDropSpotStats is the drop spot usage of a map over a set of matches
+type MapDropSpotStats struct {
+	MapName   string          `json:"map_name"`
+	Matches   int             `json:"matches"` // Processed matches of the map with recorded landings matching the filter, the denominator of the landing rates
+	DropSpots []DropSpotStats `json:"drop_spots"`
+}
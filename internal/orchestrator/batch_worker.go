@@ -29,6 +29,12 @@ type BatchWorker interface {
 	// Description
 	Description() string
 
+	// PayloadSchema describes the parameters the worker reads from the job payload
+	PayloadSchema() PayloadSchema
+
+	// SupportsDryRun reports whether the worker can run a job that only projects its imports
+	SupportsDryRun() bool
+
 	//
 	ActiveJobID() *primitive.ObjectID
 }
@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"context"
+	"harvest/internal/model"
+	"sync"
+)
+
+// MatchLookup finds which matches are already stored
+type MatchLookup interface {
+	GetExistingMatchIDs(ctx context.Context, matchIDs []string) ([]string, error)
+}
+
+// MatchDryRun projects what importing discovered matches would do without fetching them.
+// Matches discovered more than once during the job are only counted the first time.
+// It is safe for concurrent use.
+type MatchDryRun struct {
+	seen map[string]struct{}
+	mu   sync.Mutex
+}
+
+// NewMatchDryRun creates an empty projection for a dry run job
+func NewMatchDryRun() *MatchDryRun {
+	return &MatchDryRun{
+		seen: make(map[string]struct{}),
+	}
+}
+
+// Project counts the matches that weren't discovered earlier in the job and how many of them are
+// already stored. A real run requests every discovered match from the API before importing it.
+func (d *MatchDryRun) Project(ctx context.Context, lookup MatchLookup, matchIDs []string, discoveryRequests int) (model.DryRunResult, error) {
+	result := model.DryRunResult{DiscoveryRequests: discoveryRequests}
+
+	d.mu.Lock()
+	discovered := make([]string, 0, len(matchIDs))
+	for _, matchID := range matchIDs {
+		if _, ok := d.seen[matchID]; ok {
+			continue
+		}
+		d.seen[matchID] = struct{}{}
+		discovered = append(discovered, matchID)
+	}
+	d.mu.Unlock()
+
+	existing, err := lookup.GetExistingMatchIDs(ctx, discovered)
+	if err != nil {
+		return result, err
+	}
+
+	result.DiscoveredMatches = len(discovered)
+	result.ExistingMatches = len(existing)
+	result.NewMatches = len(discovered) - len(existing)
+	result.MatchRequests = len(discovered)
+
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package orchestrator
+
+import (
+	"context"
+	"harvest/internal/model"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobItemWriter persists per-item job results
+type JobItemWriter interface {
+	InsertJobItems(ctx context.Context, items []model.JobItem) error
+}
+
+// ItemLog collects the outcome of each item a job processes so it can be written
+// alongside the job metrics at the end of every batch. It is safe for concurrent use.
+type ItemLog struct {
+	jobID primitive.ObjectID
+	items []model.JobItem
+	mu    sync.Mutex
+}
+
+// NewItemLog creates an item log for the given job
+func NewItemLog(jobID primitive.ObjectID) *ItemLog {
+	return &ItemLog{
+		jobID: jobID,
+		items: make([]model.JobItem, 0),
+	}
+}
+
+// Record adds the result of a single item
+func (l *ItemLog) Record(itemType, itemID string, status StatusError) {
+	l.RecordChild("", itemType, itemID, status)
+}
+
+// RecordChild adds the result of an item that was processed on behalf of a parent item,
+// so a retry knows which parent to process again
+func (l *ItemLog) RecordChild(parentID, itemType, itemID string, status StatusError) {
+	item := model.JobItem{
+		ID:        primitive.NewObjectID(),
+		JobID:     l.jobID,
+		ItemType:  itemType,
+		ItemID:    itemID,
+		ParentID:  parentID,
+		Result:    resultFromStatus(status.Status()),
+		Message:   status.Message(),
+		CreatedAt: time.Now(),
+	}
+
+	l.mu.Lock()
+	l.items = append(l.items, item)
+	l.mu.Unlock()
+}
+
+// Flush writes all recorded items and clears the log
+func (l *ItemLog) Flush(ctx context.Context, writer JobItemWriter) error {
+	l.mu.Lock()
+	items := l.items
+	l.items = make([]model.JobItem, 0)
+	l.mu.Unlock()
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	return writer.InsertJobItems(ctx, items)
+}
+
+func resultFromStatus(status string) model.JobResultType {
+	switch status {
+	case StatusSuccess:
+		return model.ResultSuccess
+	case StatusWarning:
+		return model.ResultWarning
+	case StatusSkipped:
+		return model.ResultSkipped
+	case StatusInvalid:
+		return model.ResultInvalid
+	default:
+		return model.ResultFailure
+	}
+}
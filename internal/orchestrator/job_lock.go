@@ -0,0 +1,223 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"harvest/internal/model"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// How long a lease stays valid without being renewed, so a crashed instance frees the job type
+	jobLeaseTTL = 60 * time.Second
+
+	// How often a job holding the lease renews it
+	jobLeaseRenewInterval = jobLeaseTTL / 3
+)
+
+// ErrJobTypeLeaseHeld is returned by a locked worker started while another job holds the lease on its type
+var ErrJobTypeLeaseHeld = errors.New("job type lease is held by another job")
+
+// LeasedWorker is a worker that may only run while it holds the cluster-wide lease on its job type.
+// The lease is taken before the job is taken off the queue, so a job that can't run yet doesn't
+// hold a worker slot or show as processing.
+type LeasedWorker interface {
+	BatchWorker
+
+	// TryAcquireLease takes the lease for the job without waiting and keeps renewing it until the
+	// worker finishes or ReleaseLease is called. Returns false if another job holds the lease.
+	TryAcquireLease(ctx context.Context, job *model.Job) (bool, error)
+
+	// ReleaseLease gives up the lease of a job that won't be started
+	ReleaseLease(job *model.Job)
+}
+
+// JobLeaseStore persists the leases shared by every instance
+type JobLeaseStore interface {
+	AcquireJobLease(ctx context.Context, key string, holder string, jobID primitive.ObjectID, ttl time.Duration) (*model.JobLease, bool, error)
+	RenewJobLease(ctx context.Context, key string, holder string, jobID primitive.ObjectID, ttl time.Duration) (bool, error)
+	ReleaseJobLease(ctx context.Context, key string, holder string, jobID primitive.ObjectID) error
+	SetJobLock(ctx context.Context, id primitive.ObjectID, lock *model.JobLock) error
+}
+
+// InstanceID identifies this process as a job holder. WORKER_ID should be set to a value that is
+// stable across restarts of the same replica, so it can resume its own jobs.
+func InstanceID() string {
+	if id := os.Getenv("WORKER_ID"); id != "" {
+		return id
+	}
+
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+
+	return primitive.NewObjectID().Hex()
+}
+
+// WithJobTypeLock wraps a worker factory so that only one job of the type runs at a time across
+// every instance. The returned workers implement LeasedWorker.
+func WithJobTypeLock(factory WorkerFactory, store JobLeaseStore, holder string) WorkerFactory {
+	return func() BatchWorker {
+		return &lockedWorker{
+			BatchWorker: factory(),
+			store:       store,
+			holder:      holder,
+		}
+	}
+}
+
+// lockedWorker holds the lease on its job type for as long as the wrapped worker runs
+type lockedWorker struct {
+	BatchWorker
+	store  JobLeaseStore
+	holder string
+
+	stopRenewal context.CancelFunc
+	renewed     chan struct{}
+	lost        atomic.Bool
+	mu          sync.Mutex
+}
+
+// TryAcquireLease implements orchestrator.LeasedWorker.
+func (w *lockedWorker) TryAcquireLease(ctx context.Context, job *model.Job) (bool, error) {
+	key := w.Type()
+	logger := log.With().Str("jobId", job.ID.Hex()).Str("lease", key).Logger()
+
+	lease, acquired, err := w.store.AcquireJobLease(ctx, key, w.holder, job.ID, jobLeaseTTL)
+	if err != nil {
+		return false, err
+	}
+
+	// Show which instance and job the lease is waiting on
+	if !acquired {
+		if lease != nil {
+			logger.Info().
+				Str("holder", lease.Holder).
+				Str("holderJobId", lease.JobID.Hex()).
+				Msg("Job type is running on another instance, lease not acquired")
+			w.store.SetJobLock(ctx, job.ID, lockFromLease(lease, false))
+		}
+		return false, nil
+	}
+
+	logger.Info().Str("holder", w.holder).Msg("Acquired job type lease")
+	w.store.SetJobLock(ctx, job.ID, lockFromLease(lease, true))
+
+	// The lease is renewed from the moment it is taken, the job may still wait for a worker slot
+	renewCtx, stop := context.WithCancel(context.Background())
+	renewed := make(chan struct{})
+
+	w.mu.Lock()
+	w.stopRenewal = stop
+	w.renewed = renewed
+	w.mu.Unlock()
+
+	go func() {
+		defer close(renewed)
+		w.renew(renewCtx, job, lease)
+	}()
+
+	return true, nil
+}
+
+// ReleaseLease implements orchestrator.LeasedWorker.
+func (w *lockedWorker) ReleaseLease(job *model.Job) {
+	w.mu.Lock()
+	stop, renewed := w.stopRenewal, w.renewed
+	w.stopRenewal, w.renewed = nil, nil
+	w.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+
+	// Stop renewing before releasing, so a late renewal can't record the lock again
+	stop()
+	<-renewed
+	w.release(job)
+}
+
+// StartWorker implements orchestrator.BatchWorker. The lease is normally taken by the caller with
+// TryAcquireLease, a worker started without it tries once and fails if the type is running elsewhere.
+func (w *lockedWorker) StartWorker(job *model.Job) (bool, error) {
+	w.mu.Lock()
+	held := w.stopRenewal != nil
+	w.mu.Unlock()
+
+	if !held {
+		acquired, err := w.TryAcquireLease(context.Background(), job)
+		if err != nil {
+			return false, err
+		}
+		if !acquired {
+			return false, ErrJobTypeLeaseHeld
+		}
+	}
+	defer w.ReleaseLease(job)
+
+	// Lost while the job was waiting for a worker slot
+	if w.lost.Load() {
+		return false, ErrJobTypeLeaseHeld
+	}
+
+	return w.BatchWorker.StartWorker(job)
+}
+
+// renew extends the lease until the job finishes. If the lease is lost the job is cancelled,
+// since another instance may already be running the same job type.
+func (w *lockedWorker) renew(ctx context.Context, job *model.Job, lease *model.JobLease) {
+	ticker := time.NewTicker(jobLeaseRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := w.store.RenewJobLease(ctx, lease.Key, w.holder, job.ID, jobLeaseTTL)
+		if err != nil {
+			// Try again on the next tick, the lease is still valid for a while
+			continue
+		}
+
+		if !renewed {
+			w.lost.Store(true)
+			log.Error().Str("jobId", job.ID.Hex()).Str("lease", lease.Key).Msg("Lost job type lease, cancelling job")
+			w.BatchWorker.Cancel()
+			return
+		}
+
+		lease.ExpiresAt = time.Now().Add(jobLeaseTTL)
+		w.store.SetJobLock(ctx, job.ID, lockFromLease(lease, true))
+	}
+}
+
+func (w *lockedWorker) release(job *model.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.store.ReleaseJobLease(ctx, w.Type(), w.holder, job.ID); err != nil {
+		log.Error().Err(err).Str("jobId", job.ID.Hex()).Msg("Failed to release job type lease, it expires on its own")
+	}
+
+	w.store.SetJobLock(ctx, job.ID, nil)
+}
+
+func lockFromLease(lease *model.JobLease, acquired bool) *model.JobLock {
+	return &model.JobLock{
+		Key:         lease.Key,
+		Acquired:    acquired,
+		Holder:      lease.Holder,
+		HolderJobID: lease.JobID,
+		AcquiredAt:  lease.AcquiredAt,
+		ExpiresAt:   lease.ExpiresAt,
+	}
+}
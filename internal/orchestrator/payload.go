@@ -0,0 +1,194 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PayloadFieldType is the JSON type expected for a payload field
+type PayloadFieldType string
+
+const (
+	FieldString      PayloadFieldType = "string"
+	FieldInteger     PayloadFieldType = "integer"
+	FieldBoolean     PayloadFieldType = "boolean"
+	FieldDateTime    PayloadFieldType = "datetime" // RFC3339 string
+	FieldStringArray PayloadFieldType = "string_array"
+)
+
+// PayloadField describes a single parameter a worker reads from its job payload
+type PayloadField struct {
+	Name        string           `json:"name"`
+	Type        PayloadFieldType `json:"type"`
+	Required    bool             `json:"required"`
+	Description string           `json:"description"`
+	Default     interface{}      `json:"default,omitempty"`
+	Enum        []string         `json:"enum,omitempty"`
+}
+
+// PayloadSchema lists every field a worker accepts. Fields not in the schema are rejected.
+type PayloadSchema []PayloadField
+
+// PayloadValidationError is returned when a job payload does not match the worker's schema
+type PayloadValidationError struct {
+	JobType  string
+	Problems []string
+}
+
+func (e *PayloadValidationError) Error() string {
+	return fmt.Sprintf("invalid payload for job type %v: %v", e.JobType, strings.Join(e.Problems, "; "))
+}
+
+// Validate checks a payload against the schema
+func (s PayloadSchema) Validate(jobType string, payload interface{}) error {
+	values, err := normalizePayload(payload)
+	if err != nil {
+		return &PayloadValidationError{JobType: jobType, Problems: []string{err.Error()}}
+	}
+
+	fields := make(map[string]PayloadField, len(s))
+	for _, field := range s {
+		fields[field.Name] = field
+	}
+
+	problems := make([]string, 0)
+	for name := range values {
+		if _, ok := fields[name]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown field %q", name))
+		}
+	}
+
+	for _, field := range s {
+		value, ok := values[field.Name]
+		if !ok || value == nil {
+			if field.Required {
+				problems = append(problems, fmt.Sprintf("missing required field %q", field.Name))
+			}
+			continue
+		}
+
+		if problem := field.check(value); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &PayloadValidationError{JobType: jobType, Problems: problems}
+	}
+
+	return nil
+}
+
+// check returns a description of what is wrong with the value, or an empty string if it is valid
+func (f PayloadField) check(value interface{}) string {
+	switch f.Type {
+	case FieldString:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Sprintf("field %q must be a string", f.Name)
+		}
+		if len(f.Enum) > 0 && !contains(f.Enum, str) {
+			return fmt.Sprintf("field %q must be one of %v", f.Name, strings.Join(f.Enum, ", "))
+		}
+	case FieldInteger:
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return fmt.Sprintf("field %q must be an integer", f.Name)
+		}
+	case FieldBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Sprintf("field %q must be a boolean", f.Name)
+		}
+	case FieldDateTime:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Sprintf("field %q must be an RFC3339 date time string", f.Name)
+		}
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			return fmt.Sprintf("field %q must be an RFC3339 date time string", f.Name)
+		}
+	case FieldStringArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Sprintf("field %q must be an array of strings", f.Name)
+		}
+		for _, item := range items {
+			if _, ok := item.(string); !ok {
+				return fmt.Sprintf("field %q must be an array of strings", f.Name)
+			}
+		}
+	}
+
+	return ""
+}
+
+// DecodePayload decodes a job payload into a typed struct using its json tags. Payloads read
+// back from MongoDB are BSON documents, so they are converted to JSON first.
+func DecodePayload(payload interface{}, out interface{}) error {
+	if payload == nil {
+		return nil
+	}
+
+	data, err := payloadJSON(payload)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode job payload: %w", err)
+	}
+
+	return nil
+}
+
+// normalizePayload converts any payload representation into a generic JSON object
+func normalizePayload(payload interface{}) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if payload == nil {
+		return values, nil
+	}
+
+	data, err := payloadJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("payload must be a JSON object")
+	}
+
+	return values, nil
+}
+
+func payloadJSON(payload interface{}) ([]byte, error) {
+	var data []byte
+	var err error
+
+	switch p := payload.(type) {
+	case primitive.D, primitive.M:
+		data, err = bson.MarshalExtJSON(p, false, false)
+	default:
+		data, err = json.Marshal(p)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	return data, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+package orchestrator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var testSchema = PayloadSchema{
+	{Name: "shard", Type: FieldString, Required: true, Enum: []string{"steam", "kakao"}},
+	{Name: "name", Type: FieldString},
+	{Name: "limit", Type: FieldInteger},
+	{Name: "dry", Type: FieldBoolean},
+	{Name: "since", Type: FieldDateTime},
+	{Name: "ids", Type: FieldStringArray},
+}
+
+func TestPayloadSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  interface{}
+		problems []string // Substrings of the expected problems, none for a valid payload
+	}{
+		{
+			name: "valid map",
+			payload: map[string]interface{}{
+				"shard": "steam",
+				"name":  "player",
+				"limit": 10,
+				"dry":   true,
+				"since": "2024-01-01T00:00:00Z",
+				"ids":   []string{"a", "b"},
+			},
+		},
+		{
+			name: "valid struct",
+			payload: struct {
+				Shard string `json:"shard"`
+				Limit int    `json:"limit"`
+			}{Shard: "kakao", Limit: 3},
+		},
+		{
+			name:    "valid bson document",
+			payload: bson.D{{Key: "shard", Value: "steam"}, {Key: "limit", Value: int32(5)}, {Key: "ids", Value: bson.A{"x"}}},
+		},
+		{
+			name:    "optional field set to null",
+			payload: map[string]interface{}{"shard": "steam", "name": nil},
+		},
+		{
+			name:     "nil payload is missing required fields",
+			payload:  nil,
+			problems: []string{`missing required field "shard"`},
+		},
+		{
+			name:     "required field set to null",
+			payload:  map[string]interface{}{"shard": nil},
+			problems: []string{`missing required field "shard"`},
+		},
+		{
+			name:     "unknown field",
+			payload:  map[string]interface{}{"shard": "steam", "extra": 1},
+			problems: []string{`unknown field "extra"`},
+		},
+		{
+			name:     "value not in enum",
+			payload:  map[string]interface{}{"shard": "xbox"},
+			problems: []string{`field "shard" must be one of steam, kakao`},
+		},
+		{
+			name:     "string of the wrong type",
+			payload:  map[string]interface{}{"shard": "steam", "name": 5},
+			problems: []string{`field "name" must be a string`},
+		},
+		{
+			name:     "fractional integer",
+			payload:  map[string]interface{}{"shard": "steam", "limit": 1.5},
+			problems: []string{`field "limit" must be an integer`},
+		},
+		{
+			name:     "integer as a string",
+			payload:  map[string]interface{}{"shard": "steam", "limit": "10"},
+			problems: []string{`field "limit" must be an integer`},
+		},
+		{
+			name:     "boolean as a string",
+			payload:  map[string]interface{}{"shard": "steam", "dry": "true"},
+			problems: []string{`field "dry" must be a boolean`},
+		},
+		{
+			name:     "date time not in RFC3339",
+			payload:  map[string]interface{}{"shard": "steam", "since": "2024-01-01"},
+			problems: []string{`field "since" must be an RFC3339 date time string`},
+		},
+		{
+			name:     "array with a number",
+			payload:  map[string]interface{}{"shard": "steam", "ids": []interface{}{"a", 1}},
+			problems: []string{`field "ids" must be an array of strings`},
+		},
+		{
+			name:     "array as a string",
+			payload:  map[string]interface{}{"shard": "steam", "ids": "a"},
+			problems: []string{`field "ids" must be an array of strings`},
+		},
+		{
+			name:     "every problem is reported",
+			payload:  map[string]interface{}{"limit": true, "other": "x"},
+			problems: []string{`unknown field "other"`, `missing required field "shard"`, `field "limit" must be an integer`},
+		},
+		{
+			name:     "not an object",
+			payload:  []string{"steam"},
+			problems: []string{"payload must be a JSON object"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := testSchema.Validate("test-job", tt.payload)
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("Validate returned error: %v", err)
+				}
+				return
+			}
+
+			var validationErr *PayloadValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate returned %v, want a *PayloadValidationError", err)
+			}
+			if validationErr.JobType != "test-job" {
+				t.Errorf("JobType = %q, want %q", validationErr.JobType, "test-job")
+			}
+			if len(validationErr.Problems) != len(tt.problems) {
+				t.Fatalf("Problems = %q, want %d problems", validationErr.Problems, len(tt.problems))
+			}
+			for _, want := range tt.problems {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+type testPayload struct {
+	Shard string   `json:"shard" bson:"shard"`
+	Limit int      `json:"limit" bson:"limit"`
+	Dry   bool     `json:"dry" bson:"dry"`
+	Since string   `json:"since" bson:"since"`
+	IDs   []string `json:"ids" bson:"ids"`
+}
+
+// bsonRoundTrip stores a payload the way a job is saved and returns what is read back from MongoDB
+func bsonRoundTrip(t *testing.T, payload interface{}) interface{} {
+	t.Helper()
+
+	data, err := bson.Marshal(bson.M{"payload": payload})
+	if err != nil {
+		t.Fatalf("bson.Marshal returned error: %v", err)
+	}
+
+	var stored struct {
+		Payload interface{} `bson:"payload"`
+	}
+	if err := bson.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("bson.Unmarshal returned error: %v", err)
+	}
+
+	return stored.Payload
+}
+
+func TestDecodePayload(t *testing.T) {
+	full := testPayload{Shard: "steam", Limit: 25, Dry: true, Since: "2024-01-01T00:00:00Z", IDs: []string{"a", "b"}}
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    testPayload
+		wantErr bool
+	}{
+		{name: "nil payload", payload: nil, want: testPayload{}},
+		{name: "struct", payload: full, want: full},
+		{
+			name:    "json map",
+			payload: map[string]interface{}{"shard": "steam", "limit": 25.0, "dry": true, "since": "2024-01-01T00:00:00Z", "ids": []interface{}{"a", "b"}},
+			want:    full,
+		},
+		{name: "bson document from a struct", payload: bsonRoundTrip(t, full), want: full},
+		{
+			name:    "bson document from a map",
+			payload: bsonRoundTrip(t, map[string]interface{}{"shard": "kakao", "limit": int64(3), "ids": []string{"c"}}),
+			want:    testPayload{Shard: "kakao", Limit: 3, IDs: []string{"c"}},
+		},
+		{name: "bson.M", payload: bson.M{"shard": "steam", "limit": int32(7)}, want: testPayload{Shard: "steam", Limit: 7}},
+		{name: "wrong field type", payload: map[string]interface{}{"limit": "ten"}, wantErr: true},
+		{name: "not an object", payload: "steam", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testPayload
+			err := DecodePayload(tt.payload, &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("DecodePayload succeeded with %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodePayload returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodePayload = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBSONPayload(t *testing.T) {
+	// A payload read back from MongoDB validates the same as the JSON it was created from
+	payload := bsonRoundTrip(t, map[string]interface{}{"shard": "steam", "limit": 10, "ids": []string{"a"}})
+	if err := testSchema.Validate("test-job", payload); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+
+	payload = bsonRoundTrip(t, map[string]interface{}{"shard": "steam", "limit": "10"})
+	if err := testSchema.Validate("test-job", payload); err == nil {
+		t.Fatal("Validate succeeded for a string limit, want an error")
+	}
+}
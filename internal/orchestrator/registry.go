@@ -1,58 +1,90 @@
 package orchestrator
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 
 	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// WorkerFactory creates a fresh worker instance. Each job gets its own instance so several
+// jobs of the same type can run at once.
+type WorkerFactory func() BatchWorker
+
+// ErrJobAlreadyActive is returned by Spawn for a job that already has a worker in this process
+var ErrJobAlreadyActive = errors.New("job is already active")
+
 type WorkerRegistry interface {
-	Register(string, BatchWorker)
+	Register(WorkerFactory)
+
+	// Get returns a template worker for the job type, used for its name and description
 	Get(string) (BatchWorker, bool)
 	AvailableProcessors() []string
-	CancelProcessByType(string) error
+
+	// Spawn creates a worker for a job and tracks it as active until Release is called
+	Spawn(jobType string, jobID primitive.ObjectID) (BatchWorker, error)
+	Release(jobID primitive.ObjectID)
+
+	// ActiveWorker returns the worker currently running a job
+	ActiveWorker(jobID primitive.ObjectID) (BatchWorker, bool)
+
+	CancelJob(jobID primitive.ObjectID) error
+}
+
+type registeredWorker struct {
+	factory  WorkerFactory
+	template BatchWorker
 }
 
 // Registry is a central registry for job processors
 type Registry struct {
-	processors map[string]BatchWorker
+	processors map[string]registeredWorker
+	active     map[primitive.ObjectID]BatchWorker
 	mu         sync.RWMutex
 }
 
 // NewRegistry creates a new processor registry
-func NewWorkerRegistry(processors ...BatchWorker) WorkerRegistry {
+func NewWorkerRegistry(factories ...WorkerFactory) WorkerRegistry {
 	registry := Registry{
-		processors: make(map[string]BatchWorker),
+		processors: make(map[string]registeredWorker),
+		active:     make(map[primitive.ObjectID]BatchWorker),
 	}
 
-	for _, process := range processors {
-		registry.Register(process.Type(), process)
+	for _, factory := range factories {
+		registry.Register(factory)
 	}
 
 	return &registry
 }
 
-func (r *Registry) CancelProcessByType(processType string) error {
-	process, ok := r.Get(processType)
-
+// CancelJob cancels a running job by ID
+func (r *Registry) CancelJob(jobID primitive.ObjectID) error {
+	worker, ok := r.ActiveWorker(jobID)
 	if !ok {
-		return fmt.Errorf("process not found, can't cancel")
+		return fmt.Errorf("job is not active: %v", jobID.Hex())
 	}
 
-	return process.Cancel()
+	return worker.Cancel()
 }
 
 // Register adds a processor to the registry
-func (r *Registry) Register(jobType string, processor BatchWorker) {
+func (r *Registry) Register(factory WorkerFactory) {
+	template := factory()
+	jobType := template.Type()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.processors[jobType] = processor
+	r.processors[jobType] = registeredWorker{
+		factory:  factory,
+		template: template,
+	}
 
 	log.Info().
 		Str("jobType", jobType).
-		Str("processor", processor.Name()).
+		Str("processor", template.Name()).
 		Msg("Registered job processor")
 }
 
@@ -62,7 +94,44 @@ func (r *Registry) Get(jobType string) (BatchWorker, bool) {
 	defer r.mu.RUnlock()
 
 	processor, exists := r.processors[jobType]
-	return processor, exists
+	return processor.template, exists
+}
+
+// Spawn creates a new worker for the job and marks it as active
+func (r *Registry) Spawn(jobType string, jobID primitive.ObjectID) (BatchWorker, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	processor, exists := r.processors[jobType]
+	if !exists {
+		return nil, fmt.Errorf("job type not found in registry: %v", jobType)
+	}
+
+	if _, running := r.active[jobID]; running {
+		return nil, fmt.Errorf("%w: %v", ErrJobAlreadyActive, jobID.Hex())
+	}
+
+	worker := processor.factory()
+	r.active[jobID] = worker
+
+	return worker, nil
+}
+
+// Release stops tracking the worker for a finished job
+func (r *Registry) Release(jobID primitive.ObjectID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.active, jobID)
+}
+
+// ActiveWorker returns the worker running the given job
+func (r *Registry) ActiveWorker(jobID primitive.ObjectID) (BatchWorker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	worker, exists := r.active[jobID]
+	return worker, exists
 }
 
 // AvailableProcessors returns a list of all registered processor job types
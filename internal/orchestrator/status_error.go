@@ -10,6 +10,7 @@ const (
 	StatusFailure = "failure"
 	StatusWarning = "warning"
 	StatusSkipped = "skipped"
+	StatusInvalid = "invalid"
 )
 
 type StatusError interface {
@@ -50,3 +51,7 @@ func NewWarningError(message string) StatusError {
 func NewSkippedError(message string) StatusError {
 	return &statusError{status: StatusSkipped, message: message}
 }
+
+func NewInvalidError(message string) StatusError {
+	return &statusError{status: StatusInvalid, message: message}
+}
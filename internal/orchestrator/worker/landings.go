@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"context"
+	"harvest/internal/database"
+	"harvest/internal/model"
+	"harvest/pkg/pubg"
+	"math"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ConvertTeamLandings converts the PUBG API TeamLandings to database model TeamLandings
+func ConvertTeamLandings(apiData []pubg.TeamLanding) []model.TeamLanding {
+	landings := make([]model.TeamLanding, 0, len(apiData))
+	for _, team := range apiData {
+		landing := model.TeamLanding{
+			TeamID:    team.TeamID,
+			Placement: team.Placement,
+			X:         team.Center.X,
+			Y:         team.Center.Y,
+			Players:   make([]model.PlayerLanding, 0, len(team.Players)),
+		}
+
+		for _, player := range team.Players {
+			landing.Players = append(landing.Players, model.PlayerLanding{
+				Name:      player.Name,
+				AccountID: player.AccountID,
+				X:         player.Location.X,
+				Y:         player.Location.Y,
+				LandedAt:  player.LandedAt,
+			})
+		}
+
+		landings = append(landings, landing)
+	}
+
+	return landings
+}
+
+// ResolveDropSpots resolves every player's landing to the nearest drop spot within maxDistance,
+// then sets each team's drop spot to the one most of its players landed at. A tie goes to the
+// spot the tied players landed closest to on average. Drop spots are in the same world
+// coordinates as the telemetry.
+func ResolveDropSpots(landings []model.TeamLanding, dropSpots []model.DropSpotLocation, maxDistance float64) {
+	if len(dropSpots) == 0 {
+		return
+	}
+
+	for i := range landings {
+		landing := &landings[i]
+
+		players := make(map[int]int)       // Players per drop spot index
+		distances := make(map[int]float64) // Summed player distance per drop spot index
+		for j := range landing.Players {
+			player := &landing.Players[j]
+
+			nearest, distance := nearestDropSpot(dropSpots, player.X, player.Y)
+			if distance > maxDistance {
+				continue
+			}
+
+			spotID := dropSpots[nearest].ID
+			player.DropSpotID = &spotID
+			player.DropSpotDistance = distance
+			players[nearest]++
+			distances[nearest] += distance
+		}
+
+		majority := -1
+		for spot, count := range players {
+			if majority == -1 || count > players[majority] ||
+				(count == players[majority] && distances[spot]/float64(count) < distances[majority]/float64(players[majority])) {
+				majority = spot
+			}
+		}
+
+		if majority == -1 {
+			continue
+		}
+
+		spot := dropSpots[majority]
+		landing.DropSpotID = &spot.ID
+		landing.DropSpotName = dropSpotName(spot)
+		landing.DropSpotDistance = distances[majority] / float64(players[majority])
+	}
+}
+
+// nearestDropSpot returns the index of the drop spot closest to a location and its distance
+func nearestDropSpot(dropSpots []model.DropSpotLocation, x, y float64) (int, float64) {
+	nearest, nearestDistance := -1, math.MaxFloat64
+	for i, spot := range dropSpots {
+		distance := math.Hypot(float64(spot.X)-x, float64(spot.Y)-y)
+		if distance < nearestDistance {
+			nearest, nearestDistance = i, distance
+		}
+	}
+
+	return nearest, nearestDistance
+}
+
+// dropSpotName returns the first of the names of a drop spot
+func dropSpotName(spot model.DropSpotLocation) string {
+	if len(spot.Names) == 0 {
+		return ""
+	}
+	return spot.Names[0]
+}
+
+// loadDropSpots gets the drop spots of every map in a batch of matches. A map whose drop spots
+// can't be loaded is left out, so its landings are stored without a drop spot.
+func loadDropSpots(ctx context.Context, db database.DropSpotLocationDatabase, matches []model.Match) map[string][]model.DropSpotLocation {
+	dropSpots := make(map[string][]model.DropSpotLocation)
+	for _, match := range matches {
+		if _, loaded := dropSpots[match.MapName]; loaded || match.MapName == "" {
+			continue
+		}
+
+		spots, err := db.GetDropSpotLocationByMap(ctx, match.MapName)
+		if err != nil {
+			log.Warn().Err(err).Str("mapName", match.MapName).Msg("could not load drop spots, landings won't be resolved")
+			continue
+		}
+		dropSpots[match.MapName] = spots
+	}
+
+	return dropSpots
+}
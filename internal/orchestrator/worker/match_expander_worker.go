@@ -28,6 +28,15 @@ type MatchExpanderWorker struct {
 	cancelFunc *context.CancelFunc
 	jobID      *primitive.ObjectID
 	cancelled  int32 // Using atomic for thread-safe access
+
+	// Platform shard read from the job payload
+	shard string
+
+	// Per-item results for the running job
+	items *orchestrator.ItemLog
+
+	// Set for a dry run, which projects the imports instead of making them
+	dryRun *orchestrator.MatchDryRun
 }
 
 // Cancel implements job.BatchWorker.
@@ -74,6 +83,16 @@ func (p *MatchExpanderWorker) Description() string {
 	return MATCH_EXPANDER_DESCRIPTION
 }
 
+// PayloadSchema implements orchestrator.BatchWorker.
+func (p *MatchExpanderWorker) PayloadSchema() orchestrator.PayloadSchema {
+	return orchestrator.PayloadSchema{shardField, maxPlayersField}
+}
+
+// SupportsDryRun implements orchestrator.BatchWorker.
+func (p *MatchExpanderWorker) SupportsDryRun() bool {
+	return true
+}
+
 // isCancelled returns true if the worker has been cancelled
 func (p *MatchExpanderWorker) isCancelled() bool {
 	return atomic.LoadInt32(&p.cancelled) == 1 || p.ctx == nil
@@ -111,25 +130,44 @@ func (p *MatchExpanderWorker) StartWorker(job *model.Job) (bool, error) {
 		}
 	}()
 
-	playerEntities, err := p.db.GetActivePlayers(context.TODO(), -1)
+	params, err := decodePlayerWalkPayload(job.Payload)
 	if err != nil {
-		log.Error().Err(err).Msg("Error getting active jobs in order to start worker")
 		return false, err
 	}
-	playerIDs := make([]string, 0, len(playerEntities))
-	for _, player := range playerEntities {
-		playerIDs = append(playerIDs, player.ID)
+	p.shard = params.Shard
+	p.items = orchestrator.NewItemLog(job.ID)
+
+	p.dryRun = nil
+	if job.DryRun {
+		p.dryRun = orchestrator.NewMatchDryRun()
+	}
+
+	playerIDs, retryMatchIDs, err := p.jobInputs(job, params.MaxPlayers)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting active jobs in order to start worker")
+		return false, err
 	}
 
 	log.Info().Int("Batch Size", job.BatchSize).Msg("Starting job with batch size")
 	initialBatches := orchestrator.SplitIntoBatches(playerIDs, 10)
 
+	// Matches that failed in the original job of a retry run as one extra batch at the end
+	totalBatches := len(initialBatches)
+	if len(retryMatchIDs) > 0 {
+		totalBatches++
+	}
+
 	safeCtx := p.SafeContext()
-	if err := p.db.SetJobTotalBatches(safeCtx, job.ID, len(initialBatches)); err != nil {
+	if err := p.db.SetJobTotalBatches(safeCtx, job.ID, totalBatches); err != nil {
 		log.Error().Err(err).Msg("Error setting job total batches")
 	}
 
-	for _, batch := range initialBatches {
+	for i, batch := range initialBatches {
+		// Skip batches completed before a restart
+		if i < job.Checkpoint.CompletedBatches {
+			continue
+		}
+
 		if p.isCancelled() {
 			log.Warn().Msg("Worker has been cancelled, stopping process")
 			return true, nil
@@ -140,27 +178,112 @@ func (p *MatchExpanderWorker) StartWorker(job *model.Job) (bool, error) {
 			// Continue with other batches
 		}
 
-		safeCtx = p.SafeContext()
-		if err := p.db.IncrementJobBatchesComplete(safeCtx, job.ID, 1); err != nil {
-			log.Error().Err(err).Msg("Error incrementing batches completed")
+		p.completeBatch(job.ID, i+1)
+	}
+
+	if len(retryMatchIDs) > 0 && job.Checkpoint.CompletedBatches < totalBatches {
+		if p.isCancelled() {
+			log.Warn().Msg("Worker has been cancelled, stopping process")
+			return true, nil
+		}
+
+		if p.dryRun != nil {
+			err = p.projectMatches(retryMatchIDs, job.ID, 0)
+		} else {
+			err = p.processMatchIDs(retryMatchIDs, job.ID)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Error processing retried matches")
 		}
+
+		p.completeBatch(job.ID, totalBatches)
 	}
 
 	return false, nil
 }
 
+// jobInputs returns the players to walk and, for a retry, the failed matches to process again
+func (p *MatchExpanderWorker) jobInputs(job *model.Job, maxPlayers int) ([]string, []string, error) {
+	if job.IsItemRetry() {
+		return job.RetryItemIDs(model.ItemPlayer), job.RetryItemIDs(model.ItemMatch), nil
+	}
+
+	playerEntities, err := p.db.GetActivePlayers(context.TODO(), maxPlayers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	playerIDs := make([]string, 0, len(playerEntities))
+	for _, player := range playerEntities {
+		playerIDs = append(playerIDs, player.ID)
+	}
+
+	return playerIDs, nil, nil
+}
+
+// completeBatch records a finished batch, its item results and the checkpoint
+func (p *MatchExpanderWorker) completeBatch(jobID primitive.ObjectID, completedBatches int) {
+	safeCtx := p.SafeContext()
+	if err := p.db.IncrementJobBatchesComplete(safeCtx, jobID, 1); err != nil {
+		log.Error().Err(err).Msg("Error incrementing batches completed")
+	}
+
+	if err := p.items.Flush(safeCtx, p.db); err != nil {
+		log.Error().Err(err).Msg("Error saving job item results")
+	}
+
+	if err := p.db.SaveJobCheckpoint(safeCtx, jobID, completedBatches); err != nil {
+		log.Error().Err(err).Msg("Error saving job checkpoint")
+	}
+}
+
 func (p *MatchExpanderWorker) ProcessBatch(batch []string, jobID primitive.ObjectID) error {
 	if p.isCancelled() {
 		return fmt.Errorf("worker cancelled")
 	}
 
-	matchIDs, err := p.pubgClient.GetMatchIDsForPlayers(pubg.SteamPlatform, batch)
+	matchIDs, err := p.pubgClient.GetMatchIDsForPlayers(p.shard, batch)
 	if err != nil {
 		log.Error().Err(err).Msg("Error getting match IDs for players")
+		for _, playerID := range batch {
+			p.items.Record(model.ItemPlayer, playerID, orchestrator.NewFailureError(err))
+		}
 		return err
 	}
 	log.Info().Int("# Matches", len(matchIDs)).Msg("Found matches")
 
+	if p.dryRun != nil {
+		// The players are looked up 10 at a time
+		return p.projectMatches(matchIDs, jobID, (len(batch)+9)/10)
+	}
+
+	return p.processMatchIDs(matchIDs, jobID)
+}
+
+// projectMatches records what importing the matches would do instead of importing them
+func (p *MatchExpanderWorker) projectMatches(matchIDs []string, jobID primitive.ObjectID, discoveryRequests int) error {
+	safeCtx := p.SafeContext()
+
+	result, err := p.dryRun.Project(safeCtx, p.db, matchIDs, discoveryRequests)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not look up existing matches")
+		return err
+	}
+
+	if err := p.db.IncrementJobDryRunResult(safeCtx, jobID, result); err != nil {
+		log.Error().Err(err).Msg("Error updating job dry run result")
+	}
+
+	metrics := model.JobMetrics{ProcessedItems: result.DiscoveredMatches}
+	if err := p.db.UpdateJobMetrics(safeCtx, jobID, metrics); err != nil {
+		log.Error().Err(err).Msg("Error updating job metrics")
+	}
+
+	return nil
+}
+
+// processMatchIDs processes the matches concurrently in small batches
+func (p *MatchExpanderWorker) processMatchIDs(matchIDs []string, jobID primitive.ObjectID) error {
 	matchIDBatches := orchestrator.SplitIntoBatches(matchIDs, 40)
 
 	for _, matchIDBatch := range matchIDBatches {
@@ -198,9 +321,17 @@ func (p *MatchExpanderWorker) ProcessBatch(batch []string, jobID primitive.Objec
 					return
 				}
 
-				matchDocument, valid, err := p.BuildMatchDocument(id, pubg.SteamPlatform)
+				matchDocument, valid, err := p.BuildMatchDocument(id, p.shard)
+				if pubg.IsNotFound(err) {
+					p.items.Record(model.ItemMatch, id, orchestrator.NewInvalidError("match not found in the PUBG API"))
+					mutex.Lock()
+					metrics.InvalidCount++
+					mutex.Unlock()
+					return
+				}
 				if err != nil {
 					log.Error().Err(err).Str("Match ID", id).Msg("Could not process match ID")
+					p.items.Record(model.ItemMatch, id, orchestrator.NewFailureError(err))
 					mutex.Lock()
 					metrics.FailureCount++
 					mutex.Unlock()
@@ -208,6 +339,7 @@ func (p *MatchExpanderWorker) ProcessBatch(batch []string, jobID primitive.Objec
 				}
 
 				if !valid {
+					p.items.Record(model.ItemMatch, id, orchestrator.NewInvalidError("match is not a valid match for shard "+p.shard))
 					mutex.Lock()
 					metrics.InvalidCount++
 					log.Warn().Msg("Skipping invalid match")
@@ -235,10 +367,16 @@ func (p *MatchExpanderWorker) ProcessBatch(batch []string, jobID primitive.Objec
 			bulkResult, err := p.db.BulkImportMatches(safeCtx, matchDocumentSlice)
 			if err != nil {
 				log.Error().Err(err).Msg("Could not bulk upsert matches")
+				for _, match := range matchDocumentSlice {
+					p.items.Record(model.ItemMatch, match.MatchID, orchestrator.NewFailureError(err))
+				}
 				// Continue with metrics update
 			} else {
 				metrics.SuccessCount += bulkResult.SuccessCount
 				metrics.WarningCount += bulkResult.DuplicateCount
+				for _, match := range matchDocumentSlice {
+					p.items.Record(model.ItemMatch, match.MatchID, orchestrator.NewSuccessError("match imported"))
+				}
 			}
 		}
 
@@ -269,7 +407,7 @@ func (p *MatchExpanderWorker) BuildMatchDocument(matchID, shard string) (*model.
 		return nil, true, fmt.Errorf("could not get match: %w", err)
 	}
 
-	if !match.IsValidMatch(pubg.SteamPlatform) {
+	if !match.IsValidMatch(shard) {
 		return nil, false, nil
 	}
 
@@ -307,7 +445,7 @@ func (p *MatchExpanderWorker) BuildMatchDocument(matchID, shard string) (*model.
 		Duration:      match.Data.Attributes.Duration,
 		IsCustomMatch: match.Data.Attributes.IsCustomMatch,
 		CreatedAt:     createdAt,
-		MatchType:     match.GetMatchType(pubg.SteamPlatform),
+		MatchType:     match.GetMatchType(shard),
 
 		// Processing metadata
 		Processed:  false,
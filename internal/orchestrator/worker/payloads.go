@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"harvest/internal/orchestrator"
+	"harvest/pkg/pubg"
+)
+
+// Platform shards the expander workers can pull matches from
+var pubgShards = []string{pubg.SteamPlatform, "kakao", "console", "psn", "xbox"}
+
+// shardField is the shared schema entry for workers that accept a PUBG platform shard
+var shardField = orchestrator.PayloadField{
+	Name:        "shard",
+	Type:        orchestrator.FieldString,
+	Description: "PUBG platform shard to query",
+	Default:     pubg.SteamPlatform,
+	Enum:        pubgShards,
+}
+
+// maxPlayersField is the shared schema entry for workers that walk the stored players
+var maxPlayersField = orchestrator.PayloadField{
+	Name:        "max_players",
+	Type:        orchestrator.FieldInteger,
+	Description: "Maximum number of active players to walk, all players when omitted",
+}
+
+// PlayerWalkPayload is the job payload accepted by the player and match expander workers
+type PlayerWalkPayload struct {
+	Shard      string `json:"shard"`
+	MaxPlayers int    `json:"max_players"`
+}
+
+// decodePlayerWalkPayload reads the payload and fills in defaults
+func decodePlayerWalkPayload(payload interface{}) (PlayerWalkPayload, error) {
+	params := PlayerWalkPayload{}
+	if err := orchestrator.DecodePayload(payload, &params); err != nil {
+		return params, err
+	}
+
+	if params.Shard == "" {
+		params.Shard = pubg.SteamPlatform
+	}
+
+	if params.MaxPlayers <= 0 {
+		params.MaxPlayers = -1
+	}
+
+	return params, nil
+}
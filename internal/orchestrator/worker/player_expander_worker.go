@@ -28,6 +28,12 @@ type PlayerExpanderWorker struct {
 	cancelFunc *context.CancelFunc
 	jobID      *primitive.ObjectID
 	cancelled  int32 // Using atomic for thread-safe access
+
+	// Platform shard read from the job payload
+	shard string
+
+	// Per-item results for the running job
+	items *orchestrator.ItemLog
 }
 
 // Cancel implements job.BatchWorker.
@@ -77,6 +83,16 @@ func (p *PlayerExpanderWorker) Description() string {
 	return PLAYER_EXPANDER_DESCRIPTION
 }
 
+// PayloadSchema implements orchestrator.BatchWorker.
+func (p *PlayerExpanderWorker) PayloadSchema() orchestrator.PayloadSchema {
+	return orchestrator.PayloadSchema{shardField, maxPlayersField}
+}
+
+// SupportsDryRun implements orchestrator.BatchWorker.
+func (p *PlayerExpanderWorker) SupportsDryRun() bool {
+	return false
+}
+
 // isCancelled returns true if the worker has been cancelled
 func (p *PlayerExpanderWorker) isCancelled() bool {
 	return atomic.LoadInt32(&p.cancelled) == 1 || p.ctx == nil
@@ -112,26 +128,39 @@ func (p *PlayerExpanderWorker) StartWorker(job *model.Job) (bool, error) {
 		}
 	}()
 
-	playerEntities, err := p.db.GetActivePlayers(context.TODO(), -1)
+	params, err := decodePlayerWalkPayload(job.Payload)
 	if err != nil {
-		log.Error().Err(err).Msg("Error getting active players in order to start worker")
-		return false, fmt.Errorf("failed to get active players: %w", err)
+		return false, err
 	}
+	p.shard = params.Shard
+	p.items = orchestrator.NewItemLog(job.ID)
 
-	playerIDs := make([]string, 0, len(playerEntities))
-	for _, player := range playerEntities {
-		playerIDs = append(playerIDs, player.ID)
+	playerIDs, retryMatchIDs, err := p.jobInputs(job, params.MaxPlayers)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting active players in order to start worker")
+		return false, fmt.Errorf("failed to get active players: %w", err)
 	}
 
 	log.Info().Int("Batch Size", job.BatchSize).Msg("Starting job with batch size")
 	initialBatches := orchestrator.SplitIntoBatches(playerIDs, 10)
 
+	// Matches that failed in the original job of a retry run as one extra batch at the end
+	totalBatches := len(initialBatches)
+	if len(retryMatchIDs) > 0 {
+		totalBatches++
+	}
+
 	safeCtx := p.SafeContext()
-	if err := p.db.SetJobTotalBatches(safeCtx, job.ID, len(initialBatches)); err != nil {
+	if err := p.db.SetJobTotalBatches(safeCtx, job.ID, totalBatches); err != nil {
 		log.Error().Err(err).Msg("Error setting job total batches")
 	}
 
-	for _, batch := range initialBatches {
+	for i, batch := range initialBatches {
+		// Skip batches completed before a restart
+		if i < job.Checkpoint.CompletedBatches {
+			continue
+		}
+
 		if p.isCancelled() {
 			log.Warn().Msg("Worker has been cancelled, stopping process")
 			return true, nil
@@ -147,10 +176,20 @@ func (p *PlayerExpanderWorker) StartWorker(job *model.Job) (bool, error) {
 			return true, nil
 		}
 
-		safeCtx = p.SafeContext()
-		if err := p.db.IncrementJobBatchesComplete(safeCtx, job.ID, 1); err != nil {
-			log.Error().Err(err).Msg("Error incrementing batches completed")
+		p.completeBatch(job.ID, i+1)
+	}
+
+	if len(retryMatchIDs) > 0 && job.Checkpoint.CompletedBatches < totalBatches {
+		if p.isCancelled() {
+			log.Warn().Msg("Worker has been cancelled, stopping process")
+			return true, nil
+		}
+
+		if err := p.processMatchIDs(retryMatchIDs, job.ID); err != nil {
+			log.Error().Err(err).Msg("Error processing retried matches")
 		}
+
+		p.completeBatch(job.ID, totalBatches)
 	}
 
 	// Clean up values that are not longer used
@@ -159,18 +198,61 @@ func (p *PlayerExpanderWorker) StartWorker(job *model.Job) (bool, error) {
 	return false, nil
 }
 
+// jobInputs returns the players to walk and, for a retry, the failed matches to process again
+func (p *PlayerExpanderWorker) jobInputs(job *model.Job, maxPlayers int) ([]string, []string, error) {
+	if job.IsItemRetry() {
+		return job.RetryItemIDs(model.ItemPlayer), job.RetryItemIDs(model.ItemMatch), nil
+	}
+
+	playerEntities, err := p.db.GetActivePlayers(context.TODO(), maxPlayers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	playerIDs := make([]string, 0, len(playerEntities))
+	for _, player := range playerEntities {
+		playerIDs = append(playerIDs, player.ID)
+	}
+
+	return playerIDs, nil, nil
+}
+
+// completeBatch records a finished batch, its item results and the checkpoint
+func (p *PlayerExpanderWorker) completeBatch(jobID primitive.ObjectID, completedBatches int) {
+	safeCtx := p.SafeContext()
+	if err := p.db.IncrementJobBatchesComplete(safeCtx, jobID, 1); err != nil {
+		log.Error().Err(err).Msg("Error incrementing batches completed")
+	}
+
+	if err := p.items.Flush(safeCtx, p.db); err != nil {
+		log.Error().Err(err).Msg("Error saving job item results")
+	}
+
+	if err := p.db.SaveJobCheckpoint(safeCtx, jobID, completedBatches); err != nil {
+		log.Error().Err(err).Msg("Error saving job checkpoint")
+	}
+}
+
 func (p *PlayerExpanderWorker) ProcessBatch(batch []string, jobID primitive.ObjectID) error {
 	if p.isCancelled() {
 		return fmt.Errorf("worker cancelled")
 	}
 
-	matchIDs, err := p.pubgClient.GetMatchIDsForPlayers(pubg.SteamPlatform, batch)
+	matchIDs, err := p.pubgClient.GetMatchIDsForPlayers(p.shard, batch)
 	if err != nil {
 		log.Error().Err(err).Msg("Error getting match IDs for players")
+		for _, playerID := range batch {
+			p.items.Record(model.ItemPlayer, playerID, orchestrator.NewFailureError(err))
+		}
 		return fmt.Errorf("failed to get match IDs: %w", err)
 	}
 	log.Info().Int("# Matches", len(matchIDs)).Msg("Found matches")
 
+	return p.processMatchIDs(matchIDs, jobID)
+}
+
+// processMatchIDs processes the matches concurrently in small batches
+func (p *PlayerExpanderWorker) processMatchIDs(matchIDs []string, jobID primitive.ObjectID) error {
 	matchIDBatches := orchestrator.SplitIntoBatches(matchIDs, 20)
 
 	for _, matchIDBatch := range matchIDBatches {
@@ -206,14 +288,24 @@ func (p *PlayerExpanderWorker) ProcessBatch(batch []string, jobID primitive.Obje
 				}
 
 				matchMetrics, err := p.ProcessMatchID(id)
+				if pubg.IsNotFound(err) {
+					p.items.Record(model.ItemMatch, id, orchestrator.NewInvalidError("match not found in the PUBG API"))
+					mutex.Lock()
+					metrics.InvalidCount++
+					mutex.Unlock()
+					return
+				}
 				if err != nil {
 					log.Error().Err(err).Str("Match ID", id).Msg("Could not process match ID")
+					p.items.Record(model.ItemMatch, id, orchestrator.NewFailureError(err))
 					mutex.Lock()
 					metrics.FailureCount++
 					mutex.Unlock()
 					return
 				}
 
+				p.items.Record(model.ItemMatch, id, orchestrator.NewSuccessError("players expanded from match"))
+
 				// Only lock if we have metrics to update
 				if matchMetrics != nil {
 					// Lock before updating shared metrics
@@ -253,7 +345,7 @@ func (p *PlayerExpanderWorker) ProcessMatchID(matchID string) (*model.JobMetrics
 	}
 
 	metrics := model.JobMetrics{}
-	match, err := p.pubgClient.GetMatch(pubg.SteamPlatform, matchID)
+	match, err := p.pubgClient.GetMatch(p.shard, matchID)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Error getting match")
@@ -8,6 +8,7 @@ import (
 	"harvest/pkg/pubg"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,17 +17,33 @@ import (
 const (
 	PROCESS_MATCHES_TYPE        = "process_matches_worker"
 	PROCESS_MATCHES_NAME        = "Process Matches Worker"
-	PROCESS_MATCHES_DESCRIPTION = "Process each match stored and extract zone / plane path information"
+	PROCESS_MATCHES_DESCRIPTION = "Process each match stored and extract zone / plane path information, kills and team landings"
+
+	// Matches shorter than this many seconds are skipped unless the payload overrides it
+	defaultMinMatchDuration = 600
 )
 
+// ProcessMatchesPayload is the job payload accepted by the process matches worker
+type ProcessMatchesPayload struct {
+	MinDuration *int       `json:"min_duration"`
+	StartDate   *time.Time `json:"start_date"`
+	EndDate     *time.Time `json:"end_date"`
+}
+
 type processMatchesWorker struct {
 	pubgClient *pubg.Client
 	db         database.Database
 
+	// Largest distance from a drop spot at which a player counts as landing there
+	dropSpotRadius float64
+
 	ctx        *context.Context
 	cancelFunc *context.CancelFunc
 	jobID      *primitive.ObjectID
 	cancelled  int32 // Using atomic for thread-safe access
+
+	// Per-item results for the running job
+	items *orchestrator.ItemLog
 }
 
 // Cancel implements orchestrator.BatchWorker.
@@ -52,6 +69,33 @@ func (p *processMatchesWorker) Description() string {
 	return PROCESS_MATCHES_DESCRIPTION
 }
 
+// PayloadSchema implements orchestrator.BatchWorker.
+func (p *processMatchesWorker) PayloadSchema() orchestrator.PayloadSchema {
+	return orchestrator.PayloadSchema{
+		{
+			Name:        "min_duration",
+			Type:        orchestrator.FieldInteger,
+			Description: "Only process matches longer than this many seconds",
+			Default:     defaultMinMatchDuration,
+		},
+		{
+			Name:        "start_date",
+			Type:        orchestrator.FieldDateTime,
+			Description: "Only process matches played at or after this time",
+		},
+		{
+			Name:        "end_date",
+			Type:        orchestrator.FieldDateTime,
+			Description: "Only process matches played at or before this time",
+		},
+	}
+}
+
+// SupportsDryRun implements orchestrator.BatchWorker.
+func (p *processMatchesWorker) SupportsDryRun() bool {
+	return false
+}
+
 // IsActive implements job.BatchWorker.
 func (p *processMatchesWorker) IsActive() bool {
 	return atomic.LoadInt32(&p.cancelled) == 0 && p.ctx != nil
@@ -87,17 +131,40 @@ func (p *processMatchesWorker) StartWorker(job *model.Job) (bool, error) {
 		}
 	}()
 
+	params := ProcessMatchesPayload{}
+	if err := orchestrator.DecodePayload(job.Payload, &params); err != nil {
+		return false, err
+	}
+
+	p.items = orchestrator.NewItemLog(job.ID)
+
+	minDuration := defaultMinMatchDuration
+	if params.MinDuration != nil {
+		minDuration = *params.MinDuration
+	}
+
 	// Take all tournament IDs and parse through them to build matches
 	safeCtx := p.SafeContext()
-	matches, err := p.db.GetUnProcessedMatches(safeCtx, 600)
+	var matches []model.Match
+	var err error
+	if job.IsItemRetry() {
+		// Only the matches that failed in the original job are processed again
+		matches, err = p.db.GetUnProcessedMatchesByIDs(safeCtx, job.RetryItemIDs(model.ItemMatch))
+	} else {
+		matches, err = p.db.GetUnProcessedMatches(safeCtx, minDuration, params.StartDate, params.EndDate)
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("unable to get un-processed matches")
 		return false, err
 	}
 	matchBatches := orchestrator.SplitIntoBatches(matches, 100)
-	p.db.SetJobTotalBatches(p.SafeContext(), job.ID, len(matchBatches))
 
-	for _, batch := range matchBatches {
+	// Processed matches drop out of the query above, so on resume the remaining batches are
+	// exactly the work left to do and are counted on top of the checkpoint
+	completed := job.Checkpoint.CompletedBatches
+	p.db.SetJobTotalBatches(p.SafeContext(), job.ID, completed+len(matchBatches))
+
+	for i, batch := range matchBatches {
 		if p.isCancelled() {
 			return true, nil
 		}
@@ -113,6 +180,14 @@ func (p *processMatchesWorker) StartWorker(job *model.Job) (bool, error) {
 		if err != nil {
 			return false, err
 		}
+
+		if err := p.items.Flush(p.SafeContext(), p.db); err != nil {
+			log.Error().Err(err).Msg("could not save job item results")
+		}
+
+		if err := p.db.SaveJobCheckpoint(p.SafeContext(), job.ID, completed+i+1); err != nil {
+			log.Error().Err(err).Msg("could not save job checkpoint")
+		}
 	}
 
 	return false, nil
@@ -131,13 +206,16 @@ func (p *processMatchesWorker) ProcessMatchBatch(batch []model.Match) model.JobM
 
 	updateMap := make(map[string]*model.TelemetryData, len(batch))
 
+	// Landings are resolved to the drop spots of the match's map
+	dropSpots := loadDropSpots(p.SafeContext(), p.db, batch)
+
 	for _, match := range batch {
 		wg.Add(1)
 		if p.isCancelled() {
 			return metrics
 		}
 
-		go func(telemetryURL, matchID string) {
+		go func(telemetryURL, matchID, mapName string) {
 			defer wg.Done()
 
 			if p.isCancelled() {
@@ -146,16 +224,30 @@ func (p *processMatchesWorker) ProcessMatchBatch(batch []model.Match) model.JobM
 
 			if telemetryURL == "" {
 				log.Warn().Str("matchID", matchID).Msg("empty telemetry URL, skipping match")
+				p.items.Record(model.ItemMatch, matchID, orchestrator.NewWarningError("empty telemetry URL"))
 				mutex.Lock()
 				metrics.WarningCount++
 				mutex.Unlock()
 				return
 			}
 
-			telemData, err := p.pubgClient.ProcessTelemetryFromURL(p.SafeContext(), telemetryURL)
+			// One pass over the telemetry feeds the zones, the kills and the landings
+			stream := pubg.NewTelemetryStream()
+			telemData := pubg.CollectTelemetryData(stream)
+			combat := pubg.CollectCombatEvents(stream)
+			landings := pubg.CollectTeamLandings(stream)
+			_, err := p.pubgClient.StreamTelemetry(p.SafeContext(), telemetryURL, false, stream)
 
+			if pubg.IsNotFound(err) {
+				p.items.Record(model.ItemMatch, matchID, orchestrator.NewInvalidError("telemetry not found"))
+				mutex.Lock()
+				metrics.InvalidCount++
+				mutex.Unlock()
+				return
+			}
 			if err != nil {
 				log.Error().Err(err).Str("Telemetry URL", telemetryURL).Msg("could not process telemetry URL")
+				p.items.Record(model.ItemMatch, matchID, orchestrator.NewFailureError(err))
 				mutex.Lock()
 				metrics.FailureCount++
 				mutex.Unlock()
@@ -166,16 +258,30 @@ func (p *processMatchesWorker) ProcessMatchBatch(batch []model.Match) model.JobM
 			convertedData := ConvertTelemetryData(telemData)
 			if convertedData == nil {
 				log.Warn().Str("matchID", matchID).Msg("no valid telemetry data could be converted")
+				p.items.Record(model.ItemMatch, matchID, orchestrator.NewWarningError("no valid telemetry data could be converted"))
 				mutex.Lock()
 				metrics.WarningCount++
 				mutex.Unlock()
 				return // Add this to exit early
 			}
 
+			convertedData.Landings = ConvertTeamLandings(landings.Teams())
+			ResolveDropSpots(convertedData.Landings, dropSpots[mapName], p.dropSpotRadius)
+
+			// Store the kills before the match is marked as processed, so a failure is processed again
+			if err := p.db.ReplaceMatchKillEvents(p.SafeContext(), matchID, ConvertKillEvents(matchID, combat)); err != nil {
+				log.Error().Err(err).Str("matchID", matchID).Msg("could not save kill events")
+				p.items.Record(model.ItemMatch, matchID, orchestrator.NewFailureError(err))
+				mutex.Lock()
+				metrics.FailureCount++
+				mutex.Unlock()
+				return
+			}
+
 			mutex.Lock()
 			updateMap[matchID] = convertedData
 			mutex.Unlock()
-		}(match.TelemetryURL, match.MatchID)
+		}(match.TelemetryURL, match.MatchID, match.MapName)
 	}
 	wg.Wait()
 
@@ -185,6 +291,14 @@ func (p *processMatchesWorker) ProcessMatchBatch(batch []model.Match) model.JobM
 		metrics.FailureCount++
 	}
 
+	for matchID := range updateMap {
+		if err != nil {
+			p.items.Record(model.ItemMatch, matchID, orchestrator.NewFailureError(err))
+		} else {
+			p.items.Record(model.ItemMatch, matchID, orchestrator.NewSuccessError("telemetry processed"))
+		}
+	}
+
 	metrics.SuccessCount += successCnt
 
 	return metrics
@@ -221,6 +335,63 @@ func ConvertTelemetryData(apiData *pubg.TelemetryData) *model.TelemetryData {
 	return dbData
 }
 
+// ConvertKillEvents converts the kills of the PUBG API CombatData to database model KillEvents
+func ConvertKillEvents(matchID string, apiData *pubg.CombatData) []model.KillEvent {
+	if apiData == nil {
+		return nil
+	}
+
+	now := time.Now()
+	kills := make([]model.KillEvent, 0, len(apiData.Kills))
+	for _, kill := range apiData.Kills {
+		killEvent := model.KillEvent{
+			MatchID:        matchID,
+			Timestamp:      kill.Timestamp,
+			Phase:          kill.Common.Phase(),
+			Killer:         convertKillParticipant(kill.Killer),
+			Victim:         convertFighter(kill.Victim),
+			KnockedBy:      convertKillParticipant(kill.DBNOMaker),
+			Weapon:         kill.KillerDamageInfo.DamageCauserName,
+			DamageReason:   kill.KillerDamageInfo.DamageReason,
+			DamageCategory: kill.KillerDamageInfo.DamageTypeCategory,
+			Distance:       kill.KillerDamageInfo.Distance,
+			IsSuicide:      kill.IsSuicide,
+			CreatedAt:      now,
+		}
+
+		// Killing a teammate, not yourself
+		if killEvent.Killer != nil && !kill.IsSuicide {
+			killEvent.IsTeamKill = killEvent.Killer.TeamID == killEvent.Victim.TeamID
+		}
+
+		kills = append(kills, killEvent)
+	}
+
+	return kills
+}
+
+// convertKillParticipant returns nil for a missing player, e.g. the killer of a zone death
+func convertKillParticipant(fighter *pubg.Fighter) *model.KillParticipant {
+	if fighter == nil || fighter.AccountID == "" && fighter.Name == "" {
+		return nil
+	}
+
+	participant := convertFighter(*fighter)
+	return &participant
+}
+
+func convertFighter(fighter pubg.Fighter) model.KillParticipant {
+	return model.KillParticipant{
+		Name:      fighter.Name,
+		AccountID: fighter.AccountID,
+		TeamID:    fighter.TeamID,
+		Position: model.Position{
+			X: int(fighter.Location.X),
+			Y: int(fighter.Location.Y),
+		},
+	}
+}
+
 // Type implements orchestrator.BatchWorker.
 func (p *processMatchesWorker) Type() string {
 	return PROCESS_MATCHES_TYPE
@@ -241,10 +412,11 @@ func (p *processMatchesWorker) SafeContext() context.Context {
 	return *p.ctx
 }
 
-func NewProcessMatchWorker(pubgClient *pubg.Client, db database.Database) orchestrator.BatchWorker {
+func NewProcessMatchWorker(pubgClient *pubg.Client, db database.Database, dropSpotRadius float64) orchestrator.BatchWorker {
 	return &processMatchesWorker{
-		pubgClient: pubgClient,
-		db:         db,
+		pubgClient:     pubgClient,
+		db:             db,
+		dropSpotRadius: dropSpotRadius,
 
 		ctx:        nil,
 		cancelFunc: nil,
@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"harvest/internal/config"
+	"harvest/internal/database"
+	"harvest/internal/orchestrator"
+	"harvest/pkg/pubg"
+)
+
+// NewRegistry creates a worker registry with every job type registered. Each job gets a fresh
+// worker from its factory. Unless a job type is configured as concurrent, its workers hold a
+// cluster-wide lease so only one job of the type runs at a time across all instances.
+func NewRegistry(pubgClient *pubg.Client, db database.Database, jobsConfig config.JobsConfig) orchestrator.WorkerRegistry {
+	dropSpotRadius := jobsConfig.DropSpotRadius()
+
+	factories := []orchestrator.WorkerFactory{
+		func() orchestrator.BatchWorker { return NewPlayerExpanderWorker(pubgClient, db) },
+		func() orchestrator.BatchWorker { return NewMatchExpanderWorker(pubgClient, db) },
+		func() orchestrator.BatchWorker { return NewTournamentExpanderWorker(pubgClient, db) },
+		func() orchestrator.BatchWorker { return NewTournamentMatchExpanderWorker(pubgClient, db) },
+		func() orchestrator.BatchWorker { return NewProcessMatchWorker(pubgClient, db, dropSpotRadius) },
+		func() orchestrator.BatchWorker { return NewRotationWorker(pubgClient, db) },
+	}
+
+	holder := orchestrator.InstanceID()
+	registry := orchestrator.NewWorkerRegistry()
+
+	for _, factory := range factories {
+		if !jobsConfig.AllowsConcurrent(factory().Type()) {
+			factory = orchestrator.WithJobTypeLock(factory, db, holder)
+		}
+		registry.Register(factory)
+	}
+
+	return registry
+}
@@ -7,6 +7,7 @@ import (
 	"harvest/internal/model"
 	"harvest/internal/orchestrator"
 	"harvest/pkg/pubg"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,6 +22,11 @@ const (
 	ROTATION_DESCRIPTION = "Search through teams and pull live server rotations"
 )
 
+// RotationPayload is the job payload accepted by the rotation worker
+type RotationPayload struct {
+	TeamIDs []string `json:"team_ids"`
+}
+
 type rotationWorker struct {
 	pubgClient *pubg.Client
 	db         database.Database
@@ -28,6 +34,9 @@ type rotationWorker struct {
 	cancelFunc *context.CancelFunc
 	jobID      *primitive.ObjectID
 	cancelled  int32 // Using atomic for thread-safe access
+
+	// Per-item results for the running job
+	items *orchestrator.ItemLog
 }
 
 func NewRotationWorker(pubgClient *pubg.Client, db database.Database) orchestrator.BatchWorker {
@@ -77,6 +86,22 @@ func (r *rotationWorker) Description() string {
 	return ROTATION_DESCRIPTION
 }
 
+// PayloadSchema implements orchestrator.BatchWorker.
+func (r *rotationWorker) PayloadSchema() orchestrator.PayloadSchema {
+	return orchestrator.PayloadSchema{
+		{
+			Name:        "team_ids",
+			Type:        orchestrator.FieldStringArray,
+			Description: "Only pull rotations for these team IDs, all teams when omitted",
+		},
+	}
+}
+
+// SupportsDryRun implements orchestrator.BatchWorker.
+func (r *rotationWorker) SupportsDryRun() bool {
+	return false
+}
+
 // IsActive implements orchestrator.BatchWorker.
 func (r *rotationWorker) IsActive() bool {
 	return atomic.LoadInt32(&r.cancelled) == 0 && r.ctx != nil
@@ -104,17 +129,68 @@ func (r *rotationWorker) StartWorker(job *model.Job) (bool, error) {
 		r.jobID = nil
 	}()
 
+	params := RotationPayload{}
+	if err := orchestrator.DecodePayload(job.Payload, &params); err != nil {
+		return false, err
+	}
+	r.items = orchestrator.NewItemLog(job.ID)
+
 	teams, err := r.db.ListTeams(r.SafeContext())
 	if err != nil {
 		return false, err
 	}
 
+	if job.IsItemRetry() {
+		// Teams are processed as a whole, so a failed match retries the team it was processed for
+		params.TeamIDs = job.RetryItemIDs(model.ItemTeam)
+		for _, item := range job.RetryItems {
+			if item.ItemType == model.ItemMatch && item.ParentID != "" {
+				params.TeamIDs = append(params.TeamIDs, item.ParentID)
+			}
+		}
+	}
+
+	if len(params.TeamIDs) > 0 || job.IsItemRetry() {
+		wanted := make(map[string]bool, len(params.TeamIDs))
+		for _, id := range params.TeamIDs {
+			wanted[id] = true
+		}
+
+		filtered := make([]model.Team, 0, len(params.TeamIDs))
+		for _, team := range teams {
+			if wanted[team.ID.Hex()] {
+				filtered = append(filtered, team)
+			}
+		}
+		teams = filtered
+	}
+
+	// Teams are processed in ID order and the checkpoint records the last completed team, so teams
+	// added or removed before a restart don't make the resume skip or repeat a team
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].ID.Hex() < teams[j].ID.Hex()
+	})
+
 	r.db.SetJobTotalBatches(r.SafeContext(), job.ID, len(teams))
+	completed := 0
+	if job.Checkpoint.LastItemID != "" {
+		completed = job.Checkpoint.CompletedBatches
+	}
 	for _, team := range teams {
+		// Skip teams completed before a restart
+		if job.Checkpoint.LastItemID != "" && team.ID.Hex() <= job.Checkpoint.LastItemID {
+			continue
+		}
+
 		metrics := r.processTeam(team)
+		completed++
 
 		r.db.UpdateJobMetrics(r.SafeContext(), job.ID, metrics)
+		if err := r.items.Flush(r.SafeContext(), r.db); err != nil {
+			log.Error().Err(err).Str("teamID", team.ID.Hex()).Msg("could not save job item results")
+		}
 		r.db.IncrementJobBatchesComplete(r.SafeContext(), job.ID, 1)
+		r.db.SaveJobItemCheckpoint(r.SafeContext(), job.ID, completed, team.ID.Hex())
 
 		if r.isCancelled() {
 			return true, nil
@@ -141,6 +217,7 @@ func (r *rotationWorker) processTeam(team model.Team) model.JobMetrics {
 	if err != nil {
 		log.Error().Err(err).Msg("could not pull player data by names")
 		metrics.FailureCount += 1
+		r.items.Record(model.ItemTeam, team.ID.Hex(), orchestrator.NewFailureError(err))
 
 		return metrics
 	}
@@ -158,28 +235,44 @@ func (r *rotationWorker) processTeam(team model.Team) model.JobMetrics {
 	var wg sync.WaitGroup
 	var mutex sync.Mutex
 
-	wg.Add(len(matchIDMap))
 	teamRotations := make([]model.TeamRotation, 0, len(matchIDMap))
 
 	for matchID := range matchIDMap {
 		if r.isCancelled() {
-			return metrics
+			break
 		}
+
+		wg.Add(1)
 		go func(matchID string) {
+			defer wg.Done()
 			if r.isCancelled() {
 				return
 			}
-			defer wg.Done()
 
 			match, err := r.pubgClient.GetMatch(pubg.SteamPlatform, matchID)
-			matchType := match.GetMatchType(pubg.SteamPlatform)
-			if matchType != "scrim" {
-				log.Warn().Str("Match Type", matchType).Msg("invalid match type")
+			if pubg.IsNotFound(err) {
+				r.items.RecordChild(team.ID.Hex(), model.ItemMatch, matchID, orchestrator.NewInvalidError("match not found in the PUBG API"))
+				mutex.Lock()
 				metrics.InvalidCount += 1
+				mutex.Unlock()
 				return
 			}
 			if err != nil {
 				log.Error().Err(err).Msg("could not get pubg match")
+				r.items.RecordChild(team.ID.Hex(), model.ItemMatch, matchID, orchestrator.NewFailureError(err))
+				mutex.Lock()
+				metrics.FailureCount += 1
+				mutex.Unlock()
+				return
+			}
+			matchType := match.GetMatchType(pubg.SteamPlatform)
+			if matchType != "scrim" {
+				log.Warn().Str("Match Type", matchType).Msg("invalid match type")
+				r.items.RecordChild(team.ID.Hex(), model.ItemMatch, matchID, orchestrator.NewInvalidError("match type is "+matchType+", not scrim"))
+				mutex.Lock()
+				metrics.InvalidCount += 1
+				mutex.Unlock()
+				return
 			}
 			matchMetrics, rotation := r.processMatch(team, match)
 
@@ -194,14 +287,25 @@ func (r *rotationWorker) processTeam(team model.Team) model.JobMetrics {
 		}(matchID)
 	}
 
+	// Matches already being fetched finish before the metrics are returned
 	wg.Wait()
+	if r.isCancelled() {
+		return metrics
+	}
 
 	result, err := r.db.BulkCreateTeamRotations(r.SafeContext(), teamRotations)
 	if err != nil {
 		metrics.FailureCount += 1
+		for _, rotation := range teamRotations {
+			r.items.RecordChild(team.ID.Hex(), model.ItemMatch, rotation.MatchID, orchestrator.NewFailureError(err))
+		}
 		return metrics
 	}
 
+	for _, rotation := range teamRotations {
+		r.items.RecordChild(team.ID.Hex(), model.ItemMatch, rotation.MatchID, orchestrator.NewSuccessError("rotation stored for team "+team.Name))
+	}
+
 	metrics.SuccessCount += int(result.UpsertedCount)
 	metrics.WarningCount += int(result.ModifiedCount)
 
@@ -216,6 +320,7 @@ func (r *rotationWorker) processMatch(team model.Team, match *pubg.PUBGMatchResp
 	matchDocument, err := orchestrator.BuildMatchDocument(pubg.SteamPlatform, *match)
 	if err != nil {
 		log.Error().Err(err).Msg("could not build match document")
+		r.items.RecordChild(team.ID.Hex(), model.ItemMatch, match.Data.ID, orchestrator.NewFailureError(err))
 		metrics.FailureCount += 1
 		return metrics, nil
 	}
@@ -223,6 +328,7 @@ func (r *rotationWorker) processMatch(team model.Team, match *pubg.PUBGMatchResp
 
 	if err != nil {
 		log.Error().Err(err).Msg("not able to build match document")
+		r.items.RecordChild(team.ID.Hex(), model.ItemMatch, matchDocument.MatchID, orchestrator.NewFailureError(err))
 		metrics.FailureCount += 1
 		return metrics, nil
 	}
@@ -238,6 +344,7 @@ func (r *rotationWorker) processMatch(team model.Team, match *pubg.PUBGMatchResp
 
 	if !ok {
 		log.Warn().Msg("match found but players not on the same team")
+		r.items.RecordChild(team.ID.Hex(), model.ItemMatch, matchDocument.MatchID, orchestrator.NewInvalidError("players not on the same team"))
 		metrics.InvalidCount += 1
 		return metrics, nil
 	}
@@ -248,6 +355,7 @@ func (r *rotationWorker) processMatch(team model.Team, match *pubg.PUBGMatchResp
 
 	rotations, err := r.pubgClient.BuildRotationsFromTelemetryYRL(r.SafeContext(), playerNames, matchDocument.TelemetryURL)
 	if err != nil {
+		r.items.RecordChild(team.ID.Hex(), model.ItemMatch, matchDocument.MatchID, orchestrator.NewFailureError(err))
 		metrics.FailureCount += 1
 		return metrics, nil
 	}
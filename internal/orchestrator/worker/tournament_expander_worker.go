@@ -27,6 +27,9 @@ type tournamentExpanderWorker struct {
 	cancelFunc *context.CancelFunc
 	jobID      *primitive.ObjectID
 	cancelled  int32 // Using atomic for thread-safe access
+
+	// Per-item results for the running job
+	items *orchestrator.ItemLog
 }
 
 // ActiveJobID implements orchestrator.BatchWorker.
@@ -62,6 +65,17 @@ func (t *tournamentExpanderWorker) Description() string {
 	return TOURNAMENT_EXPANDER_DESCRIPTION
 }
 
+// PayloadSchema implements orchestrator.BatchWorker.
+// The tournament list comes straight from the PUBG API so the worker takes no parameters.
+func (t *tournamentExpanderWorker) PayloadSchema() orchestrator.PayloadSchema {
+	return orchestrator.PayloadSchema{}
+}
+
+// SupportsDryRun implements orchestrator.BatchWorker.
+func (t *tournamentExpanderWorker) SupportsDryRun() bool {
+	return false
+}
+
 // IsActive implements orchestrator.BatchWorker.
 func (t *tournamentExpanderWorker) IsActive() bool {
 	return atomic.LoadInt32(&t.cancelled) == 0 && t.ctx != nil
@@ -88,14 +102,31 @@ func (t *tournamentExpanderWorker) StartWorker(job *model.Job) (bool, error) {
 		t.cancelFunc = nil
 		t.jobID = nil
 	}()
+	t.items = orchestrator.NewItemLog(job.ID)
+
 	tournaments, err := t.pubgClient.GetTournaments()
-	log.Info().Int("Tournaments", len(tournaments.Data)).Msg("Tournaments found")
 	if err != nil {
-		return false, fmt.Errorf("error getting tournaments: %v", err)
+		return false, fmt.Errorf("error getting tournaments: %w", err)
+	}
+	log.Info().Int("Tournaments", len(tournaments.Data)).Msg("Tournaments found")
+
+	tournamentData := tournaments.Data
+	if job.IsItemRetry() {
+		// Only store the tournaments that failed in the original job
+		wanted := make(map[string]bool)
+		for _, id := range job.RetryItemIDs(model.ItemTournament) {
+			wanted[id] = true
+		}
+
+		tournamentData = make([]pubg.TournamentData, 0, len(wanted))
+		for _, tournament := range tournaments.Data {
+			if wanted[tournament.ID] {
+				tournamentData = append(tournamentData, tournament)
+			}
+		}
 	}
-	//tournamens.Data
 
-	batches := orchestrator.SplitIntoBatches(tournaments.Data, job.BatchSize)
+	batches := orchestrator.SplitIntoBatches(tournamentData, job.BatchSize)
 
 	safeCtx := t.SafeContext()
 	if err := t.db.SetJobTotalBatches(safeCtx, job.ID, len(batches)); err != nil {
@@ -103,12 +134,21 @@ func (t *tournamentExpanderWorker) StartWorker(job *model.Job) (bool, error) {
 	}
 
 	safeCtx = t.SafeContext()
-	for _, batch := range batches {
+	for i, batch := range batches {
+		// Skip batches completed before a restart
+		if i < job.Checkpoint.CompletedBatches {
+			continue
+		}
+
 		metrics := t.processBatch(batch)
 
 		metrics.BatchesComplete = 1
 
 		t.db.UpdateJobMetrics(safeCtx, job.ID, metrics)
+		if err := t.items.Flush(safeCtx, t.db); err != nil {
+			log.Error().Err(err).Msg("could not save job item results")
+		}
+		t.db.SaveJobCheckpoint(safeCtx, job.ID, i+1)
 	}
 
 	return false, nil
@@ -145,9 +185,16 @@ func (t *tournamentExpanderWorker) processBatch(batch []pubg.TournamentData) mod
 	if err != nil {
 		log.Error().Err(err).Msg("error bulk upserting tournamentss")
 		metrics.FailureCount += 1
+		for _, tournament := range tournamentEntities {
+			t.items.Record(model.ItemTournament, tournament.ID, orchestrator.NewFailureError(err))
+		}
 		return metrics
 	}
 
+	for _, tournament := range tournamentEntities {
+		t.items.Record(model.ItemTournament, tournament.ID, orchestrator.NewSuccessError("tournament stored"))
+	}
+
 	metrics.SuccessCount += int(bulkResult.UpsertedCount)
 	metrics.WarningCount += int(bulkResult.MatchedCount)
 
@@ -20,6 +20,11 @@ const (
 	TOURNAMENT_MATCH_EXPANDER_DESCRIPTION = "Search through pubg tournaments and bulk import their matche"
 )
 
+// TournamentMatchExpanderPayload is the job payload accepted by the tournament match expander worker
+type TournamentMatchExpanderPayload struct {
+	TournamentIDs []string `json:"tournament_ids"`
+}
+
 type tournamentMatchExpanderWorker struct {
 	pubgClient *pubg.Client
 	db         database.Database
@@ -28,6 +33,12 @@ type tournamentMatchExpanderWorker struct {
 	cancelFunc *context.CancelFunc
 	jobID      *primitive.ObjectID
 	cancelled  int32 // Using atomic for thread-safe access
+
+	// Per-item results for the running job
+	items *orchestrator.ItemLog
+
+	// Set for a dry run, which projects the imports instead of making them
+	dryRun *orchestrator.MatchDryRun
 }
 
 // Cancel implements job.BatchWorker.
@@ -53,6 +64,22 @@ func (t *tournamentMatchExpanderWorker) Description() string {
 	return TOURNAMENT_MATCH_EXPANDER_DESCRIPTION
 }
 
+// PayloadSchema implements orchestrator.BatchWorker.
+func (t *tournamentMatchExpanderWorker) PayloadSchema() orchestrator.PayloadSchema {
+	return orchestrator.PayloadSchema{
+		{
+			Name:        "tournament_ids",
+			Type:        orchestrator.FieldStringArray,
+			Description: "Only import matches for these tournament IDs, all active tournaments when omitted",
+		},
+	}
+}
+
+// SupportsDryRun implements orchestrator.BatchWorker.
+func (t *tournamentMatchExpanderWorker) SupportsDryRun() bool {
+	return true
+}
+
 // IsActive implements job.BatchWorker.
 func (t *tournamentMatchExpanderWorker) IsActive() bool {
 	return atomic.LoadInt32(&t.cancelled) == 0 && t.ctx != nil
@@ -88,6 +115,17 @@ func (t *tournamentMatchExpanderWorker) StartWorker(job *model.Job) (bool, error
 		}
 	}()
 
+	params := TournamentMatchExpanderPayload{}
+	if err := orchestrator.DecodePayload(job.Payload, &params); err != nil {
+		return false, err
+	}
+	t.items = orchestrator.NewItemLog(job.ID)
+
+	t.dryRun = nil
+	if job.DryRun {
+		t.dryRun = orchestrator.NewMatchDryRun()
+	}
+
 	// Take all tournament IDs and parse through them to build matches
 	safeCtx := t.SafeContext()
 	tournaments, err := t.db.GetActiveTournaments(safeCtx, -1)
@@ -95,15 +133,47 @@ func (t *tournamentMatchExpanderWorker) StartWorker(job *model.Job) (bool, error
 		log.Error().Err(err).Msg("could not get tournaments")
 		return false, err
 	}
+
+	// A retry only processes the tournaments and matches that failed in the original job
+	retryMatchIDs := job.RetryItemIDs(model.ItemMatch)
+	if job.IsItemRetry() {
+		tournaments = make([]model.Entity, 0)
+		for _, id := range job.RetryItemIDs(model.ItemTournament) {
+			tournaments = append(tournaments, model.Entity{ID: id})
+		}
+	} else if len(params.TournamentIDs) > 0 {
+		wanted := make(map[string]bool, len(params.TournamentIDs))
+		for _, id := range params.TournamentIDs {
+			wanted[id] = true
+		}
+
+		filtered := make([]model.Entity, 0, len(params.TournamentIDs))
+		for _, tournament := range tournaments {
+			if wanted[tournament.ID] {
+				filtered = append(filtered, tournament)
+			}
+		}
+		tournaments = filtered
+	}
 	batches := orchestrator.SplitIntoBatches(tournaments, 9)
 
-	t.db.SetJobTotalBatches(t.SafeContext(), job.ID, len(batches))
+	totalBatches := len(batches)
+	if len(retryMatchIDs) > 0 {
+		totalBatches++
+	}
+
+	t.db.SetJobTotalBatches(t.SafeContext(), job.ID, totalBatches)
+
+	for i, batch := range batches {
+		// Skip batches completed before a restart
+		if i < job.Checkpoint.CompletedBatches {
+			continue
+		}
 
-	for _, batch := range batches {
 		if t.isCancelled() {
 			return true, nil
 		}
-		metrics := t.processTournamentBatch(batch)
+		metrics := t.processTournamentBatch(batch, job.ID)
 		// Batch complete
 		metrics.BatchesComplete += 1
 
@@ -112,12 +182,50 @@ func (t *tournamentMatchExpanderWorker) StartWorker(job *model.Job) (bool, error
 		if err != nil {
 			return false, err
 		}
+
+		if err := t.items.Flush(t.SafeContext(), t.db); err != nil {
+			log.Error().Err(err).Msg("could not save job item results")
+		}
+
+		if err := t.db.SaveJobCheckpoint(t.SafeContext(), job.ID, i+1); err != nil {
+			log.Error().Err(err).Msg("could not save job checkpoint")
+		}
+	}
+
+	// Retried matches run as one extra batch at the end
+	if len(retryMatchIDs) > 0 && job.Checkpoint.CompletedBatches < totalBatches {
+		if t.isCancelled() {
+			return true, nil
+		}
+
+		var metrics model.JobMetrics
+		if t.dryRun != nil {
+			metrics, err = t.projectMatches(retryMatchIDs, job.ID, 0)
+		} else {
+			metrics, err = t.importMatches(retryMatchIDs)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("could not import retried matches")
+		}
+		metrics.BatchesComplete += 1
+
+		if err := t.db.UpdateJobMetrics(t.SafeContext(), job.ID, metrics); err != nil {
+			return false, err
+		}
+
+		if err := t.items.Flush(t.SafeContext(), t.db); err != nil {
+			log.Error().Err(err).Msg("could not save job item results")
+		}
+
+		if err := t.db.SaveJobCheckpoint(t.SafeContext(), job.ID, totalBatches); err != nil {
+			log.Error().Err(err).Msg("could not save job checkpoint")
+		}
 	}
 
 	return false, nil
 }
 
-func (t *tournamentMatchExpanderWorker) processTournamentBatch(tournamentBatch []model.Entity) model.JobMetrics {
+func (t *tournamentMatchExpanderWorker) processTournamentBatch(tournamentBatch []model.Entity, jobID primitive.ObjectID) model.JobMetrics {
 	metrics := model.JobMetrics{}
 
 	var wg sync.WaitGroup
@@ -137,10 +245,11 @@ func (t *tournamentMatchExpanderWorker) processTournamentBatch(tournamentBatch [
 				return
 			}
 
-			tournamentMetrics, err := t.processTournament(tournamentID)
+			tournamentMetrics, err := t.processTournament(tournamentID, jobID)
 
 			if err != nil {
 				log.Error().Err(err).Msg("could not process tournament")
+				t.items.Record(model.ItemTournament, tournamentID, orchestrator.NewFailureError(err))
 				mutex.Lock()
 				metrics.FailureCount += 1
 				mutex.Unlock()
@@ -162,7 +271,7 @@ func (t *tournamentMatchExpanderWorker) processTournamentBatch(tournamentBatch [
 	return metrics
 }
 
-func (t *tournamentMatchExpanderWorker) processTournament(tournamentID string) (model.JobMetrics, error) {
+func (t *tournamentMatchExpanderWorker) processTournament(tournamentID string, jobID primitive.ObjectID) (model.JobMetrics, error) {
 	tournamentDetail, err := t.pubgClient.GetTournamentByID(tournamentID)
 	metrics := model.JobMetrics{}
 
@@ -176,6 +285,36 @@ func (t *tournamentMatchExpanderWorker) processTournament(tournamentID string) (
 		matchIDs = append(matchIDs, match.ID)
 	}
 
+	if t.dryRun != nil {
+		return t.projectMatches(matchIDs, jobID, 1)
+	}
+
+	return t.importMatches(matchIDs)
+}
+
+// projectMatches records what importing the matches would do instead of importing them
+func (t *tournamentMatchExpanderWorker) projectMatches(matchIDs []string, jobID primitive.ObjectID, discoveryRequests int) (model.JobMetrics, error) {
+	metrics := model.JobMetrics{}
+	safeCtx := t.SafeContext()
+
+	result, err := t.dryRun.Project(safeCtx, t.db, matchIDs, discoveryRequests)
+	if err != nil {
+		log.Error().Err(err).Msg("could not look up existing matches")
+		return metrics, err
+	}
+
+	if err := t.db.IncrementJobDryRunResult(safeCtx, jobID, result); err != nil {
+		log.Error().Err(err).Msg("could not update job dry run result")
+	}
+
+	metrics.ProcessedItems = result.DiscoveredMatches
+	return metrics, nil
+}
+
+// importMatches fetches the matches from the API and bulk imports the valid ones
+func (t *tournamentMatchExpanderWorker) importMatches(matchIDs []string) (model.JobMetrics, error) {
+	metrics := model.JobMetrics{}
+
 	matchIDBatches := orchestrator.SplitIntoBatches(matchIDs, 40)
 
 	matchDocuments := make([]model.Match, 0, len(matchIDs))
@@ -193,8 +332,17 @@ func (t *tournamentMatchExpanderWorker) processTournament(tournamentID string) (
 				defer wg.Done()
 
 				matchDocument, valid, err := t.BuildMatchDocument(matchID, pubg.EventPlatform)
+				if pubg.IsNotFound(err) {
+					t.items.Record(model.ItemMatch, matchID, orchestrator.NewInvalidError("match not found in the PUBG API"))
+					mutex.Lock()
+					metrics.ProcessedItems++
+					metrics.InvalidCount++
+					mutex.Unlock()
+					return
+				}
 				if err != nil {
 					log.Error().Err(err).Msg("error building match")
+					t.items.Record(model.ItemMatch, matchID, orchestrator.NewFailureError(err))
 					mutex.Lock()
 					metrics.FailureCount++
 					mutex.Unlock()
@@ -207,6 +355,7 @@ func (t *tournamentMatchExpanderWorker) processTournament(tournamentID string) (
 					matchDocuments = append(matchDocuments, *matchDocument)
 				} else {
 					metrics.InvalidCount++
+					t.items.Record(model.ItemMatch, matchID, orchestrator.NewInvalidError("match is not a valid event match"))
 				}
 				mutex.Unlock()
 
@@ -222,6 +371,10 @@ func (t *tournamentMatchExpanderWorker) processTournament(tournamentID string) (
 		return metrics, err
 	}
 
+	for _, match := range matchDocuments {
+		t.items.Record(model.ItemMatch, match.MatchID, orchestrator.NewSuccessError("match imported"))
+	}
+
 	metrics.SuccessCount += bulkResult.SuccessCount
 	metrics.WarningCount += bulkResult.DuplicateCount
 	metrics.FailureCount += bulkResult.FailureCount
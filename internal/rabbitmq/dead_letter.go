@@ -0,0 +1,270 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rs/zerolog/log"
+)
+
+// Headers added to a message when it is dead-lettered
+const (
+	HeaderDeadLetterReason    = "x-dead-letter-reason"
+	HeaderDeadLetterError     = "x-dead-letter-error"
+	HeaderDeadLetteredAt      = "x-dead-lettered-at"
+	HeaderOriginalExchange    = "x-original-exchange"
+	HeaderOriginalRoutingKey  = "x-original-routing-key"
+	HeaderOriginalConsumerTag = "x-original-consumer-tag"
+)
+
+// HeaderLeaseRequeues counts how often a job message was returned to the queue because its job type
+// was running elsewhere. It is removed when the message is replayed, so the count starts again.
+const HeaderLeaseRequeues = "x-lease-requeues"
+
+const (
+	defaultDeadLetterExchange = "harvest_jobs.dlx"
+	defaultDeadLetterQueue    = "jobs.dead"
+)
+
+// MaxDeadLetterScan is the upper bound of messages read while looking for a single dead-lettered message
+const MaxDeadLetterScan = 1000
+
+func (c *client) deadLetterExchange() string {
+	if c.config.DeadLetterExchange != "" {
+		return c.config.DeadLetterExchange
+	}
+	return defaultDeadLetterExchange
+}
+
+func (c *client) deadLetterQueue() string {
+	if c.config.DeadLetterQueue != "" {
+		return c.config.DeadLetterQueue
+	}
+	return defaultDeadLetterQueue
+}
+
+// declareDeadLetterQueue declares the dead-letter exchange and queue on the current channel
+func (c *client) declareDeadLetterQueue() error {
+	if err := c.channel.ExchangeDeclare(c.deadLetterExchange(), "direct", true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	if _, err := c.channel.QueueDeclare(c.deadLetterQueue(), true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	return c.channel.QueueBind(c.deadLetterQueue(), c.deadLetterQueue(), c.deadLetterExchange(), false, nil)
+}
+
+// DeadLetter publishes a copy of the message to the dead-letter queue and acknowledges the original.
+// The broker's own dead-lettering only records "rejected", so the copy carries the actual reason.
+func (c *client) DeadLetter(delivery amqp.Delivery, reason string, cause error) error {
+	headers := amqp.Table{}
+	for key, value := range delivery.Headers {
+		headers[key] = value
+	}
+
+	headers[HeaderDeadLetterReason] = reason
+	headers[HeaderDeadLetteredAt] = time.Now().UTC().Format(time.RFC3339)
+	headers[HeaderOriginalExchange] = delivery.Exchange
+	headers[HeaderOriginalRoutingKey] = delivery.RoutingKey
+	headers[HeaderOriginalConsumerTag] = delivery.ConsumerTag
+	if cause != nil {
+		headers[HeaderDeadLetterError] = cause.Error()
+	}
+
+	messageID := delivery.MessageId
+	if messageID == "" {
+		messageID = newMessageID()
+	}
+
+	c.mu.Lock()
+	err := c.publishLocked(c.deadLetterExchange(), c.deadLetterQueue(), amqp.Publishing{
+		ContentType:  delivery.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Priority:     delivery.Priority,
+		MessageId:    messageID,
+		Timestamp:    time.Now(),
+		Body:         delivery.Body,
+		Headers:      headers,
+	})
+	c.mu.Unlock()
+
+	if err != nil {
+		log.Error().Err(err).Str("reason", reason).Msg("Failed to dead-letter message, dropping it")
+		delivery.Nack(false, false)
+		return err
+	}
+
+	log.Warn().
+		Str("messageId", messageID).
+		Str("reason", reason).
+		Str("routingKey", delivery.RoutingKey).
+		Msg("Message moved to dead-letter queue")
+
+	return delivery.Ack(false)
+}
+
+// PeekDeadLetters returns up to limit dead-lettered messages and leaves them on the queue
+func (c *client) PeekDeadLetters(limit int) ([]amqp.Delivery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ensureConnectedLocked(); err != nil {
+		return nil, err
+	}
+
+	messages := make([]amqp.Delivery, 0, limit)
+	defer func() { c.requeueLocked(messages) }()
+
+	for len(messages) < limit {
+		msg, ok, err := c.channel.Get(c.deadLetterQueue(), false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dead-letter queue: %w", err)
+		}
+		if !ok {
+			break
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// ReplayDeadLetter publishes a dead-lettered message back to the exchange and routing key it was
+// originally sent to, then removes it from the dead-letter queue. Returns nil if it wasn't found.
+func (c *client) ReplayDeadLetter(messageID string) (*amqp.Delivery, error) {
+	return c.takeDeadLetter(messageID, func(msg amqp.Delivery) error {
+		exchange, _ := msg.Headers[HeaderOriginalExchange].(string)
+		routingKey, _ := msg.Headers[HeaderOriginalRoutingKey].(string)
+		if routingKey == "" {
+			return fmt.Errorf("dead-lettered message has no original routing key")
+		}
+
+		headers := amqp.Table{}
+		for key, value := range msg.Headers {
+			switch key {
+			case HeaderDeadLetterReason, HeaderDeadLetterError, HeaderDeadLetteredAt,
+				HeaderOriginalExchange, HeaderOriginalRoutingKey, HeaderOriginalConsumerTag,
+				HeaderLeaseRequeues:
+				continue
+			}
+			headers[key] = value
+		}
+
+		return c.publishLocked(exchange, routingKey, amqp.Publishing{
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Priority:     msg.Priority,
+			MessageId:    msg.MessageId,
+			Body:         msg.Body,
+			Headers:      headers,
+		})
+	})
+}
+
+// DeleteDeadLetter removes a single message from the dead-letter queue. Returns nil if it wasn't found.
+func (c *client) DeleteDeadLetter(messageID string) (*amqp.Delivery, error) {
+	return c.takeDeadLetter(messageID, func(amqp.Delivery) error { return nil })
+}
+
+// PurgeDeadLetters removes every message from the dead-letter queue
+func (c *client) PurgeDeadLetters() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ensureConnectedLocked(); err != nil {
+		return 0, err
+	}
+
+	return c.channel.QueuePurge(c.deadLetterQueue(), false)
+}
+
+// takeDeadLetter scans the dead-letter queue for a message and acknowledges it once handle succeeds.
+// Every other message read during the scan is put back.
+func (c *client) takeDeadLetter(messageID string, handle func(amqp.Delivery) error) (*amqp.Delivery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ensureConnectedLocked(); err != nil {
+		return nil, err
+	}
+
+	scanned := make([]amqp.Delivery, 0)
+	defer func() { c.requeueLocked(scanned) }()
+
+	for i := 0; i < MaxDeadLetterScan; i++ {
+		msg, ok, err := c.channel.Get(c.deadLetterQueue(), false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dead-letter queue: %w", err)
+		}
+		if !ok {
+			return nil, nil
+		}
+
+		if msg.MessageId != messageID {
+			scanned = append(scanned, msg)
+			continue
+		}
+
+		if err := handle(msg); err != nil {
+			scanned = append(scanned, msg)
+			return nil, err
+		}
+
+		if err := c.channel.Ack(msg.DeliveryTag, false); err != nil {
+			return nil, err
+		}
+
+		return &msg, nil
+	}
+
+	return nil, nil
+}
+
+// requeueLocked puts messages read with Get back on their queue
+func (c *client) requeueLocked(messages []amqp.Delivery) {
+	for _, msg := range messages {
+		if err := c.channel.Nack(msg.DeliveryTag, false, true); err != nil {
+			log.Error().Err(err).Str("messageId", msg.MessageId).Msg("Failed to requeue dead-lettered message")
+		}
+	}
+}
+
+// ensureConnectedLocked reconnects if needed. The caller must hold c.mu.
+func (c *client) ensureConnectedLocked() error {
+	if c.conn == nil || c.channel == nil || c.conn.IsClosed() {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("failed to reconnect: %w", err)
+		}
+
+		// Re-setup the reconnect hooks
+		c.setupReconnect()
+	}
+
+	return nil
+}
+
+// publishLocked publishes a message. The caller must hold c.mu.
+func (c *client) publishLocked(exchange, routingKey string, msg amqp.Publishing) error {
+	if err := c.ensureConnectedLocked(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return c.channel.PublishWithContext(ctx, exchange, routingKey, false, false, msg)
+}
+
+func newMessageID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -16,11 +16,21 @@ type Client interface {
 
 	DeclareExchange(name, kind string) error
 	DeclareQueue(name string) (amqp.Queue, error)
+	DeclarePriorityQueue(name string, maxPriority uint8) (amqp.Queue, error)
+	DeclareExclusiveQueue() (amqp.Queue, error)
 	BindQueue(queueName, exchangeName, routingKey string) error
 
 	Publish(exchange, routingKey string, body []byte, headers amqp.Table) error
+	PublishWithPriority(exchange, routingKey string, body []byte, headers amqp.Table, priority uint8) error
 	Consume(queueName string, consumerTag string) (<-chan amqp.Delivery, error)
 
+	// DeadLetter moves a rejected message to the dead-letter queue with the reason it was rejected
+	DeadLetter(delivery amqp.Delivery, reason string, cause error) error
+	PeekDeadLetters(limit int) ([]amqp.Delivery, error)
+	ReplayDeadLetter(messageID string) (*amqp.Delivery, error)
+	DeleteDeadLetter(messageID string) (*amqp.Delivery, error)
+	PurgeDeadLetters() (int, error)
+
 	Health() error
 }
 
@@ -85,6 +95,12 @@ func (c *client) connect() error {
 	c.conn = conn
 	c.channel = ch
 
+	if err := c.declareDeadLetterQueue(); err != nil {
+		log.Error().Err(err).Msg("Failed to declare dead-letter queue")
+		conn.Close()
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+
 	log.Info().
 		Str("host", c.config.Host).
 		Int("port", c.config.Port).
@@ -216,6 +232,11 @@ func (c *client) Close() error {
 }
 
 func (c *client) Publish(exchange, routingKey string, body []byte, headers amqp.Table) error {
+	return c.PublishWithPriority(exchange, routingKey, body, headers, 0)
+}
+
+// PublishWithPriority publishes a message that priority queues deliver ahead of lower priority messages
+func (c *client) PublishWithPriority(exchange, routingKey string, body []byte, headers amqp.Table, priority uint8) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -235,6 +256,7 @@ func (c *client) Publish(exchange, routingKey string, body []byte, headers amqp.
 	err := c.channel.PublishWithContext(ctx, exchange, routingKey, false, false, amqp.Publishing{
 		ContentType:  "application/json",
 		DeliveryMode: amqp.Persistent, // Make messages persistent
+		Priority:     priority,
 		Body:         body,
 		Headers:      headers,
 	})
@@ -255,6 +277,7 @@ func (c *client) Publish(exchange, routingKey string, body []byte, headers amqp.
 				retryErr := c.channel.PublishWithContext(ctx, exchange, routingKey, false, false, amqp.Publishing{
 					ContentType:  "application/json",
 					DeliveryMode: amqp.Persistent,
+					Priority:     priority,
 					Body:         body,
 					Headers:      headers,
 				})
@@ -373,6 +396,57 @@ func (c *client) DeclareQueue(name string) (amqp.Queue, error) {
 	)
 }
 
+// DeclarePriorityQueue declares a durable queue that delivers higher priority messages first.
+// RabbitMQ can't add priorities to an existing queue, so the name must not be used by a plain queue.
+func (c *client) DeclarePriorityQueue(name string, maxPriority uint8) (amqp.Queue, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Connection check and auto-reconnect
+	if c.conn == nil || c.channel == nil || c.conn.IsClosed() {
+		if err := c.connect(); err != nil {
+			return amqp.Queue{}, fmt.Errorf("failed to reconnect before declaring queue: %w", err)
+		}
+
+		// Re-setup the reconnect hooks
+		c.setupReconnect()
+	}
+
+	return c.channel.QueueDeclare(
+		name,  // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{"x-max-priority": int32(maxPriority)}, // arguments
+	)
+}
+
+// DeclareExclusiveQueue declares a server-named queue that is deleted when this connection closes
+func (c *client) DeclareExclusiveQueue() (amqp.Queue, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Connection check and auto-reconnect
+	if c.conn == nil || c.channel == nil || c.conn.IsClosed() {
+		if err := c.connect(); err != nil {
+			return amqp.Queue{}, fmt.Errorf("failed to reconnect before declaring queue: %w", err)
+		}
+
+		// Re-setup the reconnect hooks
+		c.setupReconnect()
+	}
+
+	return c.channel.QueueDeclare(
+		"",    // name, generated by the server
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+}
+
 func (c *client) BindQueue(queueName, exchangeName, routingKey string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func bitsOf(values ...int) uint64 {
+	var bits uint64
+	for _, value := range values {
+		bits |= 1 << uint(value)
+	}
+	return bits
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		bounds  cronField
+		want    uint64
+		wantErr bool
+	}{
+		{name: "wildcard", field: "*", bounds: hourField, want: bitsOf(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23)},
+		{name: "single value", field: "5", bounds: minuteField, want: bitsOf(5)},
+		{name: "list", field: "1,3,5", bounds: minuteField, want: bitsOf(1, 3, 5)},
+		{name: "range", field: "2-4", bounds: hourField, want: bitsOf(2, 3, 4)},
+		{name: "wildcard step", field: "*/15", bounds: minuteField, want: bitsOf(0, 15, 30, 45)},
+		{name: "range step", field: "1-10/3", bounds: domField, want: bitsOf(1, 4, 7, 10)},
+		{name: "value step runs to the end", field: "5/20", bounds: minuteField, want: bitsOf(5, 25, 45)},
+		{name: "list of ranges and steps", field: "0-2,*/30", bounds: minuteField, want: bitsOf(0, 1, 2, 30)},
+		{name: "month bounds", field: "1,12", bounds: monthField, want: bitsOf(1, 12)},
+		{name: "value above max", field: "60", bounds: minuteField, wantErr: true},
+		{name: "value below min", field: "0", bounds: domField, wantErr: true},
+		{name: "reversed range", field: "5-2", bounds: hourField, wantErr: true},
+		{name: "range past max", field: "20-24", bounds: hourField, wantErr: true},
+		{name: "zero step", field: "*/0", bounds: minuteField, wantErr: true},
+		{name: "negative step", field: "*/-5", bounds: minuteField, wantErr: true},
+		{name: "not a number", field: "a", bounds: minuteField, wantErr: true},
+		{name: "bad range end", field: "1-b", bounds: minuteField, wantErr: true},
+		{name: "empty list entry", field: "1,,2", bounds: minuteField, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCronField(tt.field, tt.bounds)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCronField(%q) = %b, want an error", tt.field, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCronField(%q) returned error: %v", tt.field, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseCronField(%q) = %b, want %b", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    *CronSchedule
+		wantErr bool
+	}{
+		{
+			name: "five fields",
+			expr: "*/30 2-3 1 6 *",
+			want: &CronSchedule{
+				minute:        bitsOf(0, 30),
+				hour:          bitsOf(2, 3),
+				dom:           bitsOf(1),
+				month:         bitsOf(6),
+				dow:           bitsOf(0, 1, 2, 3, 4, 5, 6, 7), // 7 is kept alongside 0 for Sunday
+				domRestricted: true,
+			},
+		},
+		{
+			name: "descriptor",
+			expr: " @Daily ",
+			want: &CronSchedule{
+				minute: bitsOf(0),
+				hour:   bitsOf(0),
+				dom:    bitsOf(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31),
+				month:  bitsOf(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12),
+				dow:    bitsOf(0, 1, 2, 3, 4, 5, 6, 7),
+			},
+		},
+		{
+			name: "seven is sunday",
+			expr: "0 0 * * 7",
+			want: &CronSchedule{
+				minute:        bitsOf(0),
+				hour:          bitsOf(0),
+				dom:           bitsOf(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31),
+				month:         bitsOf(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12),
+				dow:           bitsOf(0, 7),
+				dowRestricted: true,
+			},
+		},
+		{name: "too few fields", expr: "* * * *", wantErr: true},
+		{name: "too many fields", expr: "* * * * * *", wantErr: true},
+		{name: "unknown descriptor", expr: "@often", wantErr: true},
+		{name: "invalid minute", expr: "61 * * * *", wantErr: true},
+		{name: "invalid hour", expr: "* 24 * * *", wantErr: true},
+		{name: "invalid day of month", expr: "* * 32 * *", wantErr: true},
+		{name: "invalid month", expr: "* * * 13 *", wantErr: true},
+		{name: "invalid day of week", expr: "* * * * 8", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCron(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseCron(%q) succeeded, want an error", tt.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCron(%q) returned error: %v", tt.expr, err)
+			}
+			if *got != *tt.want {
+				t.Errorf("ParseCron(%q) = %+v, want %+v", tt.expr, *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// 1 January 2024 is a Monday
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{name: "next step", expr: "*/15 * * * *", from: date(2024, 1, 1, 10, 7), want: date(2024, 1, 1, 10, 15)},
+		{name: "strictly after a match", expr: "*/15 * * * *", from: date(2024, 1, 1, 10, 15), want: date(2024, 1, 1, 10, 30)},
+		{name: "seconds are dropped", expr: "* * * * *", from: time.Date(2024, 1, 1, 10, 15, 59, 0, time.UTC), want: date(2024, 1, 1, 10, 16)},
+		{name: "next day", expr: "0 2 * * *", from: date(2024, 1, 1, 3, 0), want: date(2024, 1, 2, 2, 0)},
+		{name: "hour range", expr: "30 2-4 * * *", from: date(2024, 1, 1, 4, 30), want: date(2024, 1, 2, 2, 30)},
+		{name: "next month", expr: "@monthly", from: date(2024, 1, 15, 0, 0), want: date(2024, 2, 1, 0, 0)},
+		{name: "next year", expr: "@yearly", from: date(2024, 1, 1, 0, 0), want: date(2025, 1, 1, 0, 0)},
+		{name: "day of week", expr: "0 0 * * 1", from: date(2024, 1, 1, 0, 0), want: date(2024, 1, 8, 0, 0)},
+		{name: "seven is sunday", expr: "0 0 * * 7", from: date(2024, 1, 1, 0, 0), want: date(2024, 1, 7, 0, 0)},
+		{name: "either restricted day matches", expr: "0 0 13 * 5", from: date(2024, 1, 1, 0, 0), want: date(2024, 1, 5, 0, 0)},
+		{name: "day of month when it comes first", expr: "0 0 13 * 5", from: date(2024, 1, 12, 1, 0), want: date(2024, 1, 13, 0, 0)},
+		{name: "leap day", expr: "30 9 29 2 *", from: date(2024, 3, 1, 0, 0), want: date(2028, 2, 29, 9, 30)},
+		{name: "end of month rolls over", expr: "0 12 31 * *", from: date(2024, 4, 1, 0, 0), want: date(2024, 5, 31, 12, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) returned error: %v", tt.expr, err)
+			}
+
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNextSearchCap(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "30 february", expr: "0 0 30 2 *"},
+		{name: "31 april", expr: "0 0 31 4 *"},
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) returned error: %v", tt.expr, err)
+			}
+
+			if got := schedule.Next(from); !got.IsZero() {
+				t.Errorf("Next(%v) = %v, want the zero time", from, got)
+			}
+		})
+	}
+
+	// A leap day more than maxCronSearchYears away is past the cap
+	schedule, err := ParseCron("0 0 29 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron returned error: %v", err)
+	}
+	if got := schedule.Next(time.Date(2097, 3, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next past the search cap = %v, want the zero time", got)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"harvest/internal/controller"
+	"harvest/internal/model"
+	"harvest/internal/rabbitmq"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDeadLettersHandler returns the messages on the dead-letter queue without removing them
+func (s *Server) ListDeadLettersHandler(c *gin.Context) {
+	limit := 50
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit <= 0 || parsedLimit > rabbitmq.MaxDeadLetterScan {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid limit parameter. Must be an integer between 1 and %d", rabbitmq.MaxDeadLetterScan)})
+			return
+		}
+		limit = parsedLimit
+	}
+
+	deadLetters, err := s.dlc.ListDeadLetters(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list dead letters: " + err.Error()})
+		return
+	}
+
+	if len(deadLetters) == 0 {
+		deadLetters = []model.DeadLetter{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": deadLetters,
+		"count":        len(deadLetters),
+	})
+}
+
+// GetDeadLetterHandler returns a single dead-lettered message
+func (s *Server) GetDeadLetterHandler(c *gin.Context) {
+	deadLetter, err := s.dlc.GetDeadLetter(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dead letter: " + err.Error()})
+		return
+	}
+
+	if deadLetter == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetter)
+}
+
+// ReplayDeadLetterHandler sends a dead-lettered message back to the queue it was rejected from
+func (s *Server) ReplayDeadLetterHandler(c *gin.Context) {
+	deadLetter, err := s.dlc.ReplayDeadLetter(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, controller.ErrDeadLetterJobFinished) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job of the dead letter has already finished, delete the dead letter instead", "dead_letter": deadLetter})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if deadLetter == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully replayed dead letter", "dead_letter": deadLetter})
+}
+
+// DeleteDeadLetterHandler removes a single dead-lettered message
+func (s *Server) DeleteDeadLetterHandler(c *gin.Context) {
+	deadLetter, err := s.dlc.DeleteDeadLetter(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete dead letter: " + err.Error()})
+		return
+	}
+
+	if deadLetter == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully deleted dead letter"})
+}
+
+// PurgeDeadLettersHandler removes every message from the dead-letter queue
+func (s *Server) PurgeDeadLettersHandler(c *gin.Context) {
+	count, err := s.dlc.PurgeDeadLetters()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge dead letters: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully purged dead letters", "count": count})
+}
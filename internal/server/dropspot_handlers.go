@@ -1,8 +1,12 @@
 package server
 
 import (
+	"harvest/internal/controller"
 	"harvest/internal/model"
+	"harvest/pkg/pubg"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -97,3 +101,57 @@ func (s *Server) BulkUpsertDropSpotLocationsHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Bulk upsert successful"})
 }
+
+// GetDropSpotStatsHandler returns how often each drop spot of a map was landed at and contested
+// in the processed matches, optionally filtered by match type and date
+func (s *Server) GetDropSpotStatsHandler(c *gin.Context) {
+	mapName := c.Param("map_name")
+	if pubg.MAP_NAME_MAP[mapName] == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid map name: " + mapName})
+		return
+	}
+
+	var filter controller.DropSpotStatsFilter
+
+	// Parse match types (optional), comma separated
+	for _, matchType := range strings.Split(c.Query("match_type"), ",") {
+		if trimmed := strings.TrimSpace(matchType); trimmed != "" {
+			filter.MatchTypes = append(filter.MatchTypes, trimmed)
+		}
+	}
+
+	// Parse start date (optional)
+	if startDateParam := c.Query("start_date"); startDateParam != "" {
+		parsed, err := time.Parse("2006-01-02", startDateParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+			return
+		}
+		filter.StartDate = &parsed
+	}
+
+	// Parse end date (optional), the whole day is included by filtering up to the next day
+	if endDateParam := c.Query("end_date"); endDateParam != "" {
+		parsed, err := time.Parse("2006-01-02", endDateParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+			return
+		}
+		endBefore := parsed.AddDate(0, 0, 1)
+		filter.EndBefore = &endBefore
+	}
+
+	if filter.StartDate != nil && filter.EndBefore != nil && !filter.StartDate.Before(*filter.EndBefore) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date cannot be after end_date"})
+		return
+	}
+
+	stats, err := s.dc.GetDropSpotStats(c.Request.Context(), mapName, filter)
+	if err != nil {
+		log.Error().Err(err).Str("mapName", mapName).Msg("Failed to get drop spot stats")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get drop spot stats: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"harvest/internal/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// JobScheduleRequest represents the request for creating or updating a job schedule
+type JobScheduleRequest struct {
+	Name           string      `json:"name" binding:"required"`
+	JobType        string      `json:"job_type" binding:"required"`
+	Payload        interface{} `json:"payload"`
+	CronExpression string      `json:"cron_expression" binding:"required"`
+	Enabled        *bool       `json:"enabled"`
+}
+
+// toModel converts the request into a schedule model, defaulting to enabled
+func (r JobScheduleRequest) toModel() *model.JobSchedule {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+
+	return &model.JobSchedule{
+		Name:           r.Name,
+		JobType:        r.JobType,
+		Payload:        r.Payload,
+		CronExpression: r.CronExpression,
+		Enabled:        enabled,
+	}
+}
+
+// CreateJobScheduleHandler creates a new recurring job schedule
+func (s *Server) CreateJobScheduleHandler(c *gin.Context) {
+	var req JobScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokenID := getTokenID(c)
+	if tokenID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token ID not found"})
+		return
+	}
+
+	schedule := req.toModel()
+	schedule.TokenID = tokenID
+
+	if err := s.jsc.CreateSchedule(c.Request.Context(), schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create job schedule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// ListJobSchedulesHandler returns all job schedules
+func (s *Server) ListJobSchedulesHandler(c *gin.Context) {
+	schedules, err := s.jsc.ListSchedules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list job schedules: " + err.Error()})
+		return
+	}
+
+	if len(schedules) == 0 {
+		schedules = []model.JobSchedule{}
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// GetJobScheduleHandler returns a specific job schedule by ID
+func (s *Server) GetJobScheduleHandler(c *gin.Context) {
+	schedule, err := s.jsc.GetSchedule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job schedule: " + err.Error()})
+		return
+	}
+
+	if schedule == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job schedule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateJobScheduleHandler replaces a job schedule
+func (s *Server) UpdateJobScheduleHandler(c *gin.Context) {
+	var req JobScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := s.jsc.UpdateSchedule(c.Request.Context(), c.Param("id"), req.toModel())
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job schedule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update job schedule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteJobScheduleHandler removes a job schedule
+func (s *Server) DeleteJobScheduleHandler(c *gin.Context) {
+	err := s.jsc.DeleteSchedule(c.Request.Context(), c.Param("id"))
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job schedule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete job schedule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully deleted job schedule"})
+}
@@ -1,18 +1,26 @@
 package server
 
 import (
+	"errors"
 	"harvest/internal/model"
+	"harvest/internal/orchestrator"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // JobRequest represents the request for creating a job
 type JobRequest struct {
-	Type    string      `json:"type" binding:"required"`
-	Payload interface{} `json:"payload" binding:"required"`
+	Type     string      `json:"type" binding:"required"`
+	Payload  interface{} `json:"payload" binding:"required"`
+	Priority string      `json:"priority"` // Optional: low, normal or high, defaults to the job type's priority
+	DryRun   bool        `json:"dry_run"`  // Optional: only project what the job would import
 }
 
 // JobResponse represents the response for job operations
@@ -20,18 +28,38 @@ type JobResponse struct {
 	ID        string           `json:"id"`
 	Type      string           `json:"type"`
 	Status    string           `json:"status"`
+	Queue     string           `json:"queue,omitempty"`
+	Priority  string           `json:"priority,omitempty"`
 	Progress  int              `json:"progress"`
 	TokenID   string           `json:"tokenId"`
 	CreatedAt string           `json:"createdAt"`
 	UpdatedAt string           `json:"updatedAt"`
 	ErrorList []string         `json:"errorList,omitempty"`
 	Metrics   model.JobMetrics `json:"metrics"`
+
+	DryRun       bool                `json:"dryRun,omitempty"`
+	DryRunResult *model.DryRunResult `json:"dryRunResult,omitempty"`
+
+	RetryCount  int    `json:"retryCount"`
+	NextRetryAt string `json:"nextRetryAt,omitempty"`
+	RetryOf     string `json:"retryOf,omitempty"`
+	RetryItems  int    `json:"retryItems,omitempty"`
+
+	WorkerID    string         `json:"workerId,omitempty"`
+	StartedAt   string         `json:"startedAt,omitempty"`
+	HeartbeatAt string         `json:"heartbeatAt,omitempty"`
+	Lock        *model.JobLock `json:"lock,omitempty"`
 }
 
+// Interval between keep-alive events on an idle job stream
+const jobStreamKeepAlive = 15 * time.Second
+
 type JobTypeResponse struct {
-	JobType        string `json:"job_type"`
-	JobName        string `json:"job_name"`
-	JobDescription string `json:"job_description"`
+	JobType        string                     `json:"job_type"`
+	JobName        string                     `json:"job_name"`
+	JobDescription string                     `json:"job_description"`
+	PayloadSchema  orchestrator.PayloadSchema `json:"payload_schema"`
+	SupportsDryRun bool                       `json:"supports_dry_run"`
 }
 
 // CreateJobHandler creates a new job
@@ -42,6 +70,19 @@ func (s *Server) CreateJobHandler(c *gin.Context) {
 		return
 	}
 
+	priority := model.JobPriority(req.Priority)
+	if priority != "" && !priority.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid priority. Must be one of low, normal, high"})
+		return
+	}
+
+	if req.DryRun {
+		if worker, ok := s.jc.GetAvailableJobTypes()[req.Type]; ok && !worker.SupportsDryRun() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Job type does not support dry runs"})
+			return
+		}
+	}
+
 	// Get token ID from context (set by auth middleware)
 	tokenID := getTokenID(c)
 	if tokenID == "" {
@@ -50,7 +91,13 @@ func (s *Server) CreateJobHandler(c *gin.Context) {
 	}
 
 	// Create the job
-	job, err := s.jc.CreateJob(c.Request.Context(), req.Type, req.Payload, tokenID)
+	job, err := s.jc.CreateJob(c.Request.Context(), req.Type, req.Payload, priority, req.DryRun, tokenID)
+
+	var validationErr *orchestrator.PayloadValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error(), "problems": validationErr.Problems})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job: " + err.Error()})
 		return
@@ -82,10 +129,15 @@ func (s *Server) GetJobHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// CancelJobHandler cancels a running or queued job by ID
 func (s *Server) CancelJobHandler(c *gin.Context) {
-	jobType := c.Param("type")
+	jobID := c.Param("id")
 
-	err := s.jc.CancelJob(jobType)
+	err := s.jc.CancelJob(c.Request.Context(), jobID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Could not cancel a job")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -95,16 +147,238 @@ func (s *Server) CancelJobHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully canceled job"})
 }
 
-// ListJobsHandler returns a list of jobs for the current token
+// RetryJobHandler creates a job that processes only the items that failed in the given job
+func (s *Server) RetryJobHandler(c *gin.Context) {
+	tokenID := getTokenID(c)
+	if tokenID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token ID not found"})
+		return
+	}
+
+	job, err := s.jc.RetryJob(c.Request.Context(), c.Param("id"), tokenID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to retry job: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertJobToResponse(job))
+}
+
+// StreamJobHandler streams progress of a job as Server-Sent Events. The current job is sent first
+// as a "snapshot" event, followed by "metrics", "batch" and "status" events as they happen.
+// The stream ends once the job reaches a final status.
+func (s *Server) StreamJobHandler(c *gin.Context) {
+	jobID := c.Param("id")
+
+	// Subscribe before reading the snapshot so no update is lost in between
+	events, unsubscribe := s.jobEvents.Subscribe(jobID)
+	defer unsubscribe()
+
+	job, err := s.jc.GetJob(c.Request.Context(), jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job: " + err.Error()})
+		return
+	}
+
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	// Streams outlive the server write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn().Err(err).Str("jobId", jobID).Msg("Could not clear write deadline for job stream")
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("snapshot", convertJobToResponse(job))
+	c.Writer.Flush()
+
+	if job.Status.IsFinished() {
+		return
+	}
+
+	keepAlive := time.NewTicker(jobStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(event.Type), event)
+			return !event.IsFinal()
+		case <-keepAlive.C:
+			c.SSEvent("ping", gin.H{"timestamp": time.Now()})
+			return true
+		}
+	})
+}
+
+// GetJobItemsHandler returns a page of per-item results for a job
+func (s *Server) GetJobItemsHandler(c *gin.Context) {
+	jobID := c.Param("id")
+
+	result := model.JobResultType(c.Query("result"))
+	switch result {
+	case "", model.ResultSuccess, model.ResultWarning, model.ResultFailure, model.ResultInvalid, model.ResultSkipped:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid result parameter. Must be one of success, warning, failure, invalid, skipped"})
+		return
+	}
+
+	page := 0
+	if pageParam := c.Query("page"); pageParam != "" {
+		parsedPage, err := strconv.Atoi(pageParam)
+		if err != nil || parsedPage < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter. Must be a non-negative integer"})
+			return
+		}
+		page = parsedPage
+	}
+
+	pageSize := 50 // Default page size
+	if pageSizeParam := c.Query("page_size"); pageSizeParam != "" {
+		parsedPageSize, err := strconv.Atoi(pageSizeParam)
+		if err != nil || parsedPageSize < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_size parameter. Must be a positive integer"})
+			return
+		}
+		pageSize = parsedPageSize
+	}
+
+	items, total, err := s.jc.GetJobItems(c.Request.Context(), jobID, result, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job items: " + err.Error()})
+		return
+	}
+
+	if len(items) == 0 {
+		items = []model.JobItem{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"count": len(items),
+		"total": total,
+		"pagination": model.PaginationOptions{
+			Page: page,
+			Size: pageSize,
+		},
+	})
+}
+
+// ListJobsHandler returns a page of jobs filtered by status, type, token and creation time
 func (s *Server) ListJobsHandler(c *gin.Context) {
-	// List jobs for this token
-	jobs, err := s.jc.ListJobs(c.Request.Context())
+	filter := model.JobFilter{
+		Types:   splitQuery(c.Query("type")),
+		TokenID: c.Query("token_id"),
+	}
+
+	for _, status := range splitQuery(c.Query("status")) {
+		switch model.JobStatus(status) {
+		case model.StatusQueued, model.StatusProcessing, model.StatusCompleted,
+			model.StatusFailed, model.StatusRetrying, model.StatusCancelled:
+			filter.Statuses = append(filter.Statuses, model.JobStatus(status))
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status parameter. Must be one of queued, processing, completed, failed, retrying, cancelled"})
+			return
+		}
+	}
+
+	if createdAfter := c.Query("created_after"); createdAfter != "" {
+		parsed, err := time.Parse(time.RFC3339, createdAfter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_after parameter. Must be an RFC3339 date time"})
+			return
+		}
+		filter.CreatedAfter = &parsed
+	}
+
+	if createdBefore := c.Query("created_before"); createdBefore != "" {
+		parsed, err := time.Parse(time.RFC3339, createdBefore)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_before parameter. Must be an RFC3339 date time"})
+			return
+		}
+		filter.CreatedBefore = &parsed
+	}
+
+	switch sortBy := c.DefaultQuery("sort", model.JobSortUpdatedAt); sortBy {
+	case model.JobSortCreatedAt, model.JobSortUpdatedAt:
+		filter.SortBy = sortBy
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort parameter. Must be one of created_at, updated_at"})
+		return
+	}
+
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "asc":
+		filter.Ascending = true
+	case "desc":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order parameter. Must be one of asc, desc"})
+		return
+	}
+
+	if archived := c.Query("archived"); archived != "" {
+		parsed, err := strconv.ParseBool(archived)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid archived parameter. Must be true or false"})
+			return
+		}
+		filter.Archived = parsed
+	}
+
+	if pageParam := c.Query("page"); pageParam != "" {
+		parsedPage, err := strconv.Atoi(pageParam)
+		if err != nil || parsedPage < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter. Must be a non-negative integer"})
+			return
+		}
+		filter.Page = parsedPage
+	}
+
+	filter.Size = 25 // Default page size
+	if pageSizeParam := c.Query("page_size"); pageSizeParam != "" {
+		parsedPageSize, err := strconv.Atoi(pageSizeParam)
+		if err != nil || parsedPageSize < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_size parameter. Must be a positive integer"})
+			return
+		}
+		filter.Size = parsedPageSize
+	}
+
+	jobs, total, err := s.jc.ListJobs(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, jobs)
+	if len(jobs) == 0 {
+		jobs = []model.Job{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"count": len(jobs),
+		"total": total,
+		"pagination": model.PaginationOptions{
+			Page: filter.Page,
+			Size: filter.Size,
+		},
+	})
 }
 
 func (s *Server) ListAllAvailableJobTypes(c *gin.Context) {
@@ -116,6 +390,8 @@ func (s *Server) ListAllAvailableJobTypes(c *gin.Context) {
 			JobType:        jobType,
 			JobDescription: worker.Description(),
 			JobName:        worker.Name(),
+			PayloadSchema:  worker.PayloadSchema(),
+			SupportsDryRun: worker.SupportsDryRun(),
 		})
 	}
 
@@ -126,15 +402,54 @@ func (s *Server) ListAllAvailableJobTypes(c *gin.Context) {
 
 // convertJobToResponse converts a job model to a response format
 func convertJobToResponse(job *model.Job) JobResponse {
-	return JobResponse{
-		ID:        job.ID.Hex(),
-		Type:      job.Type,
-		Status:    string(job.Status),
-		TokenID:   job.TokenID, // Note: In your model, UserID is actually TokenID
-		CreatedAt: job.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: job.UpdatedAt.Format(time.RFC3339),
-		Metrics:   job.Metrics,
+	response := JobResponse{
+		ID:         job.ID.Hex(),
+		Type:       job.Type,
+		Status:     string(job.Status),
+		Queue:      job.Queue,
+		Priority:   string(job.Priority),
+		TokenID:    job.TokenID, // Note: In your model, UserID is actually TokenID
+		CreatedAt:  job.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  job.UpdatedAt.Format(time.RFC3339),
+		Metrics:    job.Metrics,
+		DryRun:     job.DryRun,
+		RetryCount: job.RetryCount,
+		RetryItems: len(job.RetryItems),
+		WorkerID:   job.WorkerID,
+		Lock:       job.Lock,
+	}
+
+	if job.DryRun {
+		response.DryRunResult = job.DryRunResult
+	}
+	if job.LastError != "" {
+		response.ErrorList = []string{job.LastError}
+	}
+	if job.NextRetryAt != nil && job.Status == model.StatusRetrying {
+		response.NextRetryAt = job.NextRetryAt.Format(time.RFC3339)
+	}
+	if job.RetryOf != nil {
+		response.RetryOf = job.RetryOf.Hex()
+	}
+	if job.StartedAt != nil {
+		response.StartedAt = job.StartedAt.Format(time.RFC3339)
+	}
+	if job.HeartbeatAt != nil {
+		response.HeartbeatAt = job.HeartbeatAt.Format(time.RFC3339)
+	}
+
+	return response
+}
+
+// splitQuery splits a comma separated query parameter, ignoring empty values
+func splitQuery(value string) []string {
+	values := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
 	}
+	return values
 }
 
 // getTokenID gets the token ID from the context (set by auth middleware)
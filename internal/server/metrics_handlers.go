@@ -92,3 +92,8 @@ func (s *Server) GetMatchMetricsForTimeRangeHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// GetPubgAPIStatsHandler returns the PUBG API request counts of this instance
+func (s *Server) GetPubgAPIStatsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, s.pc.GetAPIStats())
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"harvest/internal/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PipelineStepRequest represents a single step of a pipeline request
+type PipelineStepRequest struct {
+	Name      string      `json:"name" binding:"required"`
+	Type      string      `json:"type" binding:"required"`
+	Payload   interface{} `json:"payload"`
+	DependsOn []string    `json:"depends_on"`
+}
+
+// PipelineRequest represents the request for creating a pipeline
+type PipelineRequest struct {
+	Name  string                `json:"name" binding:"required"`
+	Steps []PipelineStepRequest `json:"steps" binding:"required,min=1,dive"`
+}
+
+// CreatePipelineHandler creates a pipeline and starts its first steps
+func (s *Server) CreatePipelineHandler(c *gin.Context) {
+	var req PipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokenID := getTokenID(c)
+	if tokenID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token ID not found"})
+		return
+	}
+
+	steps := make([]model.PipelineStep, len(req.Steps))
+	for i, step := range req.Steps {
+		steps[i] = model.PipelineStep{
+			Name:      step.Name,
+			JobType:   step.Type,
+			Payload:   step.Payload,
+			DependsOn: step.DependsOn,
+		}
+	}
+
+	pipeline, err := s.jc.CreatePipeline(c.Request.Context(), req.Name, steps, tokenID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create pipeline: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, pipeline)
+}
+
+// ListPipelinesHandler returns all pipelines
+func (s *Server) ListPipelinesHandler(c *gin.Context) {
+	pipelines, err := s.jc.ListPipelines(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list pipelines: " + err.Error()})
+		return
+	}
+
+	if len(pipelines) == 0 {
+		pipelines = []model.Pipeline{}
+	}
+
+	c.JSON(http.StatusOK, pipelines)
+}
+
+// GetPipelineHandler returns a pipeline and the status of each of its steps
+func (s *Server) GetPipelineHandler(c *gin.Context) {
+	pipeline, err := s.jc.GetPipeline(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pipeline: " + err.Error()})
+		return
+	}
+
+	if pipeline == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pipeline not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// CancelPipelineHandler cancels a running pipeline
+func (s *Server) CancelPipelineHandler(c *gin.Context) {
+	err := s.jc.CancelPipeline(c.Request.Context(), c.Param("id"))
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pipeline not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to cancel pipeline: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully cancelled pipeline"})
+}
@@ -341,3 +341,38 @@ func (s *Server) GetMatchByIDHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, match)
 }
+
+// GetMatchKillsHandler returns the kills of a match in the order they happened. Kills are
+// extracted when the match telemetry is processed, so a match that hasn't been processed yet
+// has none.
+func (s *Server) GetMatchKillsHandler(c *gin.Context) {
+	matchID := c.Param("match_id")
+	if matchID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "match_id parameter is required"})
+		return
+	}
+
+	match, err := s.pc.GetMatchByID(c.Request.Context(), matchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if match == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
+
+	kills, err := s.pc.GetMatchKills(c.Request.Context(), matchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get match kills: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"match_id":  matchID,
+		"processed": match.Processed,
+		"count":     len(kills),
+		"kills":     kills,
+	})
+}
@@ -33,6 +33,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 			pubg.GET("/matches", s.filteredMatchesHandler)
 			pubg.GET("/matches/random", s.filteredRandomMatchHandler)
 			pubg.GET("/matches/:match_id", s.GetMatchByIDHandler)
+			pubg.GET("/matches/:match_id/kills", s.GetMatchKillsHandler)
 			pubg.POST("/names", s.namesHandler)
 			pubg.GET("/players", s.getPlayersHanlder)
 			pubg.GET("/tournaments", s.getTournamentsHandler)
@@ -43,6 +44,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 				dropspots.POST("/bulk", s.BulkUpsertDropSpotLocationsHandler)
 				dropspots.GET("/:id", s.GetDropSpotLocationByIDHandler)
 				dropspots.GET("/map/:map_name", s.GetDropSpotLocationByMapHandler)
+				dropspots.GET("/map/:map_name/stats", s.GetDropSpotStatsHandler)
 				dropspots.PUT("/:id", s.UpdateDropSpotLocationHandler)
 				dropspots.DELETE("/:id", s.DeleteDropSpotLocationHandler)
 			}
@@ -52,10 +54,30 @@ func (s *Server) RegisterRoutes() http.Handler {
 		jobs := api.Group("/jobs")
 		{
 			jobs.POST("", s.CreateJobHandler)
-			jobs.GET("/", s.ListJobsHandler) // For listing all jobs with optional status filter
+			jobs.GET("", s.ListJobsHandler) // Filtered and paginated job listing
 			jobs.GET("/types", s.ListAllAvailableJobTypes)
 			jobs.GET("/:id", s.GetJobHandler)
-			jobs.DELETE("/:type", s.CancelJobHandler)
+			jobs.GET("/:id/items", s.GetJobItemsHandler)
+			jobs.GET("/:id/stream", s.StreamJobHandler)
+			jobs.POST("/:id/retry", s.RetryJobHandler)
+			jobs.DELETE("/:id", s.CancelJobHandler)
+
+			schedules := jobs.Group("/schedules")
+			{
+				schedules.POST("", s.CreateJobScheduleHandler)
+				schedules.GET("", s.ListJobSchedulesHandler)
+				schedules.GET("/:id", s.GetJobScheduleHandler)
+				schedules.PUT("/:id", s.UpdateJobScheduleHandler)
+				schedules.DELETE("/:id", s.DeleteJobScheduleHandler)
+			}
+
+			pipelines := jobs.Group("/pipelines")
+			{
+				pipelines.POST("", s.CreatePipelineHandler)
+				pipelines.GET("", s.ListPipelinesHandler)
+				pipelines.GET("/:id", s.GetPipelineHandler)
+				pipelines.DELETE("/:id", s.CancelPipelineHandler)
+			}
 		}
 
 		teams := api.Group("/teams")
@@ -75,6 +97,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 			metrics.GET("/total", s.GetTotalMatchCountHandler)
 			metrics.GET("/distribution", s.GetMatchDistributionHandler)
 			metrics.GET("/time-range", s.GetMatchMetricsForTimeRangeHandler)
+			metrics.GET("/pubg-api", s.GetPubgAPIStatsHandler)
 		}
 	}
 
@@ -88,5 +111,19 @@ func (s *Server) RegisterRoutes() http.Handler {
 		tokens.DELETE("/:id", s.RevokeTokenHandler)
 	}
 
+	// Queue administration routes (ADMIN only)
+	admin := r.Group("/admin")
+	admin.Use(s.AuthMiddleware(controller.RoleAdmin))
+	{
+		deadLetters := admin.Group("/dead-letters")
+		{
+			deadLetters.GET("", s.ListDeadLettersHandler)
+			deadLetters.DELETE("", s.PurgeDeadLettersHandler)
+			deadLetters.GET("/:id", s.GetDeadLetterHandler)
+			deadLetters.POST("/:id/replay", s.ReplayDeadLetterHandler)
+			deadLetters.DELETE("/:id", s.DeleteDeadLetterHandler)
+		}
+	}
+
 	return r
 }
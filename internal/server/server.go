@@ -8,11 +8,14 @@ import (
 	"harvest/internal/config"
 	"harvest/internal/controller"
 	"harvest/internal/database"
+	"harvest/internal/events"
 	"harvest/internal/orchestrator"
 	"harvest/internal/rabbitmq"
 	"harvest/pkg/pubg"
 	"net/http"
 	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 type Server struct {
@@ -20,17 +23,34 @@ type Server struct {
 	pc             controller.PubgController
 	tc             controller.TokenController
 	jc             controller.JobController
+	jsc            controller.JobScheduleController
+	dlc            controller.DeadLetterController
 	mc             controller.MetricsController
 	dc             controller.DropSpotLocationController
 	teamController controller.TeamController
+	jobEvents      events.JobEventBus
 	config         config.Config
 }
 
-func New(config config.Config, db database.Database, cache cache.Cache, rabbit rabbitmq.Client, client pubg.Client, workerRegistry orchestrator.WorkerRegistry, fileService aws.FileService) *http.Server {
+func New(config config.Config, db database.Database, cache cache.Cache, rabbit rabbitmq.Client, jobEvents events.JobEventBus, client pubg.Client, workerRegistry orchestrator.WorkerRegistry, fileService aws.FileService) *http.Server {
 	sc := controller.NewServer(db, cache, rabbit, fileService)
 
-	jc := controller.NewJobController(db, rabbit, config.RabbitMQ, config.Jobs, workerRegistry)
-	jc.ProcessJobs(context.Background()) // Starts consuming messages from rabbit MQ
+	jc := controller.NewJobController(db, db, rabbit, jobEvents, config.RabbitMQ, config.Jobs, workerRegistry)
+	if config.Jobs.EnqueueOnly() {
+		log.Info().Msg("API running in enqueue-only mode, jobs are processed by cmd/worker")
+	} else {
+		jc.ProcessJobs(context.Background()) // Starts consuming messages from rabbit MQ
+		if err := jc.ResumeJobs(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Failed to resume orphaned jobs")
+		}
+	}
+
+	jc.StartArchiver(context.Background()) // Moves old finished jobs to jobs_archive
+
+	jsc := controller.NewJobScheduleController(db, jc)
+	jsc.StartScheduler(context.Background()) // Enqueues recurring jobs when their schedule is due
+
+	dlc := controller.NewDeadLetterController(db, rabbit)
 
 	pc := controller.NewPUBG(db, client)
 
@@ -46,9 +66,12 @@ func New(config config.Config, db database.Database, cache cache.Cache, rabbit r
 		pc:             pc,
 		tc:             tc,
 		jc:             jc,
+		jsc:            jsc,
+		dlc:            dlc,
 		mc:             mc,
 		dc:             dc,
 		teamController: *teamController,
+		jobEvents:      jobEvents,
 		config:         config,
 	}
 
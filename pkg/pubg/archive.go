@@ -0,0 +1,248 @@
+package pubg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"harvest/internal/config"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Archive backends selectable with pubg.telemetry_archive.backend
+const (
+	TelemetryArchiveLocal = "local"
+	TelemetryArchiveS3    = "s3"
+)
+
+// TelemetryFileStore stores archived files. It is satisfied by aws.FileService, and by the local
+// store from NewLocalFileStore for development. DownloadFile returns an error matching
+// fs.ErrNotExist for a file that was never stored.
+type TelemetryFileStore interface {
+	UploadFile(string, io.Reader) (string, error)
+	DownloadFile(string) (io.ReadCloser, error)
+}
+
+// TelemetryArchive keeps the gzip compressed telemetry files downloaded by the client, keyed by
+// match ID, so a match can be processed again without downloading its telemetry again
+type TelemetryArchive struct {
+	files  TelemetryFileStore
+	prefix string
+}
+
+// NewTelemetryArchive creates an archive that stores telemetry files under the prefix of a file store
+func NewTelemetryArchive(files TelemetryFileStore, prefix string) *TelemetryArchive {
+	return &TelemetryArchive{
+		files:  files,
+		prefix: strings.Trim(prefix, "/"),
+	}
+}
+
+// NewTelemetryArchiveFromConfig creates the archive selected by the configuration, or returns nil
+// when archiving is disabled. The file store is only used by the s3 backend.
+func NewTelemetryArchiveFromConfig(cfg config.TelemetryArchiveConfig, files TelemetryFileStore) (*TelemetryArchive, error) {
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = "telemetry"
+	}
+
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case TelemetryArchiveLocal:
+		directory := cfg.Directory
+		if directory == "" {
+			directory = "data"
+		}
+		return NewTelemetryArchive(NewLocalFileStore(directory), prefix), nil
+	case TelemetryArchiveS3:
+		if files == nil {
+			return nil, fmt.Errorf("telemetry archive backend %q needs a file service", cfg.Backend)
+		}
+		return NewTelemetryArchive(files, prefix), nil
+	default:
+		return nil, fmt.Errorf("unknown telemetry archive backend %q", cfg.Backend)
+	}
+}
+
+// SetTelemetryArchive makes the client read telemetry files from the archive and archive the
+// files it downloads. Set it before the client is copied.
+func (c *Client) SetTelemetryArchive(archive *TelemetryArchive) {
+	c.archive = archive
+}
+
+// Open returns the compressed telemetry file of a match, or an error matching fs.ErrNotExist
+// if it hasn't been archived
+func (a *TelemetryArchive) Open(matchID string) (io.ReadCloser, error) {
+	return a.files.DownloadFile(a.fileName(matchID))
+}
+
+// Store archives the compressed telemetry file of a match
+func (a *TelemetryArchive) Store(matchID string, compressed io.Reader) error {
+	location, err := a.files.UploadFile(a.fileName(matchID), compressed)
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Str("match_id", matchID).Str("location", location).Msg("Archived telemetry file")
+	return nil
+}
+
+func (a *TelemetryArchive) fileName(matchID string) string {
+	return path.Join(a.prefix, matchID+".json.gz")
+}
+
+// telemetryMatchID finds the match ID in a telemetry URL, which ends in <match id>-telemetry.json.
+// URLs in another form are keyed by their hash.
+func telemetryMatchID(telemetryURL string) string {
+	if parsed, err := url.Parse(telemetryURL); err == nil {
+		name := path.Base(parsed.Path)
+		if matchID, ok := strings.CutSuffix(name, "-telemetry.json"); ok && matchID != "" {
+			return matchID
+		}
+	}
+
+	hash := sha256.Sum256([]byte(telemetryURL))
+	return hex.EncodeToString(hash[:])
+}
+
+// readArchivedTelemetry returns the uncompressed telemetry file from the archive
+func (c *Client) readArchivedTelemetry(matchID string) ([]byte, error) {
+	file, err := c.archive.Open(matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archived telemetry: %w", err)
+	}
+	defer gz.Close()
+
+	return io.ReadAll(gz)
+}
+
+// archiveTelemetry compresses a downloaded telemetry file and stores it. A failure is only logged,
+// as the file can still be downloaded again.
+func (c *Client) archiveTelemetry(matchID string, data []byte) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(data); err != nil {
+		log.Warn().Err(err).Str("match_id", matchID).Msg("Failed to compress telemetry for the archive")
+		return
+	}
+	if err := gz.Close(); err != nil {
+		log.Warn().Err(err).Str("match_id", matchID).Msg("Failed to compress telemetry for the archive")
+		return
+	}
+
+	if err := c.archive.Store(matchID, &compressed); err != nil {
+		log.Warn().Err(err).Str("match_id", matchID).Msg("Failed to archive telemetry")
+	}
+}
+
+// streamArchivedTelemetry runs the stream over an archived telemetry file. Returns an error
+// matching fs.ErrNotExist if the file hasn't been archived.
+func (c *Client) streamArchivedTelemetry(matchID string, stream *TelemetryStream) (TelemetryStreamStats, error) {
+	file, err := c.archive.Open(matchID)
+	if err != nil {
+		return TelemetryStreamStats{}, err
+	}
+	defer file.Close()
+
+	return stream.Run(file)
+}
+
+// telemetrySpool compresses a telemetry file to a temporary file while it is streamed, so it can
+// be archived once the whole file has been read without holding it in memory
+type telemetrySpool struct {
+	file *os.File
+	gz   *gzip.Writer
+}
+
+func newTelemetrySpool() (*telemetrySpool, error) {
+	file, err := os.CreateTemp("", "telemetry-*.json.gz")
+	if err != nil {
+		return nil, err
+	}
+
+	return &telemetrySpool{file: file, gz: gzip.NewWriter(file)}, nil
+}
+
+func (s *telemetrySpool) Write(p []byte) (int, error) {
+	return s.gz.Write(p)
+}
+
+// store archives the compressed file, which must have been read to the end
+func (s *telemetrySpool) store(archive *TelemetryArchive, matchID string) error {
+	if err := s.gz.Close(); err != nil {
+		return err
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return archive.Store(matchID, s.file)
+}
+
+// discard removes the temporary file
+func (s *telemetrySpool) discard() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
+
+// localFileStore keeps files in a directory, for development and tests
+type localFileStore struct {
+	directory string
+}
+
+// NewLocalFileStore creates a file store in a directory of the local filesystem
+func NewLocalFileStore(directory string) TelemetryFileStore {
+	return &localFileStore{directory: directory}
+}
+
+// UploadFile writes the file to a temporary file first, so a reader never sees a partial file
+func (s *localFileStore) UploadFile(fileName string, file io.Reader) (string, error) {
+	filePath := filepath.Join(s.directory, filepath.FromSlash(fileName))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, file); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return "", err
+	}
+
+	return "file://" + filepath.ToSlash(filePath), nil
+}
+
+func (s *localFileStore) DownloadFile(fileName string) (io.ReadCloser, error) {
+	// A missing file is reported as a *fs.PathError matching fs.ErrNotExist
+	file, err := os.Open(filepath.Join(s.directory, filepath.FromSlash(fileName)))
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
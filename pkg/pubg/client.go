@@ -4,11 +4,12 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"harvest/internal/cache"
 	"harvest/internal/config"
 	"io"
+	"io/fs"
 	"net/http"
 	"time"
 
@@ -17,14 +18,20 @@ import (
 
 // Client represents a PUBG API client
 type Client struct {
-	httpClient    *http.Client
-	apiKey        string
-	baseURL       string
-	OldEnoughMin  int
-	requestTicker *time.Ticker
-	requestChan   chan struct{}
-	cache         cache.Cache
-	defaultTTL    time.Duration
+	httpClient   *http.Client
+	baseURL      string
+	OldEnoughMin int
+	keys         *keyPool
+	cache        cache.Cache
+	defaultTTL   time.Duration
+
+	// Retries of transient failures and the delay before the first one
+	maxRetries int
+	retryDelay time.Duration
+	stats      *clientStats
+
+	// Downloaded telemetry files are kept here when set
+	archive *TelemetryArchive
 }
 
 const (
@@ -34,53 +41,53 @@ const (
 
 // New creates a new PUBG API client with rate limiting and caching
 func New(config config.PUBGConfig, cache cache.Cache) *Client {
-	// Calculate interval between requests
-	interval := time.Minute / time.Duration(config.RequestsPerMinute-1)
+	// Stay one request below the limit to leave room for clock drift
+	perMinute := config.RequestsPerMinute - 1
+
+	// Share the budget with the other replicas through Redis
+	var store RateLimitStore
+	if config.SharedRateLimit {
+		if shared, ok := cache.(RateLimitStore); ok {
+			store = shared
+		} else {
+			log.Warn().Msg("Shared rate limit needs the Redis cache, limiting requests locally")
+		}
+	}
+
+	// Every key has its own requests per minute
+	apiKeys := config.Keys()
 
 	log.Info().
+		Int("api_keys", len(apiKeys)).
 		Int("requests_per_minute", config.RequestsPerMinute).
-		Dur("request_interval", interval).
+		Int("burst", config.RateLimitBurst).
+		Bool("shared_rate_limit", store != nil).
+		Int("max_retries", config.MaxRetries).
 		Str("base_url", config.BaseURL).
 		Bool("cache_enabled", cache != nil).
 		Msg("Initializing PUBG API client")
 
-	// Create a ticker that ticks once per allowed request
-	ticker := time.NewTicker(interval)
-
-	// Create a buffered channel to handle request permissions
-	requestChan := make(chan struct{}, 1) // Buffer of 1 allows one immediate request
-
-	// Initialize the channel with one token
-	requestChan <- struct{}{}
-
-	// Start goroutine to add tokens at the specified rate
-	go func() {
-		for range ticker.C {
-			// Try to add a token, but don't block if buffer is full
-			select {
-			case requestChan <- struct{}{}:
-				// Token added
-			default:
-				// Buffer full, skip
-			}
-		}
-	}()
-
 	// If no default TTL is specified, set a reasonable default
 	defaultTTL := time.Duration(config.DefaultCacheTTL) * time.Minute
 	if defaultTTL == 0 {
 		defaultTTL = time.Duration(15) * time.Minute
 	}
 
+	retryDelay := time.Duration(config.RequestDelay) * time.Millisecond
+	if retryDelay <= 0 {
+		retryDelay = defaultRetryDelay
+	}
+
 	client := &Client{
-		httpClient:    &http.Client{Timeout: time.Second * 30},
-		apiKey:        config.APIKey,
-		baseURL:       config.BaseURL,
-		OldEnoughMin:  config.OldEnoughMin,
-		requestTicker: ticker,
-		requestChan:   requestChan,
-		cache:         cache,
-		defaultTTL:    defaultTTL,
+		httpClient:   &http.Client{Timeout: time.Second * 30},
+		baseURL:      config.BaseURL,
+		OldEnoughMin: config.OldEnoughMin,
+		keys:         newKeyPool(apiKeys, perMinute, config.RateLimitBurst, store),
+		cache:        cache,
+		defaultTTL:   defaultTTL,
+		maxRetries:   config.MaxRetries,
+		retryDelay:   retryDelay,
+		stats:        &clientStats{},
 	}
 
 	return client
@@ -96,7 +103,6 @@ func generateCacheKey(endpoint string) string {
 // with optional rate limiting and caching
 func (c *Client) request(ctx context.Context, endpoint string, shouldRateLimit bool) ([]byte, error) {
 	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
-	startTime := time.Now()
 
 	// Try to get from cache first if cache is enabled
 	if c.cache != nil {
@@ -119,9 +125,67 @@ func (c *Client) request(ctx context.Context, endpoint string, shouldRateLimit b
 		}
 	}
 
-	// Apply rate limiting if needed
-	if shouldRateLimit {
-		<-c.requestChan
+	respBody, err := c.do(ctx, url, "application/vnd.api+json", shouldRateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the response if cache is enabled
+	if c.cache != nil {
+		cacheKey := generateCacheKey(endpoint)
+		err := c.cache.Set(ctx, cacheKey, respBody, c.defaultTTL)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("endpoint", endpoint).
+				Msg("Failed to cache response")
+		}
+	}
+
+	return respBody, nil
+}
+
+// send makes a single attempt at a request and reads the response. Failures worth repeating are
+// marked with ErrTransient.
+func (c *Client) send(ctx context.Context, url string, accept string, shouldRateLimit bool) ([]byte, error) {
+	startTime := time.Now()
+
+	resp, err := c.open(ctx, url, accept, shouldRateLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read response
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("url", url).
+			Int("status_code", resp.StatusCode).
+			Msg("Error reading response body")
+		return nil, transientRequestError(ctx, fmt.Errorf("error reading response: %w", err))
+	}
+
+	// Log a single success entry with duration
+	log.Debug().
+		Str("url", url).
+		Int("status_code", resp.StatusCode).
+		Int("response_size", len(respBody)).
+		Dur("duration", time.Since(startTime)).
+		Msg("API request completed")
+
+	return respBody, nil
+}
+
+// open makes a single attempt at a request and returns the successful response with its body
+// still to be read. The caller must close the body. Failures worth repeating are marked with
+// ErrTransient.
+func (c *Client) open(ctx context.Context, url string, accept string, shouldRateLimit bool) (*http.Response, error) {
+	// Pick the next key, waiting for one with budget left if rate limiting applies
+	key, err := c.keys.acquire(ctx, shouldRateLimit)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create request with headers
@@ -137,21 +201,36 @@ func (c *Client) request(ctx context.Context, endpoint string, shouldRateLimit b
 	// Add the context to the request
 	req = req.WithContext(ctx)
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Accept", "application/vnd.api+json")
+	req.Header.Set("Authorization", "Bearer "+key.value)
+	req.Header.Set("Accept", accept)
 
 	// Execute request
+	c.stats.requests.Add(1)
+	key.requests.Add(1)
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		log.Error().
 			Err(err).
 			Str("url", url).
 			Msg("Error executing request")
-		return nil, fmt.Errorf("error making request: %w", err)
+		return nil, transientRequestError(ctx, fmt.Errorf("error making request: %w", err))
+	}
+
+	if shouldRateLimit {
+		key.limiter.Observe(resp)
+	}
+	key.observe(resp)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
 	}
 	defer resp.Body.Close()
 
-	// Read response
+	// Check for API errors
+	if resp.StatusCode == http.StatusTooManyRequests {
+		c.stats.rateLimited.Add(1)
+	}
+
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Error().
@@ -159,41 +238,16 @@ func (c *Client) request(ctx context.Context, endpoint string, shouldRateLimit b
 			Str("url", url).
 			Int("status_code", resp.StatusCode).
 			Msg("Error reading response body")
-		return nil, fmt.Errorf("error reading response: %w", err)
+		return nil, transientRequestError(ctx, fmt.Errorf("error reading response: %w", err))
 	}
 
-	// Check for API errors
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		apiErr := parseAPIError(resp.StatusCode, respBody)
-		log.Error().
-			Err(apiErr).
-			Str("url", url).
-			Int("status_code", resp.StatusCode).
-			Msg("API error response")
-		return nil, apiErr
-	}
-
-	// Cache the response if cache is enabled
-	if c.cache != nil {
-		cacheKey := generateCacheKey(endpoint)
-		err := c.cache.Set(ctx, cacheKey, respBody, c.defaultTTL)
-		if err != nil {
-			log.Warn().
-				Err(err).
-				Str("endpoint", endpoint).
-				Msg("Failed to cache response")
-		}
-	}
-
-	// Log a single success entry with duration
-	log.Debug().
-		Str("endpoint", endpoint).
+	apiErr := parseAPIError(resp, respBody)
+	log.Error().
+		Err(apiErr).
+		Str("url", url).
 		Int("status_code", resp.StatusCode).
-		Int("response_size", len(respBody)).
-		Dur("duration", time.Since(startTime)).
-		Msg("API request completed")
-
-	return respBody, nil
+		Msg("API error response")
+	return nil, apiErr
 }
 
 // Request is a backward-compatible function that always applies rate limiting
@@ -221,93 +275,126 @@ func (c *Client) RequestNonRateLimitedWithContext(ctx context.Context, endpoint
 	return c.request(ctx, endpoint, false)
 }
 
-// parseAPIError extracts error information from the API response
-func parseAPIError(statusCode int, respBody []byte) error {
-	var errResp struct {
-		Errors []struct {
-			Title  string `json:"title"`
-			Detail string `json:"detail"`
-		} `json:"errors"`
+// GetTelemetry retrieves a telemetry file with optional rate limiting, but no caching
+// as telemetry files are too large to cache efficiently. With a telemetry archive the file
+// is read from the archive if it has been downloaded before, and archived otherwise.
+func (c *Client) GetTelemetry(ctx context.Context, telemetryURL string, shouldRateLimit bool) ([]byte, error) {
+	if c.archive == nil {
+		return c.do(ctx, telemetryURL, "application/json", shouldRateLimit)
 	}
 
-	if err := json.Unmarshal(respBody, &errResp); err == nil && len(errResp.Errors) > 0 {
-		return fmt.Errorf("API error: %s - %s",
-			errResp.Errors[0].Title,
-			errResp.Errors[0].Detail)
+	matchID := telemetryMatchID(telemetryURL)
+	data, err := c.readArchivedTelemetry(matchID)
+	if err == nil {
+		log.Debug().Str("match_id", matchID).Int("response_size", len(data)).Msg("Telemetry archive hit")
+		return data, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		log.Warn().Err(err).Str("match_id", matchID).Msg("Telemetry archive error, falling back to API")
+	}
+
+	data, err = c.do(ctx, telemetryURL, "application/json", shouldRateLimit)
+	if err != nil {
+		return nil, err
 	}
 
-	return fmt.Errorf("API error: status code %d", statusCode)
+	c.archiveTelemetry(matchID, data)
+	return data, nil
 }
 
-// GetTelemetry retrieves a telemetry file with optional rate limiting, but no caching
-// as telemetry files are too large to cache efficiently
-func (c *Client) GetTelemetry(ctx context.Context, telemetryURL string, shouldRateLimit bool) ([]byte, error) {
+// StreamTelemetry downloads a telemetry file and feeds its events to the stream as they arrive,
+// without holding the file in memory. Opening the file is retried like any other request, but a
+// download that fails part way is returned as a transient error, as the handlers have already
+// seen some of the events. With a telemetry archive the file is read from the archive if it has
+// been downloaded before, and archived otherwise.
+func (c *Client) StreamTelemetry(ctx context.Context, telemetryURL string, shouldRateLimit bool, stream *TelemetryStream) (TelemetryStreamStats, error) {
 	startTime := time.Now()
 
-	// Apply rate limiting if needed
-	if shouldRateLimit {
-		<-c.requestChan
+	var matchID string
+	if c.archive != nil {
+		matchID = telemetryMatchID(telemetryURL)
+		stats, err := c.streamArchivedTelemetry(matchID, stream)
+		if err == nil {
+			log.Debug().Str("match_id", matchID).Int("events", stats.Events).Msg("Telemetry archive hit")
+			return stats, nil
+		}
+		if stats.Events > 0 {
+			// The handlers have seen part of the archived file, so it can't be read again from the API
+			return stats, fmt.Errorf("error reading archived telemetry: %w", err)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Warn().Err(err).Str("match_id", matchID).Msg("Telemetry archive error, falling back to API")
+		}
 	}
 
-	// Create the request
-	req, err := http.NewRequest(http.MethodGet, telemetryURL, nil)
+	var resp *http.Response
+	err := c.retry(ctx, telemetryURL, func() error {
+		var err error
+		resp, err = c.open(ctx, telemetryURL, "application/json", shouldRateLimit)
+		return err
+	})
 	if err != nil {
-		log.Error().
-			Err(err).
-			Str("telemetry_url", telemetryURL).
-			Msg("Error creating telemetry request")
-		return nil, fmt.Errorf("error creating telemetry request: %w", err)
+		return TelemetryStreamStats{}, err
 	}
+	defer resp.Body.Close()
 
-	// Add the context to the request
-	req = req.WithContext(ctx)
-
-	// Set the authorization header with your bearer token
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Accept", "application/json")
-
-	// Make the request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		log.Error().
-			Err(err).
-			Str("telemetry_url", telemetryURL).
-			Msg("Error executing telemetry request")
-		return nil, fmt.Errorf("error making telemetry request: %w", err)
+	body := &countingReader{reader: resp.Body}
+
+	// Compress the file for the archive as it is read
+	var source io.Reader = body
+	var spool *telemetrySpool
+	if c.archive != nil {
+		if spool, err = newTelemetrySpool(); err != nil {
+			log.Warn().Err(err).Str("match_id", matchID).Msg("Failed to create telemetry archive file")
+		} else {
+			defer spool.discard()
+			source = io.TeeReader(body, spool)
+		}
 	}
-	defer resp.Body.Close()
 
-	// Read response
-	respBody, err := io.ReadAll(resp.Body)
+	stats, err := stream.Run(source)
 	if err != nil {
-		log.Error().
-			Err(err).
-			Str("telemetry_url", telemetryURL).
-			Int("status_code", resp.StatusCode).
-			Msg("Error reading telemetry response")
-		return nil, fmt.Errorf("error reading telemetry response: %w", err)
+		if body.err != nil && body.err != io.EOF {
+			c.stats.failures.Add(1)
+			return stats, transientRequestError(ctx, fmt.Errorf("error reading telemetry: %w", body.err))
+		}
+		return stats, err
 	}
 
-	// Check for API errors
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		apiErr := parseAPIError(resp.StatusCode, respBody)
-		log.Error().
-			Err(apiErr).
-			Str("telemetry_url", telemetryURL).
-			Int("status_code", resp.StatusCode).
-			Msg("Telemetry API error response")
-		return nil, apiErr
+	if spool != nil {
+		// The decoder stops at the end of the array, copy anything after it so the archive is complete
+		if _, err := io.Copy(spool, body); err != nil {
+			log.Warn().Err(err).Str("match_id", matchID).Msg("Failed to read the end of the telemetry file")
+		} else if err := spool.store(c.archive, matchID); err != nil {
+			log.Warn().Err(err).Str("match_id", matchID).Msg("Failed to archive telemetry")
+		}
 	}
 
-	// Log a single success entry with duration
 	log.Debug().
-		Str("telemetry_url", telemetryURL).
+		Str("url", telemetryURL).
 		Int("status_code", resp.StatusCode).
-		Int("response_size", len(respBody)).
+		Int64("response_size", body.read).
+		Int("events", stats.Events).
 		Dur("duration", time.Since(startTime)).
-		Msg("Telemetry request completed")
+		Msg("Telemetry download completed")
 
-	return respBody, nil
+	return stats, nil
+}
+
+// countingReader counts the bytes read from a response body and keeps the error that ended it
+type countingReader struct {
+	reader io.Reader
+	read   int64
+	err    error
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if err != nil {
+		r.err = err
+	}
+	return n, err
 }
 
 // Backward compatibility methods for telemetry
@@ -325,14 +412,10 @@ func (c *Client) GetTelemetryWithContext(ctx context.Context, telemetryURL strin
 	return c.GetTelemetry(ctx, telemetryURL, shouldRateLimit)
 }
 
-// Close stops the ticker and closes the cache when the client is no longer needed
+// Close closes the cache when the client is no longer needed
 func (c *Client) Close() {
 	log.Info().Msg("Shutting down PUBG API client")
 
-	if c.requestTicker != nil {
-		c.requestTicker.Stop()
-	}
-
 	if c.cache != nil {
 		err := c.cache.Close()
 		if err != nil {
@@ -0,0 +1,123 @@
+package pubg
+
+import "math"
+
+// Fighter represents a player taking part in a combat event
+type Fighter struct {
+	Name      string   `json:"name" bson:"name"`
+	AccountID string   `json:"accountId" bson:"accountId"`
+	TeamID    int      `json:"teamId" bson:"teamId"`
+	Health    float64  `json:"health" bson:"health"`
+	Location  Position `json:"location" bson:"location"`
+}
+
+// DamageInfo describes what dealt the damage of a kill or knock
+type DamageInfo struct {
+	DamageReason       string   `json:"damageReason" bson:"damageReason"`
+	DamageTypeCategory string   `json:"damageTypeCategory" bson:"damageTypeCategory"`
+	DamageCauserName   string   `json:"damageCauserName" bson:"damageCauserName"`
+	AdditionalInfo     []string `json:"additionalInfo" bson:"additionalInfo"`
+	Distance           float64  `json:"distance" bson:"distance"`
+}
+
+// LogPlayerKillV2 represents a player being killed. The killer is nil for deaths to the zone or a fall,
+// and the knock fields are empty when the victim died without being knocked first.
+type LogPlayerKillV2 struct {
+	BaseEvent
+	Common           Common     `json:"common" bson:"common"`
+	AttackID         int        `json:"attackId" bson:"attackId"`
+	DBNOID           int        `json:"dBNOId" bson:"dBNOId"`
+	Victim           Fighter    `json:"victim" bson:"victim"`
+	DBNOMaker        *Fighter   `json:"dBNOMaker" bson:"dBNOMaker"`
+	DBNODamageInfo   DamageInfo `json:"dBNODamageInfo" bson:"dBNODamageInfo"`
+	Finisher         *Fighter   `json:"finisher" bson:"finisher"`
+	FinishDamageInfo DamageInfo `json:"finishDamageInfo" bson:"finishDamageInfo"`
+	Killer           *Fighter   `json:"killer" bson:"killer"`
+	KillerDamageInfo DamageInfo `json:"killerDamageInfo" bson:"killerDamageInfo"`
+	IsSuicide        bool       `json:"isSuicide" bson:"isSuicide"`
+}
+
+// LogPlayerTakeDamage represents a player taking damage. The attacker is nil for damage from the zone.
+type LogPlayerTakeDamage struct {
+	BaseEvent
+	Common             Common   `json:"common" bson:"common"`
+	AttackID           int      `json:"attackId" bson:"attackId"`
+	Attacker           *Fighter `json:"attacker" bson:"attacker"`
+	Victim             Fighter  `json:"victim" bson:"victim"`
+	DamageTypeCategory string   `json:"damageTypeCategory" bson:"damageTypeCategory"`
+	DamageReason       string   `json:"damageReason" bson:"damageReason"`
+	Damage             float64  `json:"damage" bson:"damage"`
+	DamageCauserName   string   `json:"damageCauserName" bson:"damageCauserName"`
+}
+
+// LogPlayerMakeGroggy represents a player being knocked
+type LogPlayerMakeGroggy struct {
+	BaseEvent
+	Common             Common   `json:"common" bson:"common"`
+	AttackID           int      `json:"attackId" bson:"attackId"`
+	Attacker           *Fighter `json:"attacker" bson:"attacker"`
+	Victim             Fighter  `json:"victim" bson:"victim"`
+	DamageReason       string   `json:"damageReason" bson:"damageReason"`
+	DamageTypeCategory string   `json:"damageTypeCategory" bson:"damageTypeCategory"`
+	DamageCauserName   string   `json:"damageCauserName" bson:"damageCauserName"`
+	Distance           float64  `json:"distance" bson:"distance"`
+	DBNOID             int      `json:"dBNOId" bson:"dBNOId"`
+}
+
+// LogPlayerRevive represents a knocked player being revived by a teammate
+type LogPlayerRevive struct {
+	BaseEvent
+	Common  Common  `json:"common" bson:"common"`
+	Reviver Fighter `json:"reviver" bson:"reviver"`
+	Victim  Fighter `json:"victim" bson:"victim"`
+	DBNOID  int     `json:"dBNOId" bson:"dBNOId"`
+}
+
+// CombatData holds the fights of a match extracted from its telemetry
+type CombatData struct {
+	Kills   []LogPlayerKillV2
+	Knocks  []LogPlayerMakeGroggy
+	Revives []LogPlayerRevive
+
+	// Damage dealt to other players by account ID, excluding self and zone damage
+	DamageDealt map[string]float64
+}
+
+// CollectCombatEvents registers the handlers that extract kills, knocks, revives and damage dealt.
+// Damage events are only summed up as there are too many of them to keep. The returned data is
+// filled in as the stream runs.
+func CollectCombatEvents(stream *TelemetryStream) *CombatData {
+	result := &CombatData{
+		Kills:       []LogPlayerKillV2{},
+		Knocks:      []LogPlayerMakeGroggy{},
+		Revives:     []LogPlayerRevive{},
+		DamageDealt: make(map[string]float64),
+	}
+
+	OnEvent(stream, "LogPlayerKillV2", func(event *LogPlayerKillV2) {
+		result.Kills = append(result.Kills, *event)
+	})
+
+	OnEvent(stream, "LogPlayerMakeGroggy", func(event *LogPlayerMakeGroggy) {
+		result.Knocks = append(result.Knocks, *event)
+	})
+
+	OnEvent(stream, "LogPlayerRevive", func(event *LogPlayerRevive) {
+		result.Revives = append(result.Revives, *event)
+	})
+
+	OnEvent(stream, "LogPlayerTakeDamage", func(event *LogPlayerTakeDamage) {
+		if event.Attacker == nil || event.Attacker.AccountID == "" || event.Attacker.AccountID == event.Victim.AccountID {
+			return
+		}
+		result.DamageDealt[event.Attacker.AccountID] += event.Damage
+	})
+
+	return result
+}
+
+// Phase returns the circle phase an event happened in, 0 before the first circle closes.
+// isGame 2.0 is the first real zone, as in the safe zones of TelemetryData.
+func (c Common) Phase() int {
+	return int(math.Max(0, math.Floor(c.IsGame)-1))
+}
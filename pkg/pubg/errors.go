@@ -0,0 +1,112 @@
+package pubg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrTransient marks failures that are likely to succeed when the request is repeated later,
+// such as rate limiting, server errors and network failures
+var ErrTransient = errors.New("transient PUBG API error")
+
+// IsTransient reports whether an error returned by the client is worth retrying later
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrTransient)
+}
+
+func isTransientStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// transientRequestError marks a failed request as transient unless it failed because the caller gave up
+func transientRequestError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrTransient, err)
+}
+
+// APIError is an error response of the PUBG API. Use errors.As to get it from an error returned
+// by the client. Rate limiting and server errors also match ErrTransient.
+type APIError struct {
+	StatusCode int
+	Title      string
+	Detail     string
+	RetryAfter time.Duration // How long the API asked to wait before trying again, if it said
+}
+
+func (e *APIError) Error() string {
+	if e.Title != "" {
+		return fmt.Sprintf("API error: %s - %s", e.Title, e.Detail)
+	}
+	return fmt.Sprintf("API error: status code %d", e.StatusCode)
+}
+
+// Is makes transient API errors match ErrTransient
+func (e *APIError) Is(target error) bool {
+	return target == ErrTransient && isTransientStatus(e.StatusCode)
+}
+
+// IsNotFound reports whether the API has no such resource, e.g. a match that expired
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsRateLimited reports whether the request was rejected by the API rate limit
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsUnauthorized reports whether the API key was rejected
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+func hasStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// parseAPIError extracts error information from the API response
+func parseAPIError(resp *http.Response, respBody []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: retryAfter(resp),
+	}
+
+	var errResp struct {
+		Errors []struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(respBody, &errResp); err == nil && len(errResp.Errors) > 0 {
+		apiErr.Title = errResp.Errors[0].Title
+		apiErr.Detail = errResp.Errors[0].Detail
+	}
+
+	return apiErr
+}
+
+// retryAfter reads the Retry-After header, or the rate limit reset of a 429 response
+func retryAfter(resp *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get(headerRetryAfter)); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if reset, err := strconv.ParseInt(resp.Header.Get(headerRateLimitReset), 10, 64); err == nil {
+			if wait := time.Until(time.Unix(reset, 0)); wait > 0 {
+				return wait
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,199 @@
+package pubg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// How long a key the API rejected as unauthorized is left out of the rotation
+const unauthorizedKeyQuarantine = 10 * time.Minute
+
+// KeyStats reports the usage of a single API key
+type KeyStats struct {
+	Name             string     `json:"name"` // Position and the last characters of the key
+	Requests         int64      `json:"requests"`
+	RateLimited      int64      `json:"rate_limited"`
+	Unauthorized     int64      `json:"unauthorized"`
+	QuarantinedUntil *time.Time `json:"quarantined_until,omitempty"`
+}
+
+// apiKey is a key of the pool with its own requests per minute budget
+type apiKey struct {
+	name    string
+	value   string
+	limiter *RateLimiter
+
+	requests     atomic.Int64
+	rateLimited  atomic.Int64
+	unauthorized atomic.Int64
+
+	quarantinedUntil time.Time
+	mu               sync.Mutex
+}
+
+func (k *apiKey) isQuarantined(now time.Time) bool {
+	return now.Before(k.quarantineEnds())
+}
+
+func (k *apiKey) quarantineEnds() time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.quarantinedUntil
+}
+
+// quarantine leaves the key out of the rotation until the given time
+func (k *apiKey) quarantine(until time.Time, reason string) {
+	k.mu.Lock()
+	if until.After(k.quarantinedUntil) {
+		k.quarantinedUntil = until
+	}
+	k.mu.Unlock()
+
+	log.Warn().Str("key", k.name).Str("reason", reason).Time("until", until).Msg("PUBG API key quarantined")
+}
+
+// observe counts a response and quarantines the key if the API rejected it
+func (k *apiKey) observe(resp *http.Response) {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		k.rateLimited.Add(1)
+		k.quarantine(rateLimitPauseUntil(resp), "rate limited")
+	case http.StatusUnauthorized:
+		k.unauthorized.Add(1)
+		k.quarantine(time.Now().Add(unauthorizedKeyQuarantine), "unauthorized")
+	}
+}
+
+func (k *apiKey) stats() KeyStats {
+	stats := KeyStats{
+		Name:         k.name,
+		Requests:     k.requests.Load(),
+		RateLimited:  k.rateLimited.Load(),
+		Unauthorized: k.unauthorized.Load(),
+	}
+
+	k.mu.Lock()
+	if time.Now().Before(k.quarantinedUntil) {
+		until := k.quarantinedUntil
+		stats.QuarantinedUntil = &until
+	}
+	k.mu.Unlock()
+
+	return stats
+}
+
+// keyPool spreads requests over several API keys in turn, skipping quarantined keys and keys
+// that have used up their requests per minute
+type keyPool struct {
+	keys []*apiKey
+	next atomic.Uint64
+}
+
+func newKeyPool(values []string, perMinute int, burst int, store RateLimitStore) *keyPool {
+	if len(values) == 0 {
+		log.Warn().Msg("No PUBG API key configured")
+		values = []string{""}
+	}
+
+	pool := &keyPool{keys: make([]*apiKey, 0, len(values))}
+	for i, value := range values {
+		pool.keys = append(pool.keys, &apiKey{
+			name:    keyName(i, value),
+			value:   value,
+			limiter: NewRateLimiter(value, perMinute, burst, store),
+		})
+	}
+	return pool
+}
+
+// keyName identifies a key in logs and stats without revealing it
+func keyName(index int, value string) string {
+	suffix := value
+	if len(suffix) > 6 {
+		suffix = suffix[len(suffix)-6:]
+	}
+	return fmt.Sprintf("key-%d (...%s)", index+1, suffix)
+}
+
+// acquire returns the key to send the next request with. For a rate limited request it blocks until
+// one of the keys has budget left, which is taken from it.
+func (p *keyPool) acquire(ctx context.Context, shouldRateLimit bool) (*apiKey, error) {
+	for {
+		keys := p.rotation()
+
+		if !shouldRateLimit {
+			return keys[0], nil
+		}
+
+		wait := time.Duration(-1)
+		for _, key := range keys {
+			keyWait := key.limiter.reserve(ctx)
+			if keyWait <= 0 {
+				return key, nil
+			}
+			if wait < 0 || keyWait < wait {
+				wait = keyWait
+			}
+		}
+
+		log.Debug().Dur("wait", wait).Msg("Waiting for PUBG API rate limit")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// rotation lists the keys that aren't quarantined, starting with the next key in turn. When every
+// key is quarantined the one released first is used.
+func (p *keyPool) rotation() []*apiKey {
+	now := time.Now()
+	start := int((p.next.Add(1) - 1) % uint64(len(p.keys)))
+
+	keys := make([]*apiKey, 0, len(p.keys))
+	for i := range p.keys {
+		key := p.keys[(start+i)%len(p.keys)]
+		if !key.isQuarantined(now) {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) > 0 {
+		return keys
+	}
+
+	first := p.keys[0]
+	for _, key := range p.keys[1:] {
+		if key.quarantineEnds().Before(first.quarantineEnds()) {
+			first = key
+		}
+	}
+	return []*apiKey{first}
+}
+
+// hasAvailable reports whether any key is out of quarantine
+func (p *keyPool) hasAvailable() bool {
+	now := time.Now()
+	for _, key := range p.keys {
+		if !key.isQuarantined(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *keyPool) stats() []KeyStats {
+	stats := make([]KeyStats, 0, len(p.keys))
+	for _, key := range p.keys {
+		stats = append(stats, key.stats())
+	}
+	return stats
+}
@@ -0,0 +1,124 @@
+package pubg
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// LogMatchEnd represents the end of a match with the final placement of every player
+type LogMatchEnd struct {
+	BaseEvent
+	Characters []MatchEndCharacter `json:"characters" bson:"characters"`
+}
+
+// MatchEndCharacter is a player at the end of a match. Newer telemetry wraps the character
+// with weapon details, older telemetry lists the characters themselves.
+type MatchEndCharacter struct {
+	Character Character `json:"character" bson:"character"`
+}
+
+func (c *MatchEndCharacter) UnmarshalJSON(data []byte) error {
+	var wrapped struct {
+		Character *Character `json:"character"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return err
+	}
+
+	if wrapped.Character != nil {
+		c.Character = *wrapped.Character
+		return nil
+	}
+	return json.Unmarshal(data, &c.Character)
+}
+
+// PlayerLanding is where a player first landed after jumping from the plane
+type PlayerLanding struct {
+	Name      string    `json:"name" bson:"name"`
+	AccountID string    `json:"accountId" bson:"accountId"`
+	Location  Position  `json:"location" bson:"location"`
+	LandedAt  time.Time `json:"landedAt" bson:"landedAt"`
+}
+
+// TeamLanding groups the landings of a roster. The center is the average landing location of its players.
+type TeamLanding struct {
+	TeamID    int             `json:"teamId" bson:"teamId"`
+	Placement int             `json:"placement" bson:"placement"` // 0 when the match end wasn't in the telemetry
+	Center    Position        `json:"center" bson:"center"`
+	Players   []PlayerLanding `json:"players" bson:"players"`
+}
+
+// LandingData collects the landings of every player of a match
+type LandingData struct {
+	landings   map[int][]PlayerLanding // By team ID
+	landed     map[string]bool         // Players seen landing, later landings are redeploys
+	placements map[int]int             // By team ID
+}
+
+// CollectTeamLandings registers the handlers that record where every player first landed and
+// the placement of their team. Call Teams once the stream has run.
+func CollectTeamLandings(stream *TelemetryStream) *LandingData {
+	result := &LandingData{
+		landings:   make(map[int][]PlayerLanding),
+		landed:     make(map[string]bool),
+		placements: make(map[int]int),
+	}
+
+	OnEvent(stream, "LogParachuteLanding", func(event *LogParachuteLanding) {
+		character := event.Character
+
+		playerKey := character.AccountID
+		if playerKey == "" {
+			playerKey = character.Name
+		}
+		if playerKey == "" || result.landed[playerKey] {
+			return
+		}
+		result.landed[playerKey] = true
+
+		result.landings[character.TeamID] = append(result.landings[character.TeamID], PlayerLanding{
+			Name:      character.Name,
+			AccountID: character.AccountID,
+			Location:  character.Location,
+			LandedAt:  event.Timestamp,
+		})
+	})
+
+	OnEvent(stream, "LogMatchEnd", func(event *LogMatchEnd) {
+		for _, player := range event.Characters {
+			if player.Character.Ranking > 0 {
+				result.placements[player.Character.TeamID] = player.Character.Ranking
+			}
+		}
+	})
+
+	return result
+}
+
+// Teams returns the landing of every team that had a player land, ordered by team ID
+func (d *LandingData) Teams() []TeamLanding {
+	teams := make([]TeamLanding, 0, len(d.landings))
+	for teamID, players := range d.landings {
+		var center Position
+		for _, player := range players {
+			center.X += player.Location.X
+			center.Y += player.Location.Y
+		}
+		center.X /= float64(len(players))
+		center.Y /= float64(len(players))
+
+		teams = append(teams, TeamLanding{
+			TeamID:    teamID,
+			Placement: d.placements[teamID],
+			Center:    center,
+			Players:   players,
+		})
+	}
+
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].TeamID < teams[j].TeamID
+	})
+
+	return teams
+}
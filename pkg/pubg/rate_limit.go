@@ -0,0 +1,204 @@
+package pubg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Rate limit headers returned by the PUBG API
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+)
+
+// How long to pause after a 429 response that doesn't say when the limit resets
+const defaultRateLimitPause = 10 * time.Second
+
+// RateLimitStore holds token buckets shared by every replica, so together they stay within
+// the requests per minute of an API key
+type RateLimitStore interface {
+	TakeRateLimitToken(ctx context.Context, key string, perMinute int, burst int) (time.Duration, error)
+	LimitRateLimitTokens(ctx context.Context, key string, remaining int, pausedUntil time.Time) error
+}
+
+// RateLimiter is a token bucket that refills at the requests per minute of an API key and holds
+// up to burst tokens. It follows the X-RateLimit headers of responses and pauses after a 429.
+type RateLimiter struct {
+	key       string
+	perMinute int
+	burst     int
+	local     *tokenBucket
+	store     RateLimitStore // Shared bucket, nil when the budget is only enforced locally
+}
+
+// NewRateLimiter creates a limiter for an API key. With a store the budget is shared through it,
+// falling back to the local bucket while the store is unavailable.
+func NewRateLimiter(apiKey string, perMinute int, burst int, store RateLimitStore) *RateLimiter {
+	if perMinute < 1 {
+		perMinute = 1
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	// Never store the key itself
+	hash := sha256.Sum256([]byte(apiKey))
+
+	return &RateLimiter{
+		key:       "pubg_rate_limit:" + hex.EncodeToString(hash[:8]),
+		perMinute: perMinute,
+		burst:     burst,
+		local:     newTokenBucket(perMinute, burst),
+		store:     store,
+	}
+}
+
+// Wait blocks until a request may be made or the context is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		wait := l.reserve(ctx)
+		if wait <= 0 {
+			return nil
+		}
+
+		log.Debug().Dur("wait", wait).Msg("Waiting for PUBG API rate limit")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before trying again
+func (l *RateLimiter) reserve(ctx context.Context) time.Duration {
+	if l.store != nil {
+		wait, err := l.store.TakeRateLimitToken(ctx, l.key, l.perMinute, l.burst)
+		if err == nil {
+			return wait
+		}
+
+		if ctx.Err() != nil {
+			return 0
+		}
+		log.Warn().Err(err).Msg("Shared PUBG rate limit unavailable, using the local limit")
+	}
+
+	return l.local.take(time.Now())
+}
+
+// Observe adjusts the limiter to the rate limit headers of a response. A 429 pauses every request
+// until the limit resets.
+func (l *RateLimiter) Observe(resp *http.Response) {
+	remaining := -1
+	if value, err := strconv.Atoi(resp.Header.Get(headerRateLimitRemaining)); err == nil {
+		remaining = value
+	}
+
+	var resetAt time.Time
+	if value, err := strconv.ParseInt(resp.Header.Get(headerRateLimitReset), 10, 64); err == nil {
+		resetAt = time.Unix(value, 0)
+	}
+
+	var pausedUntil time.Time
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		remaining = 0
+		pausedUntil = rateLimitPauseUntil(resp)
+		log.Warn().
+			Time("paused_until", pausedUntil).
+			Str("limit", resp.Header.Get(headerRateLimitLimit)).
+			Msg("PUBG API rate limit exceeded, pausing requests")
+	case remaining == 0 && !resetAt.IsZero():
+		pausedUntil = resetAt
+	case remaining < 0:
+		return
+	}
+
+	l.local.limit(remaining, pausedUntil)
+
+	if l.store != nil {
+		// A failure is logged by the store, the local bucket still holds the limit
+		l.store.LimitRateLimitTokens(context.Background(), l.key, remaining, pausedUntil)
+	}
+}
+
+// rateLimitPauseUntil works out when requests may resume after a 429
+func rateLimitPauseUntil(resp *http.Response) time.Time {
+	if wait := retryAfter(resp); wait > 0 {
+		return time.Now().Add(wait)
+	}
+
+	return time.Now().Add(defaultRateLimitPause)
+}
+
+// tokenBucket is the in-process token bucket used when no store is configured or it is unavailable
+type tokenBucket struct {
+	ratePerSecond float64
+	burst         float64
+	tokens        float64
+	updatedAt     time.Time
+	pausedUntil   time.Time
+	mu            sync.Mutex
+}
+
+func newTokenBucket(perMinute int, burst int) *tokenBucket {
+	return &tokenBucket{
+		ratePerSecond: float64(perMinute) / 60,
+		burst:         float64(burst),
+		tokens:        float64(burst),
+		updatedAt:     time.Now(),
+	}
+}
+
+// take removes a token and returns 0, or returns how long until a token is available
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Before(b.pausedUntil) {
+		return b.pausedUntil.Sub(now)
+	}
+
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration(math.Ceil((1 - b.tokens) / b.ratePerSecond * float64(time.Second)))
+}
+
+// limit caps the tokens to what the API reports as remaining and pauses until the given time
+func (b *tokenBucket) limit(remaining int, pausedUntil time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if remaining >= 0 && float64(remaining) < b.tokens {
+		b.tokens = float64(remaining)
+	}
+
+	if pausedUntil.After(b.pausedUntil) {
+		b.pausedUntil = pausedUntil
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.ratePerSecond)
+		b.updatedAt = now
+	}
+}
@@ -0,0 +1,200 @@
+package pubg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// closeTo allows for the rounding of the float token arithmetic
+func closeTo(got, want time.Duration) bool {
+	diff := got - want
+	return diff > -time.Millisecond && diff < time.Millisecond
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	type take struct {
+		at   time.Duration // Since the bucket was created
+		wait time.Duration // 0 when a token is taken
+	}
+
+	tests := []struct {
+		name      string
+		perMinute int
+		burst     int
+		takes     []take
+	}{
+		{
+			name:      "one request per second",
+			perMinute: 60,
+			burst:     1,
+			takes: []take{
+				{at: 0, wait: 0},
+				{at: 0, wait: time.Second},
+				{at: 500 * time.Millisecond, wait: 500 * time.Millisecond},
+				{at: time.Second, wait: 0},
+				{at: time.Second, wait: time.Second},
+			},
+		},
+		{
+			name:      "burst is available at once",
+			perMinute: 60,
+			burst:     3,
+			takes: []take{
+				{at: 0, wait: 0},
+				{at: 0, wait: 0},
+				{at: 0, wait: 0},
+				{at: 0, wait: time.Second},
+			},
+		},
+		{
+			name:      "refill is capped at the burst",
+			perMinute: 60,
+			burst:     2,
+			takes: []take{
+				{at: 0, wait: 0},
+				{at: 0, wait: 0},
+				{at: time.Minute, wait: 0},
+				{at: time.Minute, wait: 0},
+				{at: time.Minute, wait: time.Second},
+			},
+		},
+		{
+			name:      "slow rate",
+			perMinute: 10,
+			burst:     1,
+			takes: []take{
+				{at: 0, wait: 0},
+				{at: 0, wait: 6 * time.Second},
+				{at: 3 * time.Second, wait: 3 * time.Second},
+				{at: 6 * time.Second, wait: 0},
+			},
+		},
+		{
+			name:      "partial tokens add up",
+			perMinute: 120,
+			burst:     1,
+			takes: []take{
+				{at: 0, wait: 0},
+				{at: 250 * time.Millisecond, wait: 250 * time.Millisecond},
+				{at: 500 * time.Millisecond, wait: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := newTokenBucket(tt.perMinute, tt.burst)
+			start := bucket.updatedAt
+
+			for i, step := range tt.takes {
+				if got := bucket.take(start.Add(step.at)); !closeTo(got, step.wait) {
+					t.Errorf("take %d at %v = %v, want %v", i, step.at, got, step.wait)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenBucketLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining int
+		pause     time.Duration // From now, 0 for no pause
+		wantWait  bool
+	}{
+		{name: "remaining caps the tokens", remaining: 0, wantWait: true},
+		{name: "more remaining than tokens", remaining: 100, wantWait: false},
+		{name: "unknown remaining", remaining: -1, wantWait: false},
+		{name: "pause", remaining: -1, pause: time.Minute, wantWait: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := newTokenBucket(60, 5)
+
+			var pausedUntil time.Time
+			if tt.pause > 0 {
+				pausedUntil = time.Now().Add(tt.pause)
+			}
+			bucket.limit(tt.remaining, pausedUntil)
+
+			wait := bucket.take(time.Now())
+			if tt.wantWait && wait <= 0 {
+				t.Errorf("take = %v, want a wait", wait)
+			}
+			if !tt.wantWait && wait != 0 {
+				t.Errorf("take = %v, want a token", wait)
+			}
+			if tt.pause > 0 && (wait > tt.pause || wait < tt.pause-time.Second) {
+				t.Errorf("take = %v, want about %v", wait, tt.pause)
+			}
+		})
+	}
+}
+
+func TestTokenBucketPauseIsNotShortened(t *testing.T) {
+	bucket := newTokenBucket(60, 1)
+	now := time.Now()
+
+	bucket.limit(-1, now.Add(time.Minute))
+	bucket.limit(-1, now.Add(time.Second))
+
+	if wait := bucket.take(now); wait < 59*time.Second {
+		t.Errorf("take = %v, want the longer pause to hold", wait)
+	}
+}
+
+type fakeRateLimitStore struct {
+	wait time.Duration
+	err  error
+}
+
+func (s *fakeRateLimitStore) TakeRateLimitToken(ctx context.Context, key string, perMinute int, burst int) (time.Duration, error) {
+	return s.wait, s.err
+}
+
+func (s *fakeRateLimitStore) LimitRateLimitTokens(ctx context.Context, key string, remaining int, pausedUntil time.Time) error {
+	return s.err
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		store    RateLimitStore
+		wantWait bool // For the second request, the local bucket holds one token
+	}{
+		{name: "local bucket", store: nil, wantWait: true},
+		{name: "shared store", store: &fakeRateLimitStore{wait: 0}, wantWait: false},
+		{name: "shared store without tokens", store: &fakeRateLimitStore{wait: time.Second}, wantWait: true},
+		{name: "falls back to the local bucket", store: &fakeRateLimitStore{err: errors.New("redis down")}, wantWait: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter("key", 1, 1, tt.store)
+			ctx := context.Background()
+
+			limiter.reserve(ctx)
+			wait := limiter.reserve(ctx)
+			if tt.wantWait && wait <= 0 {
+				t.Errorf("reserve = %v, want a wait", wait)
+			}
+			if !tt.wantWait && wait != 0 {
+				t.Errorf("reserve = %v, want a token", wait)
+			}
+		})
+	}
+}
+
+func TestNewRateLimiterDefaults(t *testing.T) {
+	limiter := NewRateLimiter("secret-key", 0, 0, nil)
+
+	if limiter.perMinute != 1 || limiter.burst != 1 {
+		t.Errorf("perMinute, burst = %d, %d, want 1, 1", limiter.perMinute, limiter.burst)
+	}
+	if limiter.key == "" || limiter.key == "pubg_rate_limit:secret-key" {
+		t.Errorf("key = %q, want a hash of the API key", limiter.key)
+	}
+}
@@ -0,0 +1,126 @@
+package pubg
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Delay before the first retry when pubg.request_delay isn't set
+	defaultRetryDelay = time.Second
+
+	// Upper bound of the delay between two attempts
+	maxRetryDelay = 30 * time.Second
+)
+
+// ClientStats counts the requests a client has sent since it was created
+type ClientStats struct {
+	Requests    int64 `json:"requests"`     // Attempts sent to the API, retries included
+	Retries     int64 `json:"retries"`      // Attempts repeated after a transient failure
+	Failures    int64 `json:"failures"`     // Requests that failed, after any retries
+	RateLimited int64 `json:"rate_limited"` // 429 responses
+
+	Keys []KeyStats `json:"keys"`
+}
+
+// clientStats is shared by copies of a client, so it is held by pointer
+type clientStats struct {
+	requests    atomic.Int64
+	retries     atomic.Int64
+	failures    atomic.Int64
+	rateLimited atomic.Int64
+}
+
+// Stats returns the request counts of the client
+func (c *Client) Stats() ClientStats {
+	return ClientStats{
+		Requests:    c.stats.requests.Load(),
+		Retries:     c.stats.retries.Load(),
+		Failures:    c.stats.failures.Load(),
+		RateLimited: c.stats.rateLimited.Load(),
+		Keys:        c.keys.stats(),
+	}
+}
+
+// do sends a request and repeats it after transient failures, such as rate limiting, server
+// errors and network failures, up to maxRetries times. Other errors, like a 404, are returned
+// straight away.
+func (c *Client) do(ctx context.Context, url string, accept string, shouldRateLimit bool) ([]byte, error) {
+	var respBody []byte
+	err := c.retry(ctx, url, func() error {
+		var err error
+		respBody, err = c.send(ctx, url, accept, shouldRateLimit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return respBody, nil
+}
+
+// retry makes attempts at a request until one succeeds, fails with an error that isn't worth
+// repeating or maxRetries is used up
+func (c *Client) retry(ctx context.Context, url string, attemptRequest func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := attemptRequest()
+		if err == nil {
+			if attempt > 0 {
+				log.Info().Str("url", url).Int("retries", attempt).Msg("PUBG API request succeeded after retrying")
+			}
+			return nil
+		}
+
+		// A rejected key has been quarantined, so the retry can go out with another key right away
+		switchKey := (IsUnauthorized(err) || IsRateLimited(err)) && c.keys.hasAvailable()
+
+		if !(IsTransient(err) || switchKey) || attempt >= c.maxRetries || ctx.Err() != nil {
+			c.stats.failures.Add(1)
+			if attempt > 0 {
+				log.Error().Err(err).Str("url", url).Int("retries", attempt).Msg("PUBG API request failed after retrying")
+			}
+			return err
+		}
+
+		// Wait at least as long as the API asked for
+		delay := c.backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+		if switchKey {
+			delay = 0
+		}
+
+		c.stats.retries.Add(1)
+		log.Warn().
+			Err(err).
+			Str("url", url).
+			Int("attempt", attempt+1).
+			Int("max_retries", c.maxRetries).
+			Dur("delay", delay).
+			Msg("Retrying PUBG API request")
+
+		select {
+		case <-ctx.Done():
+			c.stats.failures.Add(1)
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// backoff returns the delay before the given retry: the retry delay doubled for every earlier
+// attempt, capped, with up to half of it taken off at random so replicas don't retry in step
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retryDelay << attempt
+	if delay > maxRetryDelay || delay <= 0 {
+		delay = maxRetryDelay
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,147 @@
+package pubg
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TelemetryHandler receives a raw telemetry event of the type it was registered for
+type TelemetryHandler func(event json.RawMessage) error
+
+// TelemetryStream decodes a telemetry file one event at a time and passes each event to the
+// handlers registered for its type, so a single pass can feed several extractors without
+// holding the whole file in memory
+type TelemetryStream struct {
+	handlers map[string][]TelemetryHandler
+}
+
+// TelemetryStreamStats counts the events a stream has read
+type TelemetryStreamStats struct {
+	Events  int // Events read from the file
+	Handled int // Events passed to at least one handler
+	Skipped int // Events with a registered type that a handler could not decode
+}
+
+// NewTelemetryStream creates a stream without handlers
+func NewTelemetryStream() *TelemetryStream {
+	return &TelemetryStream{
+		handlers: make(map[string][]TelemetryHandler),
+	}
+}
+
+// Handle registers a handler for the raw events of a type. Handlers of the same type are called
+// in the order they were registered. An error from a handler stops the stream.
+func (s *TelemetryStream) Handle(eventType string, handler TelemetryHandler) {
+	s.handlers[eventType] = append(s.handlers[eventType], handler)
+}
+
+// OnEvent registers a handler that receives the events of a type decoded into T. Events that
+// don't decode into T are skipped.
+func OnEvent[T any](s *TelemetryStream, eventType string, handler func(event *T)) {
+	s.Handle(eventType, func(data json.RawMessage) error {
+		var event T
+		if err := json.Unmarshal(data, &event); err != nil {
+			return errSkipEvent
+		}
+
+		handler(&event)
+		return nil
+	})
+}
+
+// errSkipEvent is returned by typed handlers for events that can't be decoded
+var errSkipEvent = errors.New("telemetry event skipped")
+
+// Run reads the events from r, which holds a JSON array of events and may be gzip compressed,
+// and dispatches them to the registered handlers
+func (s *TelemetryStream) Run(r io.Reader) (TelemetryStreamStats, error) {
+	var stats TelemetryStreamStats
+
+	reader, err := decompressTelemetry(r)
+	if err != nil {
+		return stats, err
+	}
+
+	decoder := json.NewDecoder(reader)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return stats, fmt.Errorf("failed to read telemetry data: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return stats, fmt.Errorf("telemetry data is not an array of events")
+	}
+
+	for decoder.More() {
+		var data json.RawMessage
+		if err := decoder.Decode(&data); err != nil {
+			return stats, fmt.Errorf("failed to decode telemetry event %d: %w", stats.Events, err)
+		}
+		stats.Events++
+
+		var event struct {
+			Type string `json:"_T"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil || event.Type == "" {
+			continue // Skip events without a type
+		}
+
+		handlers := s.handlers[event.Type]
+		if len(handlers) == 0 {
+			continue
+		}
+
+		handled := false
+		for _, handler := range handlers {
+			err := handler(data)
+			if errors.Is(err, errSkipEvent) {
+				continue
+			}
+			if err != nil {
+				return stats, fmt.Errorf("failed to handle %s event: %w", event.Type, err)
+			}
+			handled = true
+		}
+
+		if handled {
+			stats.Handled++
+		} else {
+			stats.Skipped++
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return stats, fmt.Errorf("failed to read telemetry data: %w", err)
+	}
+
+	log.Debug().
+		Int("total_events", stats.Events).
+		Int("handled_events", stats.Handled).
+		Int("skipped_events", stats.Skipped).
+		Msg("Telemetry stream completed")
+
+	return stats, nil
+}
+
+// decompressTelemetry returns a reader of the plain JSON, unpacking it if it is gzip compressed
+func decompressTelemetry(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+
+	magic, err := buffered.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		// Plain JSON, or too short for the decoder to make sense of either
+		return buffered, nil
+	}
+
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gzip telemetry data: %w", err)
+	}
+	return gz, nil
+}
@@ -0,0 +1,195 @@
+package pubg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip write returned error: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip close returned error: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+const streamTestEvents = `[
+	{"_T": "LogMatchStart", "_D": "2024-01-01T00:00:00Z"},
+	{"_T": "LogParachuteLanding", "_D": "2024-01-01T00:02:00Z", "character": {"name": "Player1", "accountId": "account.1", "teamId": 1, "location": {"x": 100, "y": 200}}},
+	{"_D": "2024-01-01T00:02:30Z"},
+	{"_T": "LogParachuteLanding", "_D": "2024-01-01T00:03:00Z", "character": {"name": "Player2", "accountId": "account.2", "teamId": 2, "location": {"x": 300, "y": 400}}},
+	{"_T": "LogMatchEnd", "_D": "2024-01-01T00:30:00Z", "characters": []}
+]`
+
+func TestTelemetryStreamRun(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name         string
+		input        []byte
+		failHandler  bool // Register a raw LogMatchEnd handler that returns an error
+		wantStats    TelemetryStreamStats
+		wantLandings []string
+		wantErr      bool
+	}{
+		{
+			name:         "plain json",
+			input:        []byte(streamTestEvents),
+			wantStats:    TelemetryStreamStats{Events: 5, Handled: 2},
+			wantLandings: []string{"Player1", "Player2"},
+		},
+		{
+			name:         "gzip compressed",
+			input:        gzipped(t, streamTestEvents),
+			wantStats:    TelemetryStreamStats{Events: 5, Handled: 2},
+			wantLandings: []string{"Player1", "Player2"},
+		},
+		{
+			name:         "leading whitespace",
+			input:        []byte("\n  " + streamTestEvents),
+			wantStats:    TelemetryStreamStats{Events: 5, Handled: 2},
+			wantLandings: []string{"Player1", "Player2"},
+		},
+		{
+			name: "event that doesn't decode is skipped",
+			input: []byte(`[
+				{"_T": "LogParachuteLanding", "character": "not an object"},
+				{"_T": "LogParachuteLanding", "character": {"name": "Player3"}}
+			]`),
+			wantStats:    TelemetryStreamStats{Events: 2, Handled: 1, Skipped: 1},
+			wantLandings: []string{"Player3"},
+		},
+		{
+			name:      "empty array",
+			input:     []byte(`[]`),
+			wantStats: TelemetryStreamStats{},
+		},
+		{
+			name:        "handler error stops the stream",
+			input:       []byte(streamTestEvents),
+			failHandler: true,
+			wantStats:   TelemetryStreamStats{Events: 5, Handled: 2},
+			wantErr:     true,
+		},
+		{name: "not an array", input: []byte(`{"_T": "LogMatchStart"}`), wantErr: true},
+		{name: "truncated array", input: []byte(`[{"_T": "LogMatchStart"}, {"_T": "Log`), wantErr: true},
+		{name: "missing closing bracket", input: []byte(`[{"_T": "LogMatchStart"}`), wantErr: true},
+		{name: "empty input", input: []byte{}, wantErr: true},
+		{name: "truncated gzip", input: gzipped(t, streamTestEvents)[:20], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewTelemetryStream()
+
+			landings := []string{}
+			OnEvent(stream, "LogParachuteLanding", func(event *LogParachuteLanding) {
+				landings = append(landings, event.Character.Name)
+			})
+
+			if tt.failHandler {
+				stream.Handle("LogMatchEnd", func(json.RawMessage) error {
+					return errHandler
+				})
+			}
+
+			stats, err := stream.Run(bytes.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Run succeeded with %+v, want an error", stats)
+				}
+				if tt.failHandler && !errors.Is(err, errHandler) {
+					t.Errorf("Run returned %v, want the handler error", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Run returned error: %v", err)
+			}
+
+			if !tt.wantErr || tt.failHandler {
+				if stats != tt.wantStats {
+					t.Errorf("stats = %+v, want %+v", stats, tt.wantStats)
+				}
+			}
+			if tt.wantLandings != nil && !reflect.DeepEqual(landings, tt.wantLandings) {
+				t.Errorf("landings = %v, want %v", landings, tt.wantLandings)
+			}
+		})
+	}
+}
+
+func TestTelemetryStreamHandlerOrder(t *testing.T) {
+	stream := NewTelemetryStream()
+
+	calls := []string{}
+	stream.Handle("LogMatchStart", func(json.RawMessage) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	stream.Handle("LogMatchStart", func(json.RawMessage) error {
+		calls = append(calls, "second")
+		return nil
+	})
+
+	stats, err := stream.Run(strings.NewReader(`[{"_T": "LogMatchStart"}]`))
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if want := []string{"first", "second"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if want := (TelemetryStreamStats{Events: 1, Handled: 1}); stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestDecompressTelemetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", input: []byte(`[{"_T": "LogMatchStart"}]`), want: `[{"_T": "LogMatchStart"}]`},
+		{name: "gzip", input: gzipped(t, `[{"_T": "LogMatchStart"}]`), want: `[{"_T": "LogMatchStart"}]`},
+		{name: "single byte", input: []byte{0x1f}, want: "\x1f"},
+		{name: "empty", input: []byte{}, want: ""},
+		{name: "gzip magic without a valid header", input: []byte{0x1f, 0x8b, 0x00}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := decompressTelemetry(bytes.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("decompressTelemetry succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decompressTelemetry returned error: %v", err)
+			}
+
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("reading the decompressed data returned error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decompressed data = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,13 @@
 package pubg
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"image"
 	"image/color"
 	"image/png"
+	"io"
 	"math"
 	"os"
 	"strings"
@@ -28,8 +29,11 @@ type Common struct {
 
 // Character represents player information
 type Character struct {
-	Location Position `json:"location" bson:"location"`
-	Name     string   `json:"name"`
+	Location  Position `json:"location" bson:"location"`
+	Name      string   `json:"name"`
+	AccountID string   `json:"accountId" bson:"accountId"`
+	TeamID    int      `json:"teamId" bson:"teamId"`
+	Ranking   int      `json:"ranking" bson:"ranking"` // Placement of the team, only set at the end of the match
 }
 
 // GameState represents the current state of game zones
@@ -58,6 +62,26 @@ type LogGameStatePeriodic struct {
 	GameState GameState `json:"gameState" bson:"gameState"`
 }
 
+// Vehicle represents vehicle information
+type Vehicle struct {
+	VehicleType string   `json:"vehicleType" bson:"vehicleType"`
+	VehicleID   string   `json:"vehicleId" bson:"vehicleId"`
+	Location    Position `json:"location" bson:"location"`
+}
+
+// LogParachuteLanding represents a player landing event
+type LogParachuteLanding struct {
+	BaseEvent
+	Character Character `json:"character" bson:"character"`
+}
+
+// LogVehicleLeave represents a player leaving a vehicle, including the plane
+type LogVehicleLeave struct {
+	BaseEvent
+	Character Character `json:"character" bson:"character"`
+	Vehicle   Vehicle   `json:"vehicle" bson:"vehicle"`
+}
+
 // PlanePath represents the airplane trajectory
 type PlanePath struct {
 	StartPoint Position `json:"startPoint" bson:"startPoint"`
@@ -78,94 +102,94 @@ type TelemetryData struct {
 	PlanePath PlanePath  `json:"planePath" bson:"planePath"`
 }
 
+// GetRotationPaths extracts the positions of the given players from the moment they land
 func GetRotationPaths(playerNames []string, telemData []byte) (*map[string][]Position, error) {
-	playerRotations := make(map[string][]Position, len(playerNames))
+	return GetRotationPathsFromReader(playerNames, bytes.NewReader(telemData))
+}
 
-	for _, playerName := range playerNames {
-		playerRotations[playerName] = nil
-	}
+// GetRotationPathsFromReader extracts the rotations of the given players from a telemetry file,
+// which may be gzip compressed, one event at a time
+func GetRotationPathsFromReader(playerNames []string, r io.Reader) (*map[string][]Position, error) {
+	stream := NewTelemetryStream()
+	playerRotations := CollectRotationPaths(stream, playerNames)
 
-	// Unmarshal the entire array of events into a slice of maps
-	var events []map[string]json.RawMessage
-	if err := json.Unmarshal(telemData, &events); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal telemetry data: %w", err)
+	stats, err := stream.Run(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process telemetry data: %w", err)
 	}
 
-	log.Info().Int("total_events", len(events)).Msg("Processing telemetry events")
-
-	for _, eventData := range events {
-		// Extract event type
-		var eventType string
-		if typeData, ok := eventData["_T"]; ok {
-			if err := json.Unmarshal(typeData, &eventType); err != nil {
-				continue
-			}
-		} else {
-			continue // Skip events without a type
-		}
-
-		// Process based on event type
-		switch eventType {
-		case "LogParachuteLanding":
-			// test
-			var character Character
+	log.Info().Int("total_events", stats.Events).Msg("Processed telemetry events")
 
-			characterData, hasCharacter := eventData["character"]
-			if !hasCharacter {
-				continue
-			}
+	return &playerRotations, nil
+}
 
-			if err := json.Unmarshal(characterData, &character); err != nil {
-				continue
-			}
+// CollectRotationPaths registers the handlers that record the positions of the given players
+// from the moment they land. The returned map is filled in as the stream runs.
+func CollectRotationPaths(stream *TelemetryStream, playerNames []string) map[string][]Position {
+	playerRotations := make(map[string][]Position, len(playerNames))
 
-			playerName := character.Name
+	for _, playerName := range playerNames {
+		playerRotations[playerName] = nil
+	}
 
-			_, ok := playerRotations[playerName]
+	OnEvent(stream, "LogParachuteLanding", func(event *LogParachuteLanding) {
+		if _, ok := playerRotations[event.Character.Name]; !ok {
+			// Player name not found in the player rotations
+			return
+		}
 
-			if !ok {
-				// Player name not found in the player rotations
-				continue
-			}
+		// Player landed move on
+		playerRotations[event.Character.Name] = make([]Position, 0, 100)
+	})
 
-			// Player landed move on
-			playerRotations[playerName] = make([]Position, 0, 100)
-		case "LogPlayerPosition":
-			// test
-			var character Character
+	OnEvent(stream, "LogPlayerPosition", func(event *LogPlayerPosition) {
+		playerName, playerLoc := event.Character.Name, event.Character.Location
 
-			characterData, hasCharacter := eventData["character"]
-			if !hasCharacter {
-				continue
-			}
+		playerPath, ok := playerRotations[playerName]
+		if !ok {
+			// Player name not found in the player rotations
+			return
+		}
 
-			if err := json.Unmarshal(characterData, &character); err != nil {
-				continue
-			}
+		if playerPath == nil {
+			// Player hasn't landed skip
+			return
+		}
 
-			playerName, playerLoc := character.Name, character.Location
+		playerRotations[playerName] = append(playerPath, playerLoc)
+	})
 
-			playerPath, ok := playerRotations[playerName]
+	return playerRotations
+}
 
-			if !ok {
-				// Player name not found in the player rotations
-				continue
-			}
+// ProcessTelemetry parses telemetry data from a JSON byte array and extracts relevant information
+func ProcessTelemetry(data []byte) (*TelemetryData, error) {
+	return ProcessTelemetryFromReader(bytes.NewReader(data))
+}
 
-			if playerPath == nil {
-				// Player hasn't landed skip
-				continue
-			}
+// ProcessTelemetryFromReader extracts the circles and plane path from a telemetry file, which may
+// be gzip compressed, one event at a time
+func ProcessTelemetryFromReader(r io.Reader) (*TelemetryData, error) {
+	stream := NewTelemetryStream()
+	result := CollectTelemetryData(stream)
 
-			playerRotations[playerName] = append(playerPath, playerLoc)
-		}
+	stats, err := stream.Run(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process telemetry data: %w", err)
 	}
 
-	return &playerRotations, nil
+	log.Info().
+		Int("total_events", stats.Events).
+		Int("safe_zones_found", len(result.SafeZones)).
+		Bool("plane_path_found", result.PlanePath != PlanePath{}).
+		Msg("Telemetry processing completed")
+
+	return result, nil
 }
 
-// ProcessTelemetry parses telemetry data from a JSON byte array and extracts relevant information
-func ProcessTelemetry(data []byte) (*TelemetryData, error) {
+// CollectTelemetryData registers the handlers that extract the circles and plane path. The
+// returned data is filled in as the stream runs.
+func CollectTelemetryData(stream *TelemetryStream) *TelemetryData {
 	// Initialize result structure
 	result := &TelemetryData{
 		SafeZones: []SafeZone{},
@@ -174,148 +198,72 @@ func ProcessTelemetry(data []byte) (*TelemetryData, error) {
 	// Use a map to track the index of each phase in our results array
 	phaseIndexMap := make(map[int]int)
 
-	// Unmarshal the entire array of events into a slice of maps
-	var events []map[string]json.RawMessage
-	if err := json.Unmarshal(data, &events); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal telemetry data: %w", err)
-	}
-
-	log.Info().Int("total_events", len(events)).Msg("Processing telemetry events")
-
-	// Process each event based on its type
-	for _, eventData := range events {
-		// Extract event type
-		var eventType string
-		if typeData, ok := eventData["_T"]; ok {
-			if err := json.Unmarshal(typeData, &eventType); err != nil {
-				continue
-			}
-		} else {
-			continue // Skip events without a type
+	OnEvent(stream, "LogVehicleLeave", func(event *LogVehicleLeave) {
+		// Check if this is the transport aircraft - only check vehicleType
+		if event.Vehicle.VehicleType != "TransportAircraft" {
+			return
 		}
 
-		// Process based on event type
-		switch eventType {
-		case "LogVehicleLeave": // Changed from LogPlayerPosition to LogVehicleRide to match the event type in your JSON
-			// Check if this event has vehicle data
-			vehicleData, hasVehicle := eventData["vehicle"]
-			if !hasVehicle {
-				continue
-			}
-
-			// Parse the vehicle data to check if it's the transport aircraft
-			var vehicle struct {
-				VehicleType string `json:"vehicleType"`
-				VehicleId   string `json:"vehicleId"`
-				Location    struct {
-					X float64 `json:"x"`
-					Y float64 `json:"y"`
-					Z float64 `json:"z"`
-				} `json:"location"`
-			}
+		// The character location is the plane position
+		location := event.Character.Location
 
-			if err := json.Unmarshal(vehicleData, &vehicle); err != nil {
-				continue
-			}
+		// If we don't have a start point yet, set it
+		if result.PlanePath.StartPoint == (Position{}) {
+			result.PlanePath.StartPoint = location
+			log.Info().
+				Float64("x", location.X).
+				Float64("y", location.Y).
+				Msg("Set plane path start point")
+		}
 
-			// Check if this is the transport aircraft - only check vehicleType
-			if vehicle.VehicleType == "TransportAircraft" {
-				// Extract character location as the plane position
-				var character struct {
-					Location struct {
-						X float64 `json:"x"`
-						Y float64 `json:"y"`
-						Z float64 `json:"z"`
-					} `json:"location"`
-				}
-
-				characterData, hasCharacter := eventData["character"]
-				if !hasCharacter {
-					continue
-				}
-
-				if err := json.Unmarshal(characterData, &character); err != nil {
-					continue
-				}
-
-				// If we don't have a start point yet, set it
-				if result.PlanePath.StartPoint == (Position{}) {
-					result.PlanePath.StartPoint = Position{
-						X: character.Location.X,
-						Y: character.Location.Y,
-					}
-					log.Info().
-						Float64("x", character.Location.X).
-						Float64("y", character.Location.Y).
-						Msg("Set plane path start point")
-				}
-
-				// Always update the end point with each new position
-				result.PlanePath.EndPoint = Position{
-					X: character.Location.X,
-					Y: character.Location.Y,
-				}
-				log.Debug().
-					Float64("x", character.Location.X).
-					Float64("y", character.Location.Y).
-					Msg("Updated plane path end point")
-			}
+		// Always update the end point with each new position
+		result.PlanePath.EndPoint = location
+		log.Debug().
+			Float64("x", location.X).
+			Float64("y", location.Y).
+			Msg("Updated plane path end point")
+	})
+
+	OnEvent(stream, "LogGameStatePeriodic", func(event *LogGameStatePeriodic) {
+		// Get the isGame value
+		isGame := event.Common.IsGame
+
+		// Adjust phase number: isGame 2.0 is actually the first real zone
+		if float64(int(isGame)) != isGame || isGame < 2.0 {
+			return
+		}
 
-		case "LogGameStatePeriodic":
-			var event LogGameStatePeriodic
-			eventBytes, err := json.Marshal(eventData)
-			if err != nil {
-				continue
-			}
+		// Convert isGame to actual phase (isGame 2.0 -> Phase 1)
+		phase := int(isGame) - 1
 
-			if err := json.Unmarshal(eventBytes, &event); err != nil {
-				continue
-			}
+		// Skip processing if the coordinates are exactly center of map (408000, 408000)
+		// which might indicate default values rather than actual gameplay position
+		if phase == 1 && event.GameState.SafetyZonePosition.X == 408000.0 && event.GameState.SafetyZonePosition.Y == 408000.0 {
+			log.Warn().Msg("Skipping Phase 1 with default center coordinates")
+			return
+		}
 
-			// Get the isGame value
-			isGame := event.Common.IsGame
-
-			// Adjust phase number: isGame 2.0 is actually the first real zone
-			var phase int
-			if float64(int(isGame)) == isGame && isGame >= 2.0 {
-				// Convert isGame to actual phase (isGame 2.0 -> Phase 1)
-				phase = int(isGame) - 1
-
-				// Skip processing if the coordinates are exactly center of map (408000, 408000)
-				// which might indicate default values rather than actual gameplay position
-				if phase == 1 && event.GameState.SafetyZonePosition.X == 408000.0 && event.GameState.SafetyZonePosition.Y == 408000.0 {
-					log.Warn().Msg("Skipping Phase 1 with default center coordinates")
-					continue
-				}
-
-				// Extract safe zone data for this phase
-				safeZone := SafeZone{
-					Phase:  phase,
-					X:      event.GameState.SafetyZonePosition.X,
-					Y:      event.GameState.SafetyZonePosition.Y,
-					Radius: event.GameState.SafetyZoneRadius,
-				}
-
-				// Check if we've seen this phase before
-				if index, found := phaseIndexMap[phase]; found {
-					// Update the existing entry with the latest values
-					result.SafeZones[index] = safeZone
-				} else {
-					// First time seeing this phase, add it to our results
-					result.SafeZones = append(result.SafeZones, safeZone)
-					// Store the index of this phase for future updates
-					phaseIndexMap[phase] = len(result.SafeZones) - 1
-				}
-			}
+		// Extract safe zone data for this phase
+		safeZone := SafeZone{
+			Phase:  phase,
+			X:      event.GameState.SafetyZonePosition.X,
+			Y:      event.GameState.SafetyZonePosition.Y,
+			Radius: event.GameState.SafetyZoneRadius,
 		}
-	}
 
-	log.Info().
-		Int("safe_zones_found", len(result.SafeZones)).
-		Bool("plane_path_found", result.PlanePath != PlanePath{}).
-		Msg("Telemetry processing completed")
+		// Check if we've seen this phase before
+		if index, found := phaseIndexMap[phase]; found {
+			// Update the existing entry with the latest values
+			result.SafeZones[index] = safeZone
+		} else {
+			// First time seeing this phase, add it to our results
+			result.SafeZones = append(result.SafeZones, safeZone)
+			// Store the index of this phase for future updates
+			phaseIndexMap[phase] = len(result.SafeZones) - 1
+		}
+	})
 
-	return result, nil
+	return result
 }
 
 func (c *Client) BuildRotationsFromTelemetryYRL(ctx context.Context, playerNames []string, telemetryURL string) (*map[string][]Position, error) {
@@ -323,23 +271,15 @@ func (c *Client) BuildRotationsFromTelemetryYRL(ctx context.Context, playerNames
 		Str("url", telemetryURL).
 		Msg("Processing telemetry data from URL")
 
-	// Get the telemetry data using the existing client method
-	telemetryData, err := c.GetTelemetry(ctx, telemetryURL, false)
-	if err != nil {
-		log.Error().
-			Str("url", telemetryURL).
-			Err(err).
-			Msg("Failed to fetch telemetry data")
-		return nil, fmt.Errorf("failed to fetch telemetry data: %w", err)
-	}
+	stream := NewTelemetryStream()
+	playerRotations := CollectRotationPaths(stream, playerNames)
 
-	log.Info().
-		Str("url", telemetryURL).
-		Int("data_size_kb", len(telemetryData)/1024).
-		Msg("Successfully downloaded telemetry data")
+	// Feed the events to the handlers while the telemetry data downloads
+	if err := c.streamTelemetryFromURL(ctx, telemetryURL, stream); err != nil {
+		return nil, err
+	}
 
-	// Process the telemetry data
-	return GetRotationPaths(playerNames, telemetryData)
+	return &playerRotations, nil
 }
 
 // ProcessTelemetryFromURL fetches and processes telemetry data from a URL
@@ -348,23 +288,39 @@ func (c *Client) ProcessTelemetryFromURL(ctx context.Context, telemetryURL strin
 		Str("url", telemetryURL).
 		Msg("Processing telemetry data from URL")
 
-	// Get the telemetry data using the existing client method
-	telemetryData, err := c.GetTelemetry(ctx, telemetryURL, false)
+	stream := NewTelemetryStream()
+	result := CollectTelemetryData(stream)
+
+	// Feed the events to the handlers while the telemetry data downloads
+	if err := c.streamTelemetryFromURL(ctx, telemetryURL, stream); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Int("safe_zones_found", len(result.SafeZones)).
+		Bool("plane_path_found", result.PlanePath != PlanePath{}).
+		Msg("Telemetry processing completed")
+
+	return result, nil
+}
+
+// streamTelemetryFromURL runs a stream over a telemetry file without rate limiting
+func (c *Client) streamTelemetryFromURL(ctx context.Context, telemetryURL string, stream *TelemetryStream) error {
+	stats, err := c.StreamTelemetry(ctx, telemetryURL, false, stream)
 	if err != nil {
 		log.Error().
 			Str("url", telemetryURL).
 			Err(err).
 			Msg("Failed to fetch telemetry data")
-		return nil, fmt.Errorf("failed to fetch telemetry data: %w", err)
+		return fmt.Errorf("failed to fetch telemetry data: %w", err)
 	}
 
 	log.Info().
 		Str("url", telemetryURL).
-		Int("data_size_kb", len(telemetryData)/1024).
-		Msg("Successfully downloaded telemetry data")
+		Int("total_events", stats.Events).
+		Msg("Successfully processed telemetry data")
 
-	// Process the telemetry data
-	return ProcessTelemetry(telemetryData)
+	return nil
 }
 
 // GetMatchTelemetry retrieves and processes telemetry data for a specific match